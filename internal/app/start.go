@@ -5,10 +5,13 @@ import (
 	pb "crypto_analyzer_auth_service/gen/go"
 	"crypto_analyzer_auth_service/internal/config"
 	"crypto_analyzer_auth_service/internal/controller"
+	"crypto_analyzer_auth_service/internal/infrastructure/gateway"
 	grpc2 "crypto_analyzer_auth_service/internal/infrastructure/grpc"
 	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
 	"crypto_analyzer_auth_service/internal/infrastructure/postgres"
 	redisInit "crypto_analyzer_auth_service/internal/infrastructure/redis"
+	"crypto_analyzer_auth_service/internal/infrastructure/secretbox"
 	"crypto_analyzer_auth_service/internal/service"
 	"crypto_analyzer_auth_service/internal/storage"
 	"errors"
@@ -17,6 +20,7 @@ import (
 	"google.golang.org/grpc"
 	"log"
 	"net"
+	"net/http"
 	"time"
 )
 
@@ -51,17 +55,89 @@ func Start(ctx context.Context) error {
 	defer redisClient.Close()
 
 	userStorage := storage.NewUserStorage(DB)
-	userSessionManager := storage.NewSessionManager(configMain.RedisCfg, redisClient)
-	userJWTManager := storage.NewJWTManager(configMain.JwtCfg)
+	userSessionManager := storage.NewSessionManager(configMain.RedisCfg, configMain.JwtCfg, redisClient)
+	userJWTManager, err := storage.NewJWTManager(ctx, configMain.JwtCfg)
+	if err != nil {
+		logger.Log.Error("failed to init jwt manager", zap.Error(err))
+		return fmt.Errorf("failed to init jwt manager: %w", err)
+	}
+
+	userPasswordHasher := storage.NewPasswordHasher(configMain.PasswordCfg)
+
+	var breachChecker storage.BreachCheckerInterface
+	if configMain.PasswordCfg.BreachFilePath != "" {
+		breachChecker, err = storage.NewBreachedPasswordsFile(configMain.PasswordCfg.BreachFilePath)
+		if err != nil {
+			logger.Log.Error("failed to init breached passwords checker", zap.Error(err))
+			return fmt.Errorf("failed to init breached passwords checker: %w", err)
+		}
+		defer breachChecker.Close()
+	}
+
+	passwordPolicy, err := service.NewPasswordPolicy(configMain.PasswordCfg, breachChecker)
+	if err != nil {
+		logger.Log.Error("failed to init password policy", zap.Error(err))
+		return fmt.Errorf("failed to init password policy: %w", err)
+	}
+
+	oneTimeTokens := storage.NewOneTimeTokenStorage(configMain.RedisCfg, redisClient)
+	userMailer, err := mailer.NewMailer(configMain.MailCfg)
+	if err != nil {
+		logger.Log.Error("failed to init mailer", zap.Error(err))
+		return fmt.Errorf("failed to init mailer: %w", err)
+	}
 
-	controllerService := service.NewService(userStorage, userSessionManager, userJWTManager)
+	var totpBox *secretbox.Box
+	if len(configMain.MFACfg.EncryptionKey) > 0 {
+		totpBox, err = secretbox.New(configMain.MFACfg.EncryptionKey)
+		if err != nil {
+			logger.Log.Error("failed to init totp secret encryption", zap.Error(err))
+			return fmt.Errorf("failed to init totp secret encryption: %w", err)
+		}
+	}
+
+	controllerService := service.NewService(service.Deps{
+		Storage:        userStorage,
+		Session:        userSessionManager,
+		JWTManager:     userJWTManager,
+		Hasher:         userPasswordHasher,
+		Tokens:         oneTimeTokens,
+		Attempts:       storage.NewLoginAttempts(configMain.RedisCfg, configMain.LockoutCfg, redisClient),
+		EmailLogins:    storage.NewEmailLoginStorage(configMain.RedisCfg, redisClient),
+		Mailer:         userMailer,
+		SecretBox:      totpBox,
+		PasswordPolicy: passwordPolicy,
+	}, configMain)
 	controllerMain := controller.NewController(controllerService, logger.Log)
 
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(grpc2.LoggerInterceptor),
+		grpc.ChainUnaryInterceptor(
+			grpc2.LoggerInterceptor,
+			grpc2.RateLimitInterceptor(storage.NewRateLimiter(configMain.RedisCfg, redisClient), configMain.RateCfg,
+				configMain.ProxyCfg, controllerService.UserIDFromContext),
+			grpc2.AuthorizationInterceptor(service.MethodPermissions, controllerMain.Authorize),
+		),
 	)
 	pb.RegisterAuthServiceServer(grpcServer, controllerMain)
 
+	gatewayConn, err := grpc2.ServeInProcess(grpcServer)
+	if err != nil {
+		logger.Log.Error("failed to init in-process grpc connection", zap.Error(err))
+		return fmt.Errorf("failed to init in-process grpc connection: %w", err)
+	}
+	defer gatewayConn.Close()
+
+	gatewayHandler, err := gateway.NewHandler(ctx, pb.NewAuthServiceClient(gatewayConn))
+	if err != nil {
+		logger.Log.Error("failed to init http gateway", zap.Error(err))
+		return fmt.Errorf("failed to init http gateway: %w", err)
+	}
+
+	httpServer := &http.Server{
+		Handler:           gatewayHandler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
 	var listener50051 net.Listener
 	listener50051, err = net.Listen("tcp", ":50051")
 	if err != nil {
@@ -69,10 +145,19 @@ func Start(ctx context.Context) error {
 		return fmt.Errorf("failed to init listener for port 50051: %w", err)
 	}
 
+	var listener8080 net.Listener
+	listener8080, err = net.Listen("tcp", ":8080")
+	if err != nil {
+		_ = listener50051.Close()
+		logger.Log.Error("failed to init listener for port 8080", zap.Error(err))
+		return fmt.Errorf("failed to init listener for port 8080: %w", err)
+	}
+
 	grpcErrorCh := make(chan error, 1)
+	httpErrorCh := make(chan error, 1)
 
 	go func() {
-		if err = grpcServer.Serve(listener50051); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		if err := grpcServer.Serve(listener50051); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 			logger.Log.Error("grpc server failed", zap.Error(err))
 			grpcErrorCh <- err
 			return
@@ -81,40 +166,58 @@ func Start(ctx context.Context) error {
 		grpcErrorCh <- nil
 	}()
 
-	select {
-	case <-ctx.Done():
-		logger.Log.Info("shutting down gRPC server...")
-
-		if err = listener50051.Close(); err != nil {
-			logger.Log.Error("failed to close listener", zap.Error(err))
+	go func() {
+		if err := httpServer.Serve(listener8080); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Error("http server failed", zap.Error(err))
+			httpErrorCh <- err
+			return
 		}
 
-		stoppedCh := make(chan struct{}, 1)
-
-		ctxShutdown, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer shutdownCancel()
-
-		go func() {
-			grpcServer.GracefulStop()
-			close(stoppedCh)
-		}()
+		httpErrorCh <- nil
+	}()
 
-		select {
-		case <-ctxShutdown.Done():
-			grpcServer.Stop()
-			logger.Log.Info("gRPC server force stopped")
-		case <-stoppedCh:
-			logger.Log.Info("gRPC server gracefully stopped")
-		}
+	var serveErr error
 
+	select {
+	case <-ctx.Done():
 	case err = <-grpcErrorCh:
 		if err != nil {
 			logger.Log.Error("grpc server error", zap.Error(err))
-			return fmt.Errorf("grpc server error: %w", err)
+			serveErr = fmt.Errorf("grpc server error: %w", err)
 		}
+	case err = <-httpErrorCh:
+		if err != nil {
+			logger.Log.Error("http server error", zap.Error(err))
+			serveErr = fmt.Errorf("http server error: %w", err)
+		}
+	}
+
+	logger.Log.Info("shutting down servers...")
+
+	ctxShutdown, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err = httpServer.Shutdown(ctxShutdown); err != nil {
+		logger.Log.Error("failed to shutdown http server", zap.Error(err))
+		_ = httpServer.Close()
+	} else {
+		logger.Log.Info("http server gracefully stopped")
+	}
+
+	stoppedCh := make(chan struct{}, 1)
+
+	go func() {
+		grpcServer.GracefulStop()
+		close(stoppedCh)
+	}()
 
-		return nil
+	select {
+	case <-ctxShutdown.Done():
+		grpcServer.Stop()
+		logger.Log.Info("gRPC server force stopped")
+	case <-stoppedCh:
+		logger.Log.Info("gRPC server gracefully stopped")
 	}
 
-	return nil
+	return serveErr
 }
@@ -2,10 +2,12 @@ package config
 
 import (
 	"crypto_analyzer_auth_service/internal/config/model"
+	"encoding/base64"
 	"fmt"
 	"github.com/joho/godotenv"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,6 +20,107 @@ func getEnv(key string) (string, error) {
 	return val, nil
 }
 
+func getEnvDefault(key, defaultValue string) string {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue
+	}
+
+	return val
+}
+
+func getEnvIntDefault(key string, defaultValue int) (int, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse env %s: %w", key, err)
+	}
+
+	return number, nil
+}
+
+func getEnvBoolDefault(key string, defaultValue bool) (bool, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
+	}
+
+	flag, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse env %s: %w", key, err)
+	}
+
+	return flag, nil
+}
+
+func getEnvDurationDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse env %s: %w", key, err)
+	}
+
+	return duration, nil
+}
+
+// loadLockoutPolicy читает политику неудачных входов с префиксом prefix (LOGIN_ACCOUNT, LOGIN_IP)
+func loadLockoutPolicy(prefix string, defaults model.LockoutPolicy) (model.LockoutPolicy, error) {
+	policy := defaults
+	var err error
+
+	if policy.BackoffAfter, err = getEnvIntDefault(prefix+"_BACKOFF_AFTER", defaults.BackoffAfter); err != nil {
+		return policy, err
+	}
+	if policy.BaseDelay, err = getEnvDurationDefault(prefix+"_BASE_DELAY", defaults.BaseDelay); err != nil {
+		return policy, err
+	}
+	if policy.MaxDelay, err = getEnvDurationDefault(prefix+"_MAX_DELAY", defaults.MaxDelay); err != nil {
+		return policy, err
+	}
+	if policy.LockoutAfter, err = getEnvIntDefault(prefix+"_LOCKOUT_AFTER", defaults.LockoutAfter); err != nil {
+		return policy, err
+	}
+	if policy.LockoutDuration, err = getEnvDurationDefault(prefix+"_LOCKOUT_DURATION", defaults.LockoutDuration); err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// loadRateLimitRule читает правило лимита запросов с префиксом prefix (RATE_LIMIT_DEFAULT, RATE_LIMIT_LOGIN)
+func loadRateLimitRule(prefix string, defaults model.RateLimitRule) (model.RateLimitRule, error) {
+	rule := defaults
+	var err error
+
+	if rule.Burst, err = getEnvIntDefault(prefix+"_BURST", defaults.Burst); err != nil {
+		return rule, err
+	}
+	if rule.Interval, err = getEnvDurationDefault(prefix+"_INTERVAL", defaults.Interval); err != nil {
+		return rule, err
+	}
+	rule.Key = getEnvDefault(prefix+"_KEY", defaults.Key)
+
+	if rule.Burst < 1 || rule.Interval <= 0 {
+		return rule, fmt.Errorf("%s_BURST must be at least 1 and %s_INTERVAL must be positive", prefix, prefix)
+	}
+
+	switch rule.Key {
+	case model.RateLimitByIP, model.RateLimitByUser, model.RateLimitByMethod:
+	default:
+		return rule, fmt.Errorf("unknown %s_KEY %q", prefix, rule.Key)
+	}
+
+	return rule, nil
+}
+
 func LoadConfig() (*model.Config, error) {
 	env := ".env"
 
@@ -78,6 +181,18 @@ func LoadConfig() (*model.Config, error) {
 		return nil, fmt.Errorf("failed to load redis config: %w", err)
 	}
 
+	var refreshPepperString string
+	refreshPepperString, err = getEnv("REDIS_REFRESH_PEPPER")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load redis config: %w", err)
+	}
+
+	cfgRedis.RefreshPepper = []byte(refreshPepperString)
+	cfgRedis.OneTimeTokenPrefix = getEnvDefault("REDIS_ONE_TIME_TOKEN_PREFIX", "one_time_token")
+	cfgRedis.LoginAttemptsPrefix = getEnvDefault("REDIS_LOGIN_ATTEMPTS_PREFIX", "login_attempts")
+	cfgRedis.RateLimitPrefix = getEnvDefault("REDIS_RATE_LIMIT_PREFIX", "rate_limit")
+	cfgRedis.EmailLoginPrefix = getEnvDefault("REDIS_EMAIL_LOGIN_PREFIX", "email_login")
+
 	var refreshExpirationString string
 	refreshExpirationString, err = getEnv("REDIS_REFRESH_EXPIRATION")
 	if err != nil {
@@ -105,13 +220,35 @@ func LoadConfig() (*model.Config, error) {
 
 	cfgJwt := &model.JwtConfig{}
 
-	secretKeyString, err := getEnv("JWT_SECRET_KEY")
+	cfgJwt.SigningAlgorithm = getEnvDefault("JWT_SIGNING_ALGORITHM", "HS256")
+
+	switch cfgJwt.SigningAlgorithm {
+	case "HS256":
+		// секрет можно хранить в файле, тогда его замена подхватывается без рестарта
+		cfgJwt.KeyPath = os.Getenv("JWT_SECRET_KEY_PATH")
+		if cfgJwt.KeyPath == "" {
+			var secretKeyString string
+			secretKeyString, err = getEnv("JWT_SECRET_KEY")
+			if err != nil {
+				return nil, fmt.Errorf("failed to load jwt config: %w", err)
+			}
+
+			cfgJwt.SecretKey = []byte(secretKeyString)
+		}
+	case "RS256", "EdDSA":
+		cfgJwt.KeyPath, err = getEnv("JWT_PRIVATE_KEY_PATH")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to load jwt config: unsupported signing algorithm %s", cfgJwt.SigningAlgorithm)
+	}
+
+	cfgJwt.KeyWatchInterval, err = time.ParseDuration(getEnvDefault("JWT_KEY_WATCH_INTERVAL", "30s"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load jwt config: %w", err)
 	}
 
-	cfgJwt.SecretKey = []byte(secretKeyString)
-
 	var accessTokenTtlString string
 	accessTokenTtlString, err = getEnv("JWT_ACCESS_TOKEN_TTL")
 	if err != nil {
@@ -137,9 +274,311 @@ func LoadConfig() (*model.Config, error) {
 		return nil, fmt.Errorf("failed to load jwt config: %w", err)
 	}
 
+	cfgPassword := &model.PasswordConfig{}
+
+	var argon2Memory uint64
+	argon2Memory, err = strconv.ParseUint(getEnvDefault("PASSWORD_ARGON2_MEMORY", "65536"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+
+	var argon2Time uint64
+	argon2Time, err = strconv.ParseUint(getEnvDefault("PASSWORD_ARGON2_TIME", "3"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+
+	var argon2Parallelism uint64
+	argon2Parallelism, err = strconv.ParseUint(getEnvDefault("PASSWORD_ARGON2_PARALLELISM", "2"), 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+
+	if argon2Memory == 0 || argon2Time == 0 || argon2Parallelism == 0 {
+		return nil, fmt.Errorf("failed to load password config: argon2 params must be positive")
+	}
+
+	cfgPassword.Argon2Memory = uint32(argon2Memory)
+	cfgPassword.Argon2Time = uint32(argon2Time)
+	cfgPassword.Argon2Parallelism = uint8(argon2Parallelism)
+
+	// по умолчанию политика совпадает с прежними жёсткими правилами
+	if cfgPassword.MinLength, err = getEnvIntDefault("PASSWORD_MIN_LENGTH", 8); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.MaxLength, err = getEnvIntDefault("PASSWORD_MAX_LENGTH", 128); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.RequireUpper, err = getEnvBoolDefault("PASSWORD_REQUIRE_UPPER", true); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.RequireLower, err = getEnvBoolDefault("PASSWORD_REQUIRE_LOWER", true); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.RequireDigit, err = getEnvBoolDefault("PASSWORD_REQUIRE_DIGIT", true); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.RequireSpecial, err = getEnvBoolDefault("PASSWORD_REQUIRE_SPECIAL", true); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.ForbidPersonalInfo, err = getEnvBoolDefault("PASSWORD_FORBID_PERSONAL_INFO", true); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.MinStrength, err = getEnvIntDefault("PASSWORD_MIN_STRENGTH", 2); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.MinStrength < 0 || cfgPassword.MinStrength > 4 {
+		return nil, fmt.Errorf("failed to load password config: PASSWORD_MIN_STRENGTH must be in 0..4")
+	}
+	if cfgPassword.MaxLength > 0 && cfgPassword.MaxLength < cfgPassword.MinLength {
+		return nil, fmt.Errorf("failed to load password config: PASSWORD_MAX_LENGTH is less than PASSWORD_MIN_LENGTH")
+	}
+
+	cfgPassword.BlocklistPath = os.Getenv("PASSWORD_BLOCKLIST_PATH")
+
+	cfgPassword.BreachFilePath = os.Getenv("PASSWORD_BREACH_FILE_PATH")
+	if cfgPassword.BreachMinCount, err = getEnvIntDefault("PASSWORD_BREACH_MIN_COUNT", 1); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.BreachMinCount < 1 {
+		return nil, fmt.Errorf("failed to load password config: PASSWORD_BREACH_MIN_COUNT must be positive")
+	}
+
+	if cfgPassword.HistorySize, err = getEnvIntDefault("PASSWORD_HISTORY_SIZE", 5); err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+	if cfgPassword.HistorySize < 0 {
+		return nil, fmt.Errorf("failed to load password config: PASSWORD_HISTORY_SIZE must not be negative")
+	}
+
+	cfgPassword.ResetTokenTTL, err = time.ParseDuration(getEnvDefault("PASSWORD_RESET_TOKEN_TTL", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load password config: %w", err)
+	}
+
+	cfgMail := &model.MailConfig{
+		Driver:       getEnvDefault("MAIL_DRIVER", "file"),
+		SMTPHost:     os.Getenv("MAIL_SMTP_HOST"),
+		SMTPPort:     getEnvDefault("MAIL_SMTP_PORT", "587"),
+		SMTPUsername: os.Getenv("MAIL_SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("MAIL_SMTP_PASSWORD"),
+		FilePath:     getEnvDefault("MAIL_FILE_PATH", "logs/mail.log"),
+		From:         getEnvDefault("MAIL_FROM", "no-reply@localhost"),
+		LinkBaseURL:  getEnvDefault("MAIL_LINK_BASE_URL", "http://localhost:3000"),
+	}
+
+	if cfgMail.Driver == "smtp" && cfgMail.SMTPHost == "" {
+		return nil, fmt.Errorf("failed to load mail config: MAIL_SMTP_HOST is required for smtp driver")
+	}
+
+	cfgEmail := &model.EmailVerificationConfig{
+		Mode: getEnvDefault("EMAIL_VERIFICATION_MODE", model.EmailVerificationClaim),
+	}
+	if cfgEmail.Mode != model.EmailVerificationClaim && cfgEmail.Mode != model.EmailVerificationBlock {
+		return nil, fmt.Errorf("failed to load email verification config: unknown EMAIL_VERIFICATION_MODE %q", cfgEmail.Mode)
+	}
+
+	cfgEmail.TokenTTL, err = time.ParseDuration(getEnvDefault("EMAIL_VERIFICATION_TOKEN_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load email verification config: %w", err)
+	}
+
+	cfgLockout := &model.LockoutConfig{}
+
+	cfgLockout.Account, err = loadLockoutPolicy("LOGIN_ACCOUNT", model.LockoutPolicy{
+		BackoffAfter:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lockout config: %w", err)
+	}
+
+	cfgLockout.IP, err = loadLockoutPolicy("LOGIN_IP", model.LockoutPolicy{
+		BackoffAfter:    20,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    100,
+		LockoutDuration: 15 * time.Minute,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lockout config: %w", err)
+	}
+
+	if cfgLockout.Window, err = getEnvDurationDefault("LOGIN_FAILURE_WINDOW", time.Hour); err != nil {
+		return nil, fmt.Errorf("failed to load lockout config: %w", err)
+	}
+
+	cfgAdmin := &model.AdminConfig{
+		Token: os.Getenv("ADMIN_API_TOKEN"),
+	}
+
+	cfgProxy := &model.ProxyConfig{}
+	if cfgProxy.TrustedHops, err = getEnvIntDefault("TRUSTED_PROXY_HOPS", 0); err != nil {
+		return nil, fmt.Errorf("failed to load proxy config: %w", err)
+	}
+	if cfgProxy.TrustedHops < 0 {
+		return nil, fmt.Errorf("failed to load proxy config: TRUSTED_PROXY_HOPS must not be negative")
+	}
+
+	cfgRate := &model.RateLimitConfig{Methods: make(map[string]model.RateLimitRule)}
+
+	if cfgRate.Enabled, err = getEnvBoolDefault("RATE_LIMIT_ENABLED", true); err != nil {
+		return nil, fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
+	cfgRate.Default, err = loadRateLimitRule("RATE_LIMIT_DEFAULT", model.RateLimitRule{
+		Burst:    50,
+		Interval: 100 * time.Millisecond,
+		Key:      model.RateLimitByIP,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
+	cfgRate.Methods["Register"], err = loadRateLimitRule("RATE_LIMIT_REGISTER", model.RateLimitRule{
+		Burst:    5,
+		Interval: time.Minute,
+		Key:      model.RateLimitByIP,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
+	cfgRate.Methods["Login"], err = loadRateLimitRule("RATE_LIMIT_LOGIN", model.RateLimitRule{
+		Burst:    10,
+		Interval: 6 * time.Second,
+		Key:      model.RateLimitByIP,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
+	cfgRate.Methods["StartEmailLogin"], err = loadRateLimitRule("RATE_LIMIT_START_EMAIL_LOGIN", model.RateLimitRule{
+		Burst:    5,
+		Interval: time.Minute,
+		Key:      model.RateLimitByIP,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
+	cfgRate.Methods["LoginWithWallet"], err = loadRateLimitRule("RATE_LIMIT_LOGIN_WITH_WALLET", model.RateLimitRule{
+		Burst:    10,
+		Interval: 6 * time.Second,
+		Key:      model.RateLimitByIP,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
+	cfgMFA := &model.MFAConfig{
+		Issuer: getEnvDefault("MFA_TOTP_ISSUER", "Crypto Analyzer"),
+	}
+
+	if encryptionKey := os.Getenv("MFA_TOTP_ENCRYPTION_KEY"); encryptionKey != "" {
+		cfgMFA.EncryptionKey, err = base64.StdEncoding.DecodeString(encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mfa config: MFA_TOTP_ENCRYPTION_KEY must be base64: %w", err)
+		}
+		if len(cfgMFA.EncryptionKey) != 32 {
+			return nil, fmt.Errorf("failed to load mfa config: MFA_TOTP_ENCRYPTION_KEY must be 32 bytes")
+		}
+	}
+
+	if cfgMFA.ChallengeTTL, err = getEnvDurationDefault("MFA_CHALLENGE_TTL", 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("failed to load mfa config: %w", err)
+	}
+	if cfgMFA.TOTPSkew, err = getEnvIntDefault("MFA_TOTP_SKEW", 1); err != nil {
+		return nil, fmt.Errorf("failed to load mfa config: %w", err)
+	}
+	if cfgMFA.RecoveryCodes, err = getEnvIntDefault("MFA_RECOVERY_CODES", 10); err != nil {
+		return nil, fmt.Errorf("failed to load mfa config: %w", err)
+	}
+	if cfgMFA.TOTPSkew < 0 || cfgMFA.RecoveryCodes < 1 {
+		return nil, fmt.Errorf("failed to load mfa config: MFA_TOTP_SKEW must not be negative, MFA_RECOVERY_CODES must be at least 1")
+	}
+
+	cfgWebAuthn := &model.WebAuthnConfig{
+		RPID:   os.Getenv("WEBAUTHN_RP_ID"),
+		RPName: getEnvDefault("WEBAUTHN_RP_NAME", "Crypto Analyzer"),
+	}
+
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfgWebAuthn.Origins = append(cfgWebAuthn.Origins, origin)
+		}
+	}
+
+	if cfgWebAuthn.ChallengeTTL, err = getEnvDurationDefault("WEBAUTHN_CHALLENGE_TTL", 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("failed to load webauthn config: %w", err)
+	}
+	if cfgWebAuthn.RPID != "" && len(cfgWebAuthn.Origins) == 0 {
+		return nil, fmt.Errorf("failed to load webauthn config: WEBAUTHN_ORIGINS must be set with WEBAUTHN_RP_ID")
+	}
+
+	cfgEmailLogin := &model.EmailLoginConfig{
+		LinkSecret: []byte(getEnvDefault("EMAIL_LOGIN_LINK_SECRET", refreshPepperString)),
+	}
+
+	if cfgEmailLogin.CodeTTL, err = getEnvDurationDefault("EMAIL_LOGIN_CODE_TTL", 10*time.Minute); err != nil {
+		return nil, fmt.Errorf("failed to load email login config: %w", err)
+	}
+	if cfgEmailLogin.MaxAttempts, err = getEnvIntDefault("EMAIL_LOGIN_MAX_ATTEMPTS", 5); err != nil {
+		return nil, fmt.Errorf("failed to load email login config: %w", err)
+	}
+	if cfgEmailLogin.MaxAttempts < 1 {
+		return nil, fmt.Errorf("failed to load email login config: EMAIL_LOGIN_MAX_ATTEMPTS must be at least 1")
+	}
+
+	cfgSiwe := &model.SiweConfig{
+		Domain: os.Getenv("SIWE_DOMAIN"),
+	}
+
+	for _, chainID := range strings.Split(getEnvDefault("SIWE_CHAIN_IDS", "1"), ",") {
+		if chainID = strings.TrimSpace(chainID); chainID == "" {
+			continue
+		}
+
+		var id int64
+		if id, err = strconv.ParseInt(chainID, 10, 64); err != nil || id < 1 {
+			return nil, fmt.Errorf("failed to load siwe config: invalid SIWE_CHAIN_IDS entry %q", chainID)
+		}
+		cfgSiwe.ChainIDs = append(cfgSiwe.ChainIDs, id)
+	}
+
+	if cfgSiwe.NonceTTL, err = getEnvDurationDefault("SIWE_NONCE_TTL", 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("failed to load siwe config: %w", err)
+	}
+	if cfgSiwe.MaxWallets, err = getEnvIntDefault("SIWE_MAX_WALLETS", 10); err != nil {
+		return nil, fmt.Errorf("failed to load siwe config: %w", err)
+	}
+	if cfgSiwe.MaxWallets < 1 {
+		return nil, fmt.Errorf("failed to load siwe config: SIWE_MAX_WALLETS must be at least 1")
+	}
+	if cfgSiwe.WalletsClaim, err = getEnvBoolDefault("SIWE_WALLETS_CLAIM", false); err != nil {
+		return nil, fmt.Errorf("failed to load siwe config: %w", err)
+	}
+	if cfgSiwe.Domain != "" && len(cfgSiwe.ChainIDs) == 0 {
+		return nil, fmt.Errorf("failed to load siwe config: SIWE_CHAIN_IDS must be set with SIWE_DOMAIN")
+	}
+
 	return &model.Config{
-		PostgresCfg: cfgPostgres,
-		RedisCfg:    cfgRedis,
-		JwtCfg:      cfgJwt,
+		PostgresCfg:   cfgPostgres,
+		RedisCfg:      cfgRedis,
+		JwtCfg:        cfgJwt,
+		PasswordCfg:   cfgPassword,
+		MailCfg:       cfgMail,
+		EmailCfg:      cfgEmail,
+		LockoutCfg:    cfgLockout,
+		AdminCfg:      cfgAdmin,
+		ProxyCfg:      cfgProxy,
+		RateCfg:       cfgRate,
+		MFACfg:        cfgMFA,
+		WebAuthnCfg:   cfgWebAuthn,
+		EmailLoginCfg: cfgEmailLogin,
+		SiweCfg:       cfgSiwe,
 	}, nil
 }
@@ -3,9 +3,20 @@ package model
 import "time"
 
 type Config struct {
-	PostgresCfg *PostgresConfig
-	RedisCfg    *RedisConfig
-	JwtCfg      *JwtConfig
+	PostgresCfg   *PostgresConfig
+	RedisCfg      *RedisConfig
+	JwtCfg        *JwtConfig
+	PasswordCfg   *PasswordConfig
+	MailCfg       *MailConfig
+	EmailCfg      *EmailVerificationConfig
+	LockoutCfg    *LockoutConfig
+	AdminCfg      *AdminConfig
+	ProxyCfg      *ProxyConfig
+	RateCfg       *RateLimitConfig
+	MFACfg        *MFAConfig
+	WebAuthnCfg   *WebAuthnConfig
+	EmailLoginCfg *EmailLoginConfig
+	SiweCfg       *SiweConfig
 }
 
 type PostgresConfig struct {
@@ -22,11 +33,160 @@ type RedisConfig struct {
 	Password          string
 	SessionDB         int
 	RefreshPrefix     string
+	RefreshPepper     []byte
 	RefreshExpiration time.Duration
+
+	OneTimeTokenPrefix  string
+	LoginAttemptsPrefix string
+	RateLimitPrefix     string
+	EmailLoginPrefix    string
 }
 
 type JwtConfig struct {
+	SigningAlgorithm  string
 	SecretKey         []byte
+	KeyPath           string
+	KeyWatchInterval  time.Duration
 	AccessTokenTTL    time.Duration
 	RefreshTokenBytes int
 }
+
+// PasswordConfig — параметры argon2id (память в KiB, число проходов и потоков) и политика новых паролей
+type PasswordConfig struct {
+	Argon2Memory      uint32
+	Argon2Time        uint32
+	Argon2Parallelism uint8
+
+	MinLength          int
+	MaxLength          int
+	RequireUpper       bool
+	RequireLower       bool
+	RequireDigit       bool
+	RequireSpecial     bool
+	ForbidPersonalInfo bool
+	MinStrength        int
+	BlocklistPath      string
+	BreachFilePath     string
+	BreachMinCount     int
+	HistorySize        int
+	ResetTokenTTL      time.Duration
+}
+
+// MailConfig — доставка писем (smtp или file) и адрес фронтенда для ссылок в письмах
+type MailConfig struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	FilePath     string
+	LinkBaseURL  string
+}
+
+// Режимы EMAIL_VERIFICATION_MODE
+const (
+	// EmailVerificationClaim — вход разрешён, в access токене claim email_verified
+	EmailVerificationClaim = "claim"
+	// EmailVerificationBlock — токены выдаются только после подтверждения email
+	EmailVerificationBlock = "block"
+)
+
+type EmailVerificationConfig struct {
+	Mode     string
+	TokenTTL time.Duration
+}
+
+// LockoutPolicy — реакция на неудачные попытки входа: после BackoffAfter неудач каждая следующая
+// откладывает вход на BaseDelay, удваивая задержку до MaxDelay; после LockoutAfter неудач (0 — никогда)
+// вход блокируется на LockoutDuration
+type LockoutPolicy struct {
+	BackoffAfter    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+}
+
+// LockoutConfig — политики для аккаунта и для адреса клиента; Window — сколько помнить неудачи
+type LockoutConfig struct {
+	Account LockoutPolicy
+	IP      LockoutPolicy
+	Window  time.Duration
+}
+
+// AdminConfig — Token для административных методов (metadata x-admin-token) в обход ролей; пустой отключает вход по токену
+type AdminConfig struct {
+	Token string
+}
+
+// ProxyConfig — TrustedHops: сколько доверенных прокси стоит перед HTTP шлюзом,
+// по нему из x-forwarded-for выбирается адрес клиента
+type ProxyConfig struct {
+	TrustedHops int
+}
+
+// Ключи, по которым считается лимит запросов
+const (
+	// RateLimitByIP — адрес клиента
+	RateLimitByIP = "ip"
+	// RateLimitByUser — пользователь из access токена, без токена — адрес клиента
+	RateLimitByUser = "user"
+	// RateLimitByMethod — один общий бюджет на метод
+	RateLimitByMethod = "method"
+)
+
+// RateLimitRule — token bucket: ёмкость Burst запросов, один токен восстанавливается за Interval.
+// Key — по чему считается бюджет (RateLimitByIP, RateLimitByUser, RateLimitByMethod)
+type RateLimitRule struct {
+	Burst    int
+	Interval time.Duration
+	Key      string
+}
+
+// RateLimitConfig — Default для всех методов и отдельные правила в Methods по имени метода (Register, Login)
+type RateLimitConfig struct {
+	Enabled bool
+	Default RateLimitRule
+	Methods map[string]RateLimitRule
+}
+
+// MFAConfig — двухфакторная аутентификация. EncryptionKey (32 байта) шифрует TOTP секреты в базе,
+// пустой отключает 2FA. ChallengeTTL — сколько живёт токен MFA челленджа после верного пароля,
+// TOTPSkew — сколько соседних 30-секундных шагов принимается из-за расхождения часов
+type MFAConfig struct {
+	Issuer        string
+	EncryptionKey []byte
+	ChallengeTTL  time.Duration
+	TOTPSkew      int
+	RecoveryCodes int
+}
+
+// WebAuthnConfig — вход по ключам WebAuthn (passkey). RPID — домен, к которому привязываются ключи
+// (пустой отключает WebAuthn), Origins — адреса фронтенда, с которых разрешены церемонии,
+// ChallengeTTL — сколько живёт челлендж между началом и завершением церемонии
+type WebAuthnConfig struct {
+	RPID         string
+	RPName       string
+	Origins      []string
+	ChallengeTTL time.Duration
+}
+
+// EmailLoginConfig — вход без пароля по коду или ссылке из письма. CodeTTL — сколько живут код и ссылка,
+// MaxAttempts — неверных кодов до сгорания входа, LinkSecret — ключ HMAC подписи ссылки
+type EmailLoginConfig struct {
+	CodeTTL     time.Duration
+	MaxAttempts int
+	LinkSecret  []byte
+}
+
+// SiweConfig — вход через кошелёк Ethereum (EIP-4361) и привязка адресов. Domain — домен фронтенда, который должен
+// стоять в подписанном сообщении (пустой отключает вход и привязку), ChainIDs — разрешённые сети, NonceTTL — сколько
+// живёт nonce, MaxWallets — сколько адресов можно привязать к аккаунту, WalletsClaim — выпускать claim wallets
+type SiweConfig struct {
+	Domain       string
+	ChainIDs     []int64
+	NonceTTL     time.Duration
+	MaxWallets   int
+	WalletsClaim bool
+}
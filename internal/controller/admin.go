@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.UnlockAccountResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "unlock_account"))
+
+	log.Info("request started")
+
+	resp, err := c.service.UnlockAccount(ctx, req)
+	if err != nil {
+		log.Error("unlock account failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
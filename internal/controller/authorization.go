@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"context"
+)
+
+// Authorize проверяет, что вызывающему доступно разрешение permission, и переводит отказ в gRPC статус.
+// Используется AuthorizationInterceptor'ом с требованиями service.MethodPermissions; возвращённый контекст
+// несёт id вызывающего до обработчика
+func (c *Controller) Authorize(ctx context.Context, permission string) (context.Context, error) {
+	authorizedCtx, err := c.service.Authorize(ctx, permission)
+
+	return authorizedCtx, statusError(err)
+}
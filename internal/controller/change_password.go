@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "change_password"))
+
+	log.Info("request started")
+
+	resp, err := c.service.ChangePassword(ctx, req)
+	if err != nil {
+		log.Error("change password failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) StartEmailLogin(ctx context.Context, req *pb.StartEmailLoginRequest) (*pb.StartEmailLoginResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "start_email_login"))
+
+	log.Info("request started")
+
+	resp, err := c.service.StartEmailLogin(ctx, req)
+	if err != nil {
+		log.Error("start email login failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) CompleteEmailLogin(ctx context.Context, req *pb.CompleteEmailLoginRequest) (*pb.CompleteEmailLoginResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "complete_email_login"))
+
+	log.Info("request started")
+
+	resp, err := c.service.CompleteEmailLogin(ctx, req)
+	if err != nil {
+		log.Error("complete email login failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "verify_email"))
+
+	log.Info("request started")
+
+	resp, err := c.service.VerifyEmail(ctx, req)
+	if err != nil {
+		log.Error("verify email failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) ResendVerificationEmail(ctx context.Context, req *pb.ResendVerificationEmailRequest) (*pb.ResendVerificationEmailResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "resend_verification_email"))
+
+	log.Info("request started")
+
+	resp, err := c.service.ResendVerificationEmail(ctx, req)
+	if err != nil {
+		log.Error("resend verification email failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorStatuses — единая таблица соответствия бизнес-ошибок gRPC кодам.
+// Если указано field, ошибка относится к полю запроса и уходит клиенту в google.rpc.BadRequest
+var errorStatuses = []struct {
+	err   error
+	code  codes.Code
+	field string
+}{
+	{domain.ErrWeakPassword, codes.InvalidArgument, "password"},
+	{domain.ErrWeakEmail, codes.InvalidArgument, "email"},
+	{domain.ErrNoRefreshToken, codes.InvalidArgument, "refresh_token"},
+	{domain.ErrNoSessionID, codes.InvalidArgument, "session_id"},
+	{domain.ErrWrongCurrentPassword, codes.InvalidArgument, "current_password"},
+	{domain.ErrNoResetToken, codes.InvalidArgument, "token"},
+	{domain.ErrInvalidResetToken, codes.InvalidArgument, "token"},
+	{domain.ErrNoVerificationToken, codes.InvalidArgument, "token"},
+	{domain.ErrInvalidVerification, codes.InvalidArgument, "token"},
+	{domain.ErrNoMFACode, codes.InvalidArgument, "code"},
+	{domain.ErrInvalidMFACode, codes.InvalidArgument, "code"},
+	{domain.ErrNoMFAToken, codes.InvalidArgument, "mfa_token"},
+	{domain.ErrInvalidMFAToken, codes.InvalidArgument, "mfa_token"},
+	{domain.ErrInvalidWebAuthnChallenge, codes.InvalidArgument, "client_data_json"},
+	{domain.ErrInvalidWebAuthnAttestation, codes.InvalidArgument, "attestation_object"},
+	{domain.ErrNoWebAuthnResponse, codes.InvalidArgument, ""},
+	{domain.ErrInvalidEmailLoginCode, codes.InvalidArgument, "code"},
+	{domain.ErrInvalidEmailLoginToken, codes.InvalidArgument, "token"},
+	{domain.ErrNoEmailLoginCode, codes.InvalidArgument, ""},
+	{domain.ErrInvalidSiweMessage, codes.InvalidArgument, "message"},
+	{domain.ErrInvalidSiweNonce, codes.InvalidArgument, "message"},
+	{domain.ErrNoWalletSignature, codes.InvalidArgument, ""},
+	{domain.ErrNoWalletAddress, codes.InvalidArgument, "address"},
+	{domain.ErrInvalidWalletAddress, codes.InvalidArgument, "address"},
+	{domain.ErrNoAccountID, codes.InvalidArgument, ""},
+	{domain.ErrNoUserID, codes.InvalidArgument, "user_id"},
+	{domain.ErrNoRole, codes.InvalidArgument, "role"},
+	{domain.ErrNotEnoughData, codes.InvalidArgument, ""},
+	{domain.ErrEmailAlreadyTaken, codes.AlreadyExists, "email"},
+	{domain.ErrUsernameAlreadyTaken, codes.AlreadyExists, "username"},
+	{domain.ErrWebAuthnCredentialExists, codes.AlreadyExists, ""},
+	{domain.ErrWalletAlreadyLinked, codes.AlreadyExists, "address"},
+	{domain.ErrNoAccessToken, codes.Unauthenticated, ""},
+	{domain.ErrInvalidAccessToken, codes.Unauthenticated, ""},
+	{domain.ErrRevokedAccessToken, codes.Unauthenticated, ""},
+	{domain.ErrNoSuchRefreshToken, codes.Unauthenticated, ""},
+	{domain.ErrInvalidCredentials, codes.Unauthenticated, ""},
+	{domain.ErrRefreshTokenReused, codes.Unauthenticated, ""},
+	{domain.ErrInvalidWebAuthnAssertion, codes.Unauthenticated, ""},
+	{domain.ErrWebAuthnCloneDetected, codes.Unauthenticated, ""},
+	{domain.ErrInvalidWalletSignature, codes.Unauthenticated, ""},
+	{domain.ErrEmailNotVerified, codes.FailedPrecondition, ""},
+	{domain.ErrMFANotConfigured, codes.FailedPrecondition, ""},
+	{domain.ErrTOTPAlreadyEnabled, codes.FailedPrecondition, ""},
+	{domain.ErrTOTPNotEnrolled, codes.FailedPrecondition, ""},
+	{domain.ErrTOTPNotEnabled, codes.FailedPrecondition, ""},
+	{domain.ErrWebAuthnNotConfigured, codes.FailedPrecondition, ""},
+	{domain.ErrSiweNotConfigured, codes.FailedPrecondition, ""},
+	{domain.ErrWalletLimitReached, codes.FailedPrecondition, ""},
+	{domain.ErrLastLoginMethod, codes.FailedPrecondition, ""},
+	{domain.ErrAdminRequired, codes.PermissionDenied, ""},
+	{domain.ErrPermissionDenied, codes.PermissionDenied, ""},
+	{domain.ErrNilUser, codes.NotFound, ""},
+	{domain.ErrNoSuchSession, codes.NotFound, ""},
+	{domain.ErrNoSuchWallet, codes.NotFound, ""},
+	{domain.ErrNoSuchRole, codes.NotFound, ""},
+}
+
+// statusError переводит ошибку сервиса в gRPC статус.
+// Текст неизвестных (внутренних) ошибок клиенту не отдаётся, он остаётся только в логах
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var policyErr *domain.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return passwordPolicyStatus(policyErr)
+	}
+
+	var blockedErr *domain.LoginBlockedError
+	if errors.As(err, &blockedErr) {
+		return loginBlockedStatus(blockedErr)
+	}
+
+	for _, e := range errorStatuses {
+		if !errors.Is(err, e.err) {
+			continue
+		}
+
+		st := status.New(e.code, e.err.Error())
+		if e.field == "" {
+			return st.Err()
+		}
+
+		stWithDetails, detailsErr := st.WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: e.field, Description: e.err.Error()},
+			},
+		})
+		if detailsErr != nil {
+			return st.Err()
+		}
+
+		return stWithDetails.Err()
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "request deadline exceeded")
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// passwordPolicyStatus отдаёт каждое нарушение политики паролей отдельным FieldViolation,
+// код нарушения — в reason, чтобы клиент мог показать подсказку
+func passwordPolicyStatus(policyErr *domain.PasswordPolicyError) error {
+	st := status.New(codes.InvalidArgument, domain.ErrWeakPassword.Error())
+
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range policyErr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "password",
+			Description: v.Message,
+			Reason:      v.Code,
+		})
+	}
+
+	stWithDetails, err := st.WithDetails(badRequest)
+	if err != nil {
+		return st.Err()
+	}
+
+	return stWithDetails.Err()
+}
+
+// loginBlockedStatus — блокировка аккаунта отдаётся как PermissionDenied, задержка между попытками —
+// как ResourceExhausted; в обоих случаях google.rpc.RetryInfo говорит, когда можно повторить
+func loginBlockedStatus(blockedErr *domain.LoginBlockedError) error {
+	code := codes.ResourceExhausted
+	if blockedErr.Locked {
+		code = codes.PermissionDenied
+	}
+
+	st := status.New(code, blockedErr.Unwrap().Error())
+
+	stWithDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(blockedErr.RetryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+
+	return stWithDetails.Err()
+}
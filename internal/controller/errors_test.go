@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestStatusErrorCodes(t *testing.T) {
+	cases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{domain.ErrWeakPassword, codes.InvalidArgument},
+		{domain.ErrNotEnoughData, codes.InvalidArgument},
+		{domain.ErrEmailAlreadyTaken, codes.AlreadyExists},
+		{domain.ErrInvalidCredentials, codes.Unauthenticated},
+		{domain.ErrWrongCurrentPassword, codes.InvalidArgument},
+		{fmt.Errorf("parse: %w", domain.ErrInvalidAccessToken), codes.Unauthenticated},
+		{domain.ErrNoSuchRefreshToken, codes.Unauthenticated},
+		{domain.ErrEmailNotVerified, codes.FailedPrecondition},
+		{domain.ErrTOTPAlreadyEnabled, codes.FailedPrecondition},
+		{domain.ErrInvalidMFACode, codes.InvalidArgument},
+		{domain.ErrInvalidWebAuthnAssertion, codes.Unauthenticated},
+		{domain.ErrWebAuthnCredentialExists, codes.AlreadyExists},
+		{domain.ErrInvalidEmailLoginCode, codes.InvalidArgument},
+		{domain.ErrInvalidWalletSignature, codes.Unauthenticated},
+		{domain.ErrWalletAlreadyLinked, codes.AlreadyExists},
+		{domain.ErrInvalidWalletAddress, codes.InvalidArgument},
+		{domain.ErrWalletLimitReached, codes.FailedPrecondition},
+		{domain.ErrLastLoginMethod, codes.FailedPrecondition},
+		{domain.ErrNoSuchWallet, codes.NotFound},
+		{domain.ErrPermissionDenied, codes.PermissionDenied},
+		{domain.ErrNoSuchRole, codes.NotFound},
+		{domain.ErrNoRole, codes.InvalidArgument},
+		{domain.ErrNilUser, codes.NotFound},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+	}
+
+	for _, tc := range cases {
+		st, ok := status.FromError(statusError(tc.err))
+
+		require.True(t, ok)
+		require.Equal(t, tc.code, st.Code(), tc.err.Error())
+	}
+}
+
+func TestStatusErrorFieldViolation(t *testing.T) {
+	st := status.Convert(statusError(fmt.Errorf("register: %w", domain.ErrWeakEmail)))
+
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Equal(t, "email", badRequest.GetFieldViolations()[0].GetField())
+}
+
+func TestStatusErrorConflict(t *testing.T) {
+	err := fmt.Errorf("failed to create user: %w", &domain.ConflictError{Field: "username", Err: domain.ErrUsernameAlreadyTaken})
+	st := status.Convert(statusError(err))
+
+	require.Equal(t, codes.AlreadyExists, st.Code())
+	require.Equal(t, domain.ErrUsernameAlreadyTaken.Error(), st.Message())
+
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Equal(t, "username", badRequest.GetFieldViolations()[0].GetField())
+}
+
+func TestStatusErrorPasswordPolicy(t *testing.T) {
+	err := fmt.Errorf("register: %w", &domain.PasswordPolicyError{Violations: []domain.PasswordViolation{
+		{Code: "PASSWORD_TOO_SHORT", Message: "password must be at least 8 characters long"},
+		{Code: "PASSWORD_NO_DIGIT", Message: "password must contain a digit"},
+	}})
+	st := status.Convert(statusError(err))
+
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.GetFieldViolations(), 2)
+	require.Equal(t, "password", badRequest.GetFieldViolations()[0].GetField())
+	require.Equal(t, "PASSWORD_TOO_SHORT", badRequest.GetFieldViolations()[0].GetReason())
+	require.Equal(t, "PASSWORD_NO_DIGIT", badRequest.GetFieldViolations()[1].GetReason())
+}
+
+func TestStatusErrorHidesInternalErrors(t *testing.T) {
+	st := status.Convert(statusError(errors.New("failed to create user: pq: connection refused")))
+
+	require.Equal(t, codes.Internal, st.Code())
+	require.Equal(t, "internal error", st.Message())
+	require.Empty(t, st.Details())
+}
+
+func TestStatusErrorLoginBlocked(t *testing.T) {
+	cases := []struct {
+		err  *domain.LoginBlockedError
+		code codes.Code
+	}{
+		{&domain.LoginBlockedError{RetryAfter: 4 * time.Second}, codes.ResourceExhausted},
+		{&domain.LoginBlockedError{Locked: true, RetryAfter: 15 * time.Minute}, codes.PermissionDenied},
+	}
+
+	for _, tc := range cases {
+		st := status.Convert(statusError(fmt.Errorf("login: %w", tc.err)))
+
+		require.Equal(t, tc.code, st.Code())
+		require.Len(t, st.Details(), 1)
+
+		retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		require.Equal(t, tc.err.RetryAfter, retryInfo.GetRetryDelay().AsDuration())
+	}
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) GetJWKS(ctx context.Context, req *pb.GetJWKSRequest) (*pb.GetJWKSResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "get_jwks"))
+
+	log.Info("request started")
+
+	resp, err := c.service.GetJWKS(ctx, req)
+	if err != nil {
+		log.Error("get jwks failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
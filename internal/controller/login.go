@@ -4,7 +4,6 @@ import (
 	"context"
 	pb "crypto_analyzer_auth_service/gen/go"
 	"crypto_analyzer_auth_service/internal/infrastructure/logger"
-	"fmt"
 	"go.uber.org/zap"
 )
 
@@ -16,7 +15,7 @@ func (c *Controller) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Login
 	resp, err := c.service.Login(ctx, req)
 	if err != nil {
 		log.Error("login failed", zap.Error(err))
-		return nil, fmt.Errorf("login failed: %w", err)
+		return nil, statusError(err)
 	}
 
 	log.Info("request ended")
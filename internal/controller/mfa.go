@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) CompleteMFA(ctx context.Context, req *pb.CompleteMFARequest) (*pb.CompleteMFAResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "complete_mfa"))
+
+	log.Info("request started")
+
+	resp, err := c.service.CompleteMFA(ctx, req)
+	if err != nil {
+		log.Error("complete mfa failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
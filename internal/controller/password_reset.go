@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "request_password_reset"))
+
+	log.Info("request started")
+
+	resp, err := c.service.RequestPasswordReset(ctx, req)
+	if err != nil {
+		log.Error("request password reset failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) ConfirmPasswordReset(ctx context.Context, req *pb.ConfirmPasswordResetRequest) (*pb.ConfirmPasswordResetResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "confirm_password_reset"))
+
+	log.Info("request started")
+
+	resp, err := c.service.ConfirmPasswordReset(ctx, req)
+	if err != nil {
+		log.Error("confirm password reset failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
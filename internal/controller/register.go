@@ -4,7 +4,6 @@ import (
 	"context"
 	pb "crypto_analyzer_auth_service/gen/go"
 	"crypto_analyzer_auth_service/internal/infrastructure/logger"
-	"fmt"
 	"go.uber.org/zap"
 )
 
@@ -16,7 +15,7 @@ func (c *Controller) Register(ctx context.Context, req *pb.RegisterRequest) (*pb
 	resp, err := c.service.Register(ctx, req)
 	if err != nil {
 		log.Error("register failed", zap.Error(err))
-		return nil, fmt.Errorf("register failed: %w", err)
+		return nil, statusError(err)
 	}
 
 	log.Info("request ended")
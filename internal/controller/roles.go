@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) ListRoles(ctx context.Context, req *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "list_roles"))
+
+	log.Info("request started")
+
+	resp, err := c.service.ListRoles(ctx, req)
+	if err != nil {
+		log.Error("list roles failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) GetUserRoles(ctx context.Context, req *pb.GetUserRolesRequest) (*pb.GetUserRolesResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "get_user_roles"))
+
+	log.Info("request started")
+
+	resp, err := c.service.GetUserRoles(ctx, req)
+	if err != nil {
+		log.Error("get user roles failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) AssignRole(ctx context.Context, req *pb.AssignRoleRequest) (*pb.AssignRoleResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "assign_role"))
+
+	log.Info("request started")
+
+	resp, err := c.service.AssignRole(ctx, req)
+	if err != nil {
+		log.Error("assign role failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) RevokeRole(ctx context.Context, req *pb.RevokeRoleRequest) (*pb.RevokeRoleResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "revoke_role"))
+
+	log.Info("request started")
+
+	resp, err := c.service.RevokeRole(ctx, req)
+	if err != nil {
+		log.Error("revoke role failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "list_sessions"))
+
+	log.Info("request started")
+
+	resp, err := c.service.ListSessions(ctx, req)
+	if err != nil {
+		log.Error("list sessions failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "revoke_session"))
+
+	log.Info("request started")
+
+	resp, err := c.service.RevokeSession(ctx, req)
+	if err != nil {
+		log.Error("revoke session failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "revoke_all_sessions"))
+
+	log.Info("request started")
+
+	resp, err := c.service.RevokeAllSessions(ctx, req)
+	if err != nil {
+		log.Error("revoke all sessions failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
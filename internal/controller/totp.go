@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "enroll_totp"))
+
+	log.Info("request started")
+
+	resp, err := c.service.EnrollTOTP(ctx, req)
+	if err != nil {
+		log.Error("enroll totp failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "confirm_totp"))
+
+	log.Info("request started")
+
+	resp, err := c.service.ConfirmTOTP(ctx, req)
+	if err != nil {
+		log.Error("confirm totp failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*pb.DisableTOTPResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "disable_totp"))
+
+	log.Info("request started")
+
+	resp, err := c.service.DisableTOTP(ctx, req)
+	if err != nil {
+		log.Error("disable totp failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
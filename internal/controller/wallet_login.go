@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) GetSiweNonce(ctx context.Context, req *pb.GetSiweNonceRequest) (*pb.GetSiweNonceResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "get_siwe_nonce"))
+
+	log.Info("request started")
+
+	resp, err := c.service.GetSiweNonce(ctx, req)
+	if err != nil {
+		log.Error("get siwe nonce failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) LoginWithWallet(ctx context.Context, req *pb.LoginWithWalletRequest) (*pb.LoginWithWalletResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "login_with_wallet"))
+
+	log.Info("request started")
+
+	resp, err := c.service.LoginWithWallet(ctx, req)
+	if err != nil {
+		log.Error("login with wallet failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) GetWalletLinkNonce(ctx context.Context, req *pb.GetWalletLinkNonceRequest) (*pb.GetWalletLinkNonceResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "get_wallet_link_nonce"))
+
+	log.Info("request started")
+
+	resp, err := c.service.GetWalletLinkNonce(ctx, req)
+	if err != nil {
+		log.Error("get wallet link nonce failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) LinkWallet(ctx context.Context, req *pb.LinkWalletRequest) (*pb.LinkWalletResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "link_wallet"))
+
+	log.Info("request started")
+
+	resp, err := c.service.LinkWallet(ctx, req)
+	if err != nil {
+		log.Error("link wallet failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) UnlinkWallet(ctx context.Context, req *pb.UnlinkWalletRequest) (*pb.UnlinkWalletResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "unlink_wallet"))
+
+	log.Info("request started")
+
+	resp, err := c.service.UnlinkWallet(ctx, req)
+	if err != nil {
+		log.Error("unlink wallet failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) ListWallets(ctx context.Context, req *pb.ListWalletsRequest) (*pb.ListWalletsResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "list_wallets"))
+
+	log.Info("request started")
+
+	resp, err := c.service.ListWallets(ctx, req)
+	if err != nil {
+		log.Error("list wallets failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) BeginWebAuthnRegistration(ctx context.Context, req *pb.BeginWebAuthnRegistrationRequest) (*pb.BeginWebAuthnRegistrationResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "begin_webauthn_registration"))
+
+	log.Info("request started")
+
+	resp, err := c.service.BeginWebAuthnRegistration(ctx, req)
+	if err != nil {
+		log.Error("begin webauthn registration failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) FinishWebAuthnRegistration(ctx context.Context, req *pb.FinishWebAuthnRegistrationRequest) (*pb.FinishWebAuthnRegistrationResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "finish_webauthn_registration"))
+
+	log.Info("request started")
+
+	resp, err := c.service.FinishWebAuthnRegistration(ctx, req)
+	if err != nil {
+		log.Error("finish webauthn registration failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) BeginWebAuthnLogin(ctx context.Context, req *pb.BeginWebAuthnLoginRequest) (*pb.BeginWebAuthnLoginResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "begin_webauthn_login"))
+
+	log.Info("request started")
+
+	resp, err := c.service.BeginWebAuthnLogin(ctx, req)
+	if err != nil {
+		log.Error("begin webauthn login failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
+
+func (c *Controller) FinishWebAuthnLogin(ctx context.Context, req *pb.FinishWebAuthnLoginRequest) (*pb.FinishWebAuthnLoginResponse, error) {
+	log := logger.FromContext(ctx).With(zap.String("method", "finish_webauthn_login"))
+
+	log.Info("request started")
+
+	resp, err := c.service.FinishWebAuthnLogin(ctx, req)
+	if err != nil {
+		log.Error("finish webauthn login failed", zap.Error(err))
+		return nil, statusError(err)
+	}
+
+	log.Info("request ended")
+
+	return resp, nil
+}
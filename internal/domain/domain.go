@@ -4,10 +4,133 @@ import (
 	"time"
 )
 
+// User — пользователь; нулевой EmailVerifiedAt означает, что email ещё не подтверждён.
+// У аккаунта, созданного входом через кошелёк, пустые Email и PasswordHash
 type User struct {
-	ID           string
-	Username     string
-	Email        string
-	PasswordHash string
-	CreatedAt    time.Time
+	ID              string
+	Username        string
+	Email           string
+	PasswordHash    string
+	CreatedAt       time.Time
+	EmailVerifiedAt time.Time
 }
+
+// RefreshSession — положение refresh токена в семействе: семейство начинается при входе,
+// каждая ротация увеличивает поколение
+type RefreshSession struct {
+	UserID     string
+	FamilyID   string
+	Generation int
+}
+
+// AccessToken — выпущенный access токен: подписанная строка, его jti и время истечения
+type AccessToken struct {
+	Token     string
+	ID        string
+	ExpiresAt time.Time
+}
+
+// AccessTokenExtra — необязательные claims access токена. Wallets — привязанные адреса
+// в нижнем регистре, Roles — роли, Scope — разрешения ролей; nil — claim не выпускается
+type AccessTokenExtra struct {
+	Wallets []string
+	Roles   []string
+	Scope   []string
+}
+
+// AccessClaims — проверенное содержимое access токена. Wallets, Roles и Scope — на момент выпуска токена;
+// в токенах, выпущенных до появления ролей, Roles и Scope пусты
+type AccessClaims struct {
+	User          User
+	EmailVerified bool
+	Wallets       []string
+	Roles         []string
+	Scope         []string
+	TokenID       string
+	ExpiresAt     time.Time
+}
+
+// ClientInfo — откуда пришёл запрос: адрес, user agent и имя клиента из metadata x-client-name
+type ClientInfo struct {
+	IP         string
+	UserAgent  string
+	ClientName string
+}
+
+// Session — активная сессия (семейство refresh токенов) пользователя
+type Session struct {
+	ID         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	IP         string
+	UserAgent  string
+	ClientName string
+}
+
+// LoginBlock — действующее ограничение входа для аккаунта или адреса (Scope: "account" или "ip")
+type LoginBlock struct {
+	Scope      string
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// JWK — публичный ключ проверки подписи access токенов (RFC 7517)
+type JWK struct {
+	Kty string
+	Kid string
+	Use string
+	Alg string
+	N   string
+	E   string
+	Crv string
+	X   string
+}
+
+// TOTP — второй фактор пользователя. Secret зашифрован; нулевой ConfirmedAt — подключение не подтверждено.
+// LastUsedStep — шаг последнего принятого кода, коды этого и более ранних шагов не принимаются повторно
+type TOTP struct {
+	UserID       string
+	Secret       []byte
+	ConfirmedAt  time.Time
+	LastUsedStep int64
+}
+
+// WebAuthnCredential — ключ WebAuthn (passkey) пользователя. PublicKey — COSE_Key,
+// SignCount — последний принятый счётчик подписей аутентификатора
+type WebAuthnCredential struct {
+	ID                []byte
+	UserID            string
+	PublicKey         []byte
+	SignCount         uint32
+	AAGUID            []byte
+	AttestationFormat string
+	BackupEligible    bool
+	BackupState       bool
+	CreatedAt         time.Time
+	LastUsedAt        time.Time
+}
+
+// WalletAddress — адрес Ethereum пользователя. Address — 0x + 40 hex в нижнем регистре,
+// ChainID — сеть последнего входа с этого адреса
+type WalletAddress struct {
+	Address    string
+	UserID     string
+	ChainID    int64
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// Role — роль и разрешения, которые она даёт
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// Разрешения, которые проверяет сам сервис. Остальные разрешения заводятся в таблице permissions
+// и проверяются другими сервисами по claim scope
+const (
+	PermissionAccountsUnlock = "accounts:unlock"
+	PermissionRolesRead      = "roles:read"
+	PermissionRolesManage    = "roles:manage"
+)
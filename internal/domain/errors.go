@@ -1,15 +1,132 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
-	ErrWeakPassword       = errors.New("password must be at least 8 characters long, contain uppercase and lowercase letters, at least one digit, and a special character")
-	ErrWeakEmail          = errors.New("invalid email format")
-	ErrNotEnoughData      = errors.New("username or/and email must be provided, password must not be empty")
-	ErrRefreshFailed      = errors.New("failed to refresh token")
-	ErrInvalidAccessToken = errors.New("access token is invalid")
-	ErrNoAccessToken      = errors.New("no access token")
-	ErrNoRefreshToken     = errors.New("no refresh token")
-	ErrNilUser            = errors.New("user is nil")
-	ErrNoSuchRefreshToken = errors.New("refresh token not found")
+	ErrWeakPassword         = errors.New("password does not meet the password policy")
+	ErrWeakEmail            = errors.New("invalid email format")
+	ErrNotEnoughData        = errors.New("username or/and email must be provided, password must not be empty")
+	ErrRefreshFailed        = errors.New("failed to refresh token")
+	ErrInvalidAccessToken   = errors.New("access token is invalid")
+	ErrNoAccessToken        = errors.New("no access token")
+	ErrRevokedAccessToken   = errors.New("access token has been revoked")
+	ErrNoRefreshToken       = errors.New("no refresh token")
+	ErrNilUser              = errors.New("user is nil")
+	ErrNoSuchRefreshToken   = errors.New("refresh token not found")
+	ErrEmailAlreadyTaken    = errors.New("email has been already taken")
+	ErrUsernameAlreadyTaken = errors.New("username has been already taken")
+	ErrRefreshTokenReused   = errors.New("refresh token has been already used")
+	ErrNoSessionID          = errors.New("no session id")
+	ErrNoSuchSession        = errors.New("session not found")
+	ErrWrongCurrentPassword = errors.New("current password is wrong")
+	ErrNoResetToken         = errors.New("no password reset token")
+	ErrInvalidResetToken    = errors.New("password reset token is invalid or expired")
+	ErrNoVerificationToken  = errors.New("no email verification token")
+	ErrInvalidVerification  = errors.New("email verification token is invalid or expired")
+	ErrEmailNotVerified     = errors.New("email is not verified")
+	ErrTooManyLoginAttempts = errors.New("too many login attempts, try again later")
+	ErrAccountLocked        = errors.New("account is temporarily locked")
+	ErrAdminRequired        = errors.New("admin privileges required")
+	ErrNoAccountID          = errors.New("user_id or email must be provided")
+	ErrInvalidCredentials   = errors.New("invalid login or password")
+	ErrMFANotConfigured     = errors.New("two-factor authentication is not configured")
+	ErrTOTPAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled      = errors.New("two-factor authentication enrollment not found")
+	ErrTOTPNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrNoMFACode            = errors.New("no two-factor authentication code")
+	ErrInvalidMFACode       = errors.New("two-factor authentication code is invalid")
+	ErrNoMFAToken           = errors.New("no mfa token")
+	ErrInvalidMFAToken      = errors.New("mfa token is invalid or expired")
+
+	ErrWebAuthnNotConfigured      = errors.New("webauthn is not configured")
+	ErrNoWebAuthnResponse         = errors.New("client_data_json and authenticator response must be provided")
+	ErrInvalidWebAuthnChallenge   = errors.New("webauthn challenge is invalid or expired")
+	ErrInvalidWebAuthnAttestation = errors.New("webauthn attestation is invalid")
+	ErrWebAuthnCredentialExists   = errors.New("webauthn credential is already registered")
+	ErrInvalidWebAuthnAssertion   = errors.New("webauthn assertion is invalid")
+	ErrWebAuthnCloneDetected      = errors.New("webauthn authenticator signature counter went backwards")
+
+	ErrNoEmailLoginCode       = errors.New("email and code or link token must be provided")
+	ErrInvalidEmailLoginCode  = errors.New("email login code is invalid or expired")
+	ErrInvalidEmailLoginToken = errors.New("email login link is invalid or expired")
+
+	ErrSiweNotConfigured      = errors.New("sign-in with ethereum is not configured")
+	ErrNoWalletSignature      = errors.New("message and signature must be provided")
+	ErrInvalidSiweMessage     = errors.New("sign-in with ethereum message is invalid")
+	ErrInvalidSiweNonce       = errors.New("sign-in with ethereum nonce is invalid or expired")
+	ErrInvalidWalletSignature = errors.New("wallet signature is invalid")
+	ErrWalletAlreadyLinked    = errors.New("wallet address is already linked to an account")
+	ErrNoWalletAddress        = errors.New("no wallet address")
+	ErrInvalidWalletAddress   = errors.New("wallet address is invalid")
+	ErrNoSuchWallet           = errors.New("wallet address is not linked to the account")
+	ErrWalletLimitReached     = errors.New("too many wallet addresses are linked to the account")
+	ErrLastLoginMethod        = errors.New("the only way to sign in to the account cannot be removed")
+
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrNoUserID         = errors.New("no user id")
+	ErrNoRole           = errors.New("no role")
+	ErrNoSuchRole       = errors.New("role not found")
 )
+
+// ConflictError — нарушение уникальности: значение поля Field уже занято другим пользователем.
+// Err — соответствующая бизнес-ошибка (ErrEmailAlreadyTaken, ErrUsernameAlreadyTaken)
+type ConflictError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConflictError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// PasswordViolation — одно нарушение политики паролей: машинный код и описание для пользователя
+type PasswordViolation struct {
+	Code    string
+	Message string
+}
+
+// PasswordPolicyError — пароль не прошёл политику; Violations перечисляет все нарушения сразу
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(messages, "; "))
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// LoginBlockedError — вход временно запрещён после неудачных попыток. Locked — блокировка аккаунта
+// (ErrAccountLocked), иначе — задержка между попытками (ErrTooManyLoginAttempts). RetryAfter — когда можно повторить
+type LoginBlockedError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", e.Unwrap(), e.RetryAfter)
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	if e.Locked {
+		return ErrAccountLocked
+	}
+
+	return ErrTooManyLoginAttempts
+}
@@ -0,0 +1,36 @@
+package clientip
+
+import (
+	"context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"strings"
+)
+
+// FromContext — адрес TCP соединения. Для вызовов через HTTP шлюз адрес берётся из x-forwarded-for:
+// шлюз дописывает в конец адрес, с которого пришёл HTTP запрос, а предыдущие значения задаёт клиент.
+// Поэтому берётся значение, отстоящее от конца на trustedHops (число доверенных прокси перед шлюзом).
+// У прямого gRPC клиента x-forwarded-for ничем не подтверждён
+func FromContext(ctx context.Context, trustedHops int) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil && p.Addr.Network() == "tcp" {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	forwarded := md.Get("x-forwarded-for")
+	if len(forwarded) == 0 {
+		return ""
+	}
+
+	hops := strings.Split(forwarded[len(forwarded)-1], ",")
+	index := len(hops) - 1 - trustedHops
+	if index < 0 {
+		index = 0
+	}
+
+	return strings.TrimSpace(hops[index])
+}
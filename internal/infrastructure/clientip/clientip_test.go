@@ -0,0 +1,44 @@
+package clientip
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	forwarded := func(values ...string) context.Context {
+		md := metadata.MD{}
+		md.Append("x-forwarded-for", values...)
+		return metadata.NewIncomingContext(context.Background(), md)
+	}
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		trustedHops int
+		want        string
+	}{
+		{name: "без адреса", ctx: context.Background(), want: ""},
+		{name: "адрес шлюза", ctx: forwarded("203.0.113.25"), want: "203.0.113.25"},
+		{name: "подставленные клиентом значения", ctx: forwarded("198.51.100.1, 203.0.113.25"), want: "203.0.113.25"},
+		{name: "доверенный прокси", ctx: forwarded("198.51.100.1, 203.0.113.25, 10.0.0.1"), trustedHops: 1, want: "203.0.113.25"},
+		{name: "прокси больше, чем значений", ctx: forwarded("203.0.113.25"), trustedHops: 3, want: "203.0.113.25"},
+		{name: "последний заголовок", ctx: forwarded("198.51.100.1", "203.0.113.25"), want: "203.0.113.25"},
+		{
+			name: "TCP соединение важнее x-forwarded-for",
+			ctx: peer.NewContext(forwarded("198.51.100.1"),
+				&peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.25"), Port: 5000}}),
+			want: "203.0.113.25",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, FromContext(tt.ctx, tt.trustedHops))
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package ethereum
+
+import (
+	"math/big"
+)
+
+// параметры кривой secp256k1 (SEC 2, 2.4.1): y² = x³ + 7 над полем p, n — порядок точки G
+var (
+	curveP, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	curveN, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	curveB    = big.NewInt(7)
+	curveG    = &point{
+		x: mustHex("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+		y: mustHex("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+	}
+
+	// halfN — граница «низкого» s (EIP-2)
+	halfN = new(big.Int).Rsh(curveN, 1)
+	// sqrtExp — (p+1)/4: p ≡ 3 (mod 4), поэтому квадратный корень — возведение в эту степень
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(curveP, big.NewInt(1)), 2)
+)
+
+// point — точка кривой в аффинных координатах; nil — бесконечно удалённая точка.
+// Операции не константного времени: подходят для проверки подписей, но не для работы с секретами в проде
+type point struct {
+	x, y *big.Int
+}
+
+func mustHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex constant " + s)
+	}
+
+	return n
+}
+
+// pointFromX восстанавливает точку по x и чётности y; false — x не лежит на кривой
+func pointFromX(x *big.Int, odd bool) (*point, bool) {
+	if x.Sign() <= 0 || x.Cmp(curveP) >= 0 {
+		return nil, false
+	}
+
+	// y² = x³ + 7
+	y2 := new(big.Int).Exp(x, big.NewInt(3), curveP)
+	y2.Add(y2, curveB).Mod(y2, curveP)
+
+	y := new(big.Int).Exp(y2, sqrtExp, curveP)
+	if new(big.Int).Exp(y, big.NewInt(2), curveP).Cmp(y2) != 0 {
+		return nil, false
+	}
+
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(curveP, y)
+	}
+
+	return &point{x: new(big.Int).Set(x), y: y}, true
+}
+
+func (p *point) onCurve() bool {
+	if p == nil {
+		return false
+	}
+
+	y2 := new(big.Int).Exp(p.y, big.NewInt(2), curveP)
+	x3 := new(big.Int).Exp(p.x, big.NewInt(3), curveP)
+	x3.Add(x3, curveB).Mod(x3, curveP)
+
+	return y2.Cmp(x3) == 0
+}
+
+func addPoints(a, b *point) *point {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) == 0 {
+			return doublePoint(a)
+		}
+		// b = -a
+		return nil
+	}
+
+	// λ = (y2 - y1) / (x2 - x1)
+	num := new(big.Int).Sub(b.y, a.y)
+	den := new(big.Int).Sub(b.x, a.x)
+	inv := new(big.Int).ModInverse(den.Mod(den, curveP), curveP)
+	lambda := num.Mul(num, inv).Mod(num, curveP)
+
+	return pointFromLambda(lambda, a, b.x)
+}
+
+func doublePoint(a *point) *point {
+	if a == nil || a.y.Sign() == 0 {
+		return nil
+	}
+
+	// λ = 3x² / 2y (коэффициент a кривой равен нулю)
+	num := new(big.Int).Mul(a.x, a.x)
+	num.Mul(num, big.NewInt(3))
+	den := new(big.Int).Lsh(a.y, 1)
+	inv := new(big.Int).ModInverse(den.Mod(den, curveP), curveP)
+	lambda := num.Mul(num, inv).Mod(num, curveP)
+
+	return pointFromLambda(lambda, a, a.x)
+}
+
+// pointFromLambda — общая часть сложения и удвоения: x3 = λ² - x1 - x2, y3 = λ(x1 - x3) - y1
+func pointFromLambda(lambda *big.Int, a *point, x2 *big.Int) *point {
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, a.x).Sub(x3, x2).Mod(x3, curveP)
+
+	y3 := new(big.Int).Sub(a.x, x3)
+	y3.Mul(y3, lambda).Sub(y3, a.y).Mod(y3, curveP)
+
+	return &point{x: x3, y: y3}
+}
+
+// scalarMult — k·a методом «удвоение и сложение»
+func scalarMult(k *big.Int, a *point) *point {
+	var result *point
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = doublePoint(result)
+		if k.Bit(i) == 1 {
+			result = addPoints(result, a)
+		}
+	}
+
+	return result
+}
+
+// marshal — несжатый открытый ключ без префикса 0x04: X || Y по 32 байта
+func (p *point) marshal() []byte {
+	out := make([]byte, 64)
+	p.x.FillBytes(out[:32])
+	p.y.FillBytes(out[32:])
+
+	return out
+}
@@ -0,0 +1,154 @@
+// Package ethereum — адреса Ethereum и восстановление подписавшего по подписи secp256k1
+// (personal_sign, EIP-191) без зависимостей от клиентов Ethereum
+package ethereum
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// SignatureLength — подпись r || s || v, которую возвращают кошельки
+const SignatureLength = 65
+
+var (
+	ErrInvalidAddress   = errors.New("invalid ethereum address")
+	ErrAddressChecksum  = errors.New("ethereum address checksum mismatch")
+	ErrInvalidSignature = errors.New("invalid secp256k1 signature")
+)
+
+// Address — 20 байт адреса аккаунта Ethereum
+type Address [20]byte
+
+// ParseAddress разбирает адрес 0x + 40 hex. Адрес в смешанном регистре проверяется
+// по контрольной сумме EIP-55, адрес в одном регистре принимается как есть
+func ParseAddress(s string) (Address, error) {
+	var address Address
+
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return address, ErrInvalidAddress
+	}
+
+	if _, err := hex.Decode(address[:], []byte(s[2:])); err != nil {
+		return address, ErrInvalidAddress
+	}
+
+	digits := s[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && s != address.String() {
+		return address, ErrAddressChecksum
+	}
+
+	return address, nil
+}
+
+// String — адрес с контрольной суммой EIP-55: буква в верхнем регистре, если соответствующий
+// полубайт keccak256 от адреса в нижнем регистре не меньше 8
+func (a Address) String() string {
+	lower := hex.EncodeToString(a[:])
+	hash := Keccak256([]byte(lower))
+
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' {
+			continue
+		}
+
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if nibble >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(out)
+}
+
+// Hex — адрес в нижнем регистре; в таком виде адреса хранятся и сравниваются
+func (a Address) Hex() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+
+	return h.Sum(nil)
+}
+
+// PersonalMessageHash — хеш, который подписывает personal_sign (EIP-191, версия 0x45)
+func PersonalMessageHash(message []byte) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message))
+
+	return Keccak256([]byte(prefix), message)
+}
+
+// RecoverAddress восстанавливает адрес, подписавший hash. v принимается и в виде 27/28, и в виде 0/1
+func RecoverAddress(hash, signature []byte) (Address, error) {
+	if len(hash) != 32 || len(signature) != SignatureLength {
+		return Address{}, ErrInvalidSignature
+	}
+
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:64])
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+
+	if v > 1 || r.Sign() == 0 || s.Sign() == 0 || r.Cmp(curveN) >= 0 || s.Cmp(curveN) >= 0 {
+		return Address{}, ErrInvalidSignature
+	}
+
+	// R — точка k·G, x которой дал r; v хранит чётность её y. Случай x ≥ n (v = 2, 3)
+	// встречается с вероятностью ~2^-127, кошельки его не выдают
+	R, ok := pointFromX(r, v == 1)
+	if !ok {
+		return Address{}, ErrInvalidSignature
+	}
+
+	// Q = r⁻¹(s·R − e·G)
+	rInv := new(big.Int).ModInverse(r, curveN)
+	e := new(big.Int).SetBytes(hash)
+
+	u1 := new(big.Int).Mul(e, rInv)
+	u1.Neg(u1).Mod(u1, curveN)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, curveN)
+
+	Q := addPoints(scalarMult(u1, curveG), scalarMult(u2, R))
+	if Q == nil {
+		return Address{}, ErrInvalidSignature
+	}
+
+	return publicKeyAddress(Q), nil
+}
+
+// VerifyPersonalSignature проверяет, что message подписан через personal_sign ключом адреса address
+func VerifyPersonalSignature(address Address, message, signature []byte) error {
+	signer, err := RecoverAddress(PersonalMessageHash(message), signature)
+	if err != nil {
+		return err
+	}
+
+	if signer != address {
+		return fmt.Errorf("%w: signed by %s", ErrInvalidSignature, signer)
+	}
+
+	return nil
+}
+
+// publicKeyAddress — последние 20 байт keccak256 несжатого открытого ключа
+func publicKeyAddress(q *point) Address {
+	var address Address
+	copy(address[:], Keccak256(q.marshal())[12:])
+
+	return address
+}
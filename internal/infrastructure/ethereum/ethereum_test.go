@@ -0,0 +1,105 @@
+package ethereum
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestKeccak256(t *testing.T) {
+	require.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(Keccak256()))
+}
+
+func TestAddressChecksum(t *testing.T) {
+	// примеры из EIP-55
+	for _, s := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		address, err := ParseAddress(s)
+		require.NoError(t, err, s)
+		require.Equal(t, s, address.String())
+		require.Equal(t, strings.ToLower(s), address.Hex())
+
+		lower, err := ParseAddress(strings.ToLower(s))
+		require.NoError(t, err, "адрес в нижнем регистре без контрольной суммы")
+		require.Equal(t, address, lower)
+	}
+
+	_, err := ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD")
+	require.ErrorIs(t, err, ErrAddressChecksum)
+
+	for _, malformed := range []string{"", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+		"0xZaAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"} {
+		_, err = ParseAddress(malformed)
+		require.ErrorIs(t, err, ErrInvalidAddress, malformed)
+	}
+}
+
+func TestKnownKeyAddress(t *testing.T) {
+	for d, want := range map[int64]string{
+		1: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf",
+		2: "0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF",
+	} {
+		key := &PrivateKey{d: big.NewInt(d), pub: scalarMult(big.NewInt(d), curveG)}
+		require.True(t, key.pub.onCurve())
+		require.Equal(t, want, key.Address().String())
+	}
+}
+
+func TestRecoverAddress(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	message := []byte("hello from the crypto analyzer")
+
+	signature, err := key.SignPersonal(message)
+	require.NoError(t, err)
+	require.Len(t, signature, SignatureLength)
+	require.Contains(t, []byte{27, 28}, signature[64])
+	require.True(t, new(big.Int).SetBytes(signature[32:64]).Cmp(halfN) <= 0, "низкий s")
+
+	signer, err := RecoverAddress(PersonalMessageHash(message), signature)
+	require.NoError(t, err)
+	require.Equal(t, key.Address(), signer)
+	require.NoError(t, VerifyPersonalSignature(key.Address(), message, signature))
+
+	// v в виде 0/1
+	raw := append([]byte(nil), signature...)
+	raw[64] -= 27
+	signer, err = RecoverAddress(PersonalMessageHash(message), raw)
+	require.NoError(t, err)
+	require.Equal(t, key.Address(), signer)
+
+	err = VerifyPersonalSignature(key.Address(), []byte("another message"), signature)
+	require.ErrorIs(t, err, ErrInvalidSignature, "подпись другого сообщения")
+
+	other, err := GenerateKey()
+	require.NoError(t, err)
+	err = VerifyPersonalSignature(other.Address(), message, signature)
+	require.ErrorIs(t, err, ErrInvalidSignature, "подпись другого ключа")
+}
+
+func TestRecoverAddressMalformed(t *testing.T) {
+	hash := PersonalMessageHash([]byte("message"))
+	n := curveN.Bytes()
+
+	cases := map[string][]byte{
+		"short":  make([]byte, 64),
+		"zero r": append(make([]byte, 64), 27),
+		"r = n":  append(append(append([]byte(nil), n...), make([]byte, 31)...), 1, 27),
+		"bad v":  append(append(make([]byte, 31), 1), append(append(make([]byte, 31), 1), 29)...),
+	}
+
+	for name, signature := range cases {
+		_, err := RecoverAddress(hash, signature)
+		require.ErrorIs(t, err, ErrInvalidSignature, name)
+	}
+
+	_, err := RecoverAddress(hash[:31], append(make([]byte, 64), 27))
+	require.ErrorIs(t, err, ErrInvalidSignature, "короткий хеш")
+}
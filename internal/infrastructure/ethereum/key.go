@@ -0,0 +1,82 @@
+package ethereum
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// PrivateKey — ключ secp256k1 для тестов и локальных инструментов. Арифметика кривой
+// здесь не константного времени, поэтому настоящие ключи кошельков им подписывать нельзя
+type PrivateKey struct {
+	d   *big.Int
+	pub *point
+}
+
+func GenerateKey() (*PrivateKey, error) {
+	for {
+		d, err := rand.Int(rand.Reader, curveN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate private key: %w", err)
+		}
+		if d.Sign() == 0 {
+			continue
+		}
+
+		return &PrivateKey{d: d, pub: scalarMult(d, curveG)}, nil
+	}
+}
+
+func (k *PrivateKey) Address() Address {
+	return publicKeyAddress(k.pub)
+}
+
+// Sign подписывает hash и возвращает r || s || v, где s низкий (EIP-2), а v — 27 или 28, как у кошельков
+func (k *PrivateKey) Sign(hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes")
+	}
+
+	e := new(big.Int).SetBytes(hash)
+
+	for {
+		nonce, err := rand.Int(rand.Reader, curveN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signature nonce: %w", err)
+		}
+		if nonce.Sign() == 0 {
+			continue
+		}
+
+		R := scalarMult(nonce, curveG)
+		if R.x.Cmp(curveN) >= 0 {
+			continue
+		}
+		r := new(big.Int).Set(R.x)
+
+		// s = k⁻¹(e + r·d)
+		s := new(big.Int).Mul(r, k.d)
+		s.Add(s, e).Mul(s, new(big.Int).ModInverse(nonce, curveN)).Mod(s, curveN)
+		if r.Sign() == 0 || s.Sign() == 0 {
+			continue
+		}
+
+		v := byte(R.y.Bit(0))
+		if s.Cmp(halfN) > 0 {
+			s.Sub(curveN, s)
+			v ^= 1
+		}
+
+		signature := make([]byte, SignatureLength)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:64])
+		signature[64] = 27 + v
+
+		return signature, nil
+	}
+}
+
+// SignPersonal подписывает message так же, как personal_sign в кошельке
+func (k *PrivateKey) SignPersonal(message []byte) ([]byte, error) {
+	return k.Sign(PersonalMessageHash(message))
+}
@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// NewHandler возвращает HTTP/JSON обработчик, который вызывает методы через gRPC клиента client,
+// поэтому HTTP запросы проходят interceptor'ы сервера (логирование, лимиты) так же, как gRPC.
+// Заголовок Authorization runtime передаёт в gRPC metadata под ключом "authorization",
+// адрес клиента — под ключом "x-forwarded-for".
+func NewHandler(ctx context.Context, client pb.AuthServiceClient) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithErrorHandler(errorHandler),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+	)
+
+	if err := pb.RegisterAuthServiceHandlerClient(ctx, mux, client); err != nil {
+		return nil, fmt.Errorf("failed to register auth service handler: %w", err)
+	}
+
+	return mux, nil
+}
+
+// headerMatcher дополнительно к стандартным заголовкам пропускает X-Client-Name для индекса сессий
+// и X-Admin-Token для административных методов
+func headerMatcher(key string) (string, bool) {
+	switch http.CanonicalHeaderKey(key) {
+	case "X-Client-Name":
+		return "x-client-name", true
+	case "X-Admin-Token":
+		return "x-admin-token", true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher отдаёт retry-after из metadata ответа стандартным заголовком Retry-After,
+// остальную metadata — с префиксом Grpc-Metadata-, как runtime по умолчанию
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if key == "retry-after" {
+		return "Retry-After", true
+	}
+
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+func errorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler,
+	w http.ResponseWriter, r *http.Request, err error) {
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, statusFromError(ctx, err))
+}
+
+// statusFromError пропускает gRPC статусы как есть: контроллер уже перевёл в них бизнес-ошибки.
+// Всё остальное, в том числе Unknown (сервер вернул ошибку без статуса), считается внутренней
+// ошибкой и клиенту не раскрывается
+func statusFromError(ctx context.Context, err error) error {
+	if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+		return err
+	}
+
+	logger.FromContext(ctx).Error("unexpected gateway error", zap.Error(err))
+
+	return status.Error(codes.Internal, "internal error")
+}
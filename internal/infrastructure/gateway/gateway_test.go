@@ -0,0 +1,224 @@
+package gateway
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	grpc2 "crypto_analyzer_auth_service/internal/infrastructure/grpc"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/storage"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeAuthServer struct {
+	pb.UnimplementedAuthServiceServer
+	loginErr error
+}
+
+func (f *fakeAuthServer) Login(_ context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	if f.loginErr != nil {
+		return nil, f.loginErr
+	}
+
+	return &pb.LoginResponse{Token: "access-" + req.GetUsername(), RefreshToken: "refresh"}, nil
+}
+
+func (f *fakeAuthServer) Verify(ctx context.Context, _ *pb.VerifyRequest) (*pb.VerifyResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get("authorization")
+	if len(tokens) == 0 || tokens[0] != "valid-token" {
+		return nil, status.Error(codes.Unauthenticated, domain.ErrInvalidAccessToken.Error())
+	}
+
+	return &pb.VerifyResponse{UserId: "user-id", Username: "user", Email: "user@gmail.com"}, nil
+}
+
+func (f *fakeAuthServer) ListSessions(ctx context.Context, _ *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	session := &pb.Session{Id: "session-id"}
+	if names := md.Get("x-client-name"); len(names) > 0 {
+		session.ClientName = names[0]
+	}
+	if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+		session.Ip = forwarded[0]
+	}
+
+	return &pb.ListSessionsResponse{Sessions: []*pb.Session{session}}, nil
+}
+
+func (f *fakeAuthServer) RevokeSession(_ context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	if req.GetSessionId() != "session-id" {
+		return nil, status.Error(codes.NotFound, domain.ErrNoSuchSession.Error())
+	}
+
+	return &pb.RevokeSessionResponse{}, nil
+}
+
+func (f *fakeAuthServer) Logout(_ context.Context, _ *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	return &pb.LogoutResponse{}, nil
+}
+
+func TestMain(m *testing.M) {
+	if err := logger.InitTestLogger(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newTestServer(t *testing.T, server pb.AuthServiceServer, opts ...grpc.ServerOption) *httptest.Server {
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterAuthServiceServer(grpcServer, server)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc2.ServeInProcess(grpcServer)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	handler, err := NewHandler(context.Background(), pb.NewAuthServiceClient(conn))
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func post(t *testing.T, ts *httptest.Server, path, body string, header http.Header) (*http.Response, map[string]any) {
+	return do(t, ts, http.MethodPost, path, body, header)
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string, header http.Header) (*http.Response, map[string]any) {
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	var decoded map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+
+	return resp, decoded
+}
+
+func TestGatewayLogin(t *testing.T) {
+	ts := newTestServer(t, &fakeAuthServer{})
+
+	resp, body := post(t, ts, "/auth/login", `{"username":"user","password":"New12321_new"}`, nil)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "access-user", body["token"])
+	require.Equal(t, "refresh", body["refreshToken"])
+}
+
+func TestGatewayLogout(t *testing.T) {
+	ts := newTestServer(t, &fakeAuthServer{})
+
+	resp, _ := post(t, ts, "/auth/logout", `{"refreshToken":"refresh"}`, nil)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGatewayVerifyForwardsAuthorization(t *testing.T) {
+	ts := newTestServer(t, &fakeAuthServer{})
+
+	resp, body := post(t, ts, "/auth/verify", `{}`, http.Header{"Authorization": {"valid-token"}})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "user-id", body["userId"])
+
+	resp, _ = post(t, ts, "/auth/verify", `{}`, nil)
+
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestGatewayErrorStatuses(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		httpStatus int
+		message    string
+	}{
+		{"invalid argument", status.Error(codes.InvalidArgument, domain.ErrWeakPassword.Error()), http.StatusBadRequest, domain.ErrWeakPassword.Error()},
+		{"unauthenticated", status.Error(codes.Unauthenticated, domain.ErrInvalidCredentials.Error()), http.StatusUnauthorized, domain.ErrInvalidCredentials.Error()},
+		{"already exists", status.Error(codes.AlreadyExists, domain.ErrEmailAlreadyTaken.Error()), http.StatusConflict, domain.ErrEmailAlreadyTaken.Error()},
+		{"not found", status.Error(codes.NotFound, domain.ErrNilUser.Error()), http.StatusNotFound, domain.ErrNilUser.Error()},
+		{"not a status", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal error"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := newTestServer(t, &fakeAuthServer{loginErr: tc.err})
+
+			resp, body := post(t, ts, "/auth/login", `{"username":"user","password":"New12321_new"}`, nil)
+
+			require.Equal(t, tc.httpStatus, resp.StatusCode)
+			require.Equal(t, tc.message, body["message"])
+		})
+	}
+}
+
+func TestGatewaySessions(t *testing.T) {
+	ts := newTestServer(t, &fakeAuthServer{})
+
+	resp, body := do(t, ts, http.MethodGet, "/auth/sessions", "", http.Header{"X-Client-Name": {"web"}})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	sessions := body["sessions"].([]any)
+	require.Len(t, sessions, 1)
+	session := sessions[0].(map[string]any)
+	require.Equal(t, "web", session["clientName"])
+	require.NotEmpty(t, session["ip"])
+
+	resp, _ = do(t, ts, http.MethodDelete, "/auth/sessions/session-id", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = do(t, ts, http.MethodDelete, "/auth/sessions/unknown", "", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	cfg := &model.RateLimitConfig{
+		Enabled: true,
+		Default: model.RateLimitRule{Burst: 100, Interval: time.Second, Key: model.RateLimitByIP},
+		Methods: map[string]model.RateLimitRule{
+			"Login": {Burst: 2, Interval: time.Minute, Key: model.RateLimitByIP},
+		},
+	}
+	interceptor := grpc2.RateLimitInterceptor(storage.NewMemoryRateLimiter(), cfg, &model.ProxyConfig{},
+		func(context.Context) string { return "" })
+	ts := newTestServer(t, &fakeAuthServer{}, grpc.UnaryInterceptor(interceptor))
+
+	body := `{"username":"user","password":"New12321_new"}`
+
+	for i := 0; i < 2; i++ {
+		resp, _ := post(t, ts, "/auth/login", body, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp, _ := post(t, ts, "/auth/login", body, http.Header{"X-Forwarded-For": {"198.51.100.7"}})
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "подделанный X-Forwarded-For не даёт нового бюджета")
+	require.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	resp, _ = post(t, ts, "/auth/logout", `{"refreshToken":"refresh"}`, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "у остальных методов свой бюджет")
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"path"
+)
+
+// AuthorizationInterceptor до вызова обработчика проверяет требование метода из requirements
+// (разрешение по короткому имени метода). Методы без требования пропускаются без проверки.
+// authorize решает, доступно ли разрешение вызывающему, и возвращает gRPC статус отказа
+// или контекст, с которым вызывается обработчик
+func AuthorizationInterceptor(requirements map[string]string,
+	authorize func(ctx context.Context, permission string) (context.Context, error)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		method := path.Base(info.FullMethod)
+
+		permission, ok := requirements[method]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		authorizedCtx, err := authorize(ctx, permission)
+		if err != nil {
+			logger.FromContext(ctx).Warn("call is not authorized", zap.String("permission", permission), zap.Error(err))
+			return nil, err
+		}
+
+		return handler(authorizedCtx, req)
+	}
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+type testActorKey struct{}
+
+func TestAuthorizationInterceptor(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), zap.NewNop())
+
+	allowed := false
+	var checked []string
+	interceptor := AuthorizationInterceptor(map[string]string{"AssignRole": "roles:manage"},
+		func(ctx context.Context, permission string) (context.Context, error) {
+			checked = append(checked, permission)
+			if !allowed {
+				return nil, status.Error(codes.PermissionDenied, "permission denied")
+			}
+			return context.WithValue(ctx, testActorKey{}, "actor"), nil
+		})
+
+	called := false
+	var actor interface{}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		actor = ctx.Value(testActorKey{})
+		return "ok", nil
+	}
+
+	// метод без требования не проверяется
+	resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/Login"}, handler)
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+	require.Empty(t, checked)
+
+	called = false
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/AssignRole"}, handler)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.False(t, called, "обработчик не вызывается без разрешения")
+	require.Equal(t, []string{"roles:manage"}, checked)
+
+	allowed = true
+	resp, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/AssignRole"}, handler)
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+	require.True(t, called)
+	require.Equal(t, "actor", actor, "обработчик получает контекст после проверки")
+	require.Equal(t, []string{"roles:manage", "roles:manage"}, checked, "проверка выполняется один раз на вызов")
+}
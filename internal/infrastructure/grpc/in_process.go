@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
+	"sync"
+)
+
+// ServeInProcess обслуживает server ещё и на соединении в памяти и возвращает клиента к нему.
+// Через него HTTP шлюз вызывает методы, проходя те же interceptor'ы, что и внешние gRPC клиенты.
+// Соединение закрывается вместе с server (Stop/GracefulStop)
+func ServeInProcess(server *grpc.Server) (*grpc.ClientConn, error) {
+	listener := newPipeListener()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logger.Log.Error("in-process grpc server failed", zap.Error(err))
+		}
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///in-process",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial in-process grpc server: %w", err)
+	}
+
+	return conn, nil
+}
+
+// pipeAddr — адрес соединений в памяти. Сеть не "tcp", поэтому адрес клиента для вызовов
+// шлюза берётся из x-forwarded-for (см. clientip.FromContext)
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "in-process" }
+
+// pipeListener — net.Listener поверх net.Pipe: DialContext отдаёт клиентский конец, Accept — серверный
+type pipeListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) DialContext(ctx context.Context) (net.Conn, error) {
+	serverConn, clientConn := net.Pipe()
+
+	select {
+	case l.conns <- serverConn:
+		return clientConn, nil
+	case <-l.done:
+	case <-ctx.Done():
+	}
+
+	_ = serverConn.Close()
+	_ = clientConn.Close()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, net.ErrClosed
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
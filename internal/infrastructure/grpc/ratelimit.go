@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/infrastructure/clientip"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/storage"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"math"
+	"path"
+	"strconv"
+	"time"
+)
+
+// RetryAfterHeader — metadata с числом секунд до следующей попытки при превышении лимита
+const RetryAfterHeader = "retry-after"
+
+// RateLimitInterceptor ограничивает частоту вызовов по правилу метода (cfg.Methods по короткому имени
+// метода, иначе cfg.Default). Бюджет у каждого метода свой. userID достаёт пользователя из access токена
+// для правил с ключом user. Адрес клиента определяется так же, как для блокировки входа (clientip.FromContext
+// с proxyCfg.TrustedHops). Ошибка хранилища лимитов запрос не отклоняет
+func RateLimitInterceptor(limiter storage.RateLimiterInterface, cfg *model.RateLimitConfig, proxyCfg *model.ProxyConfig,
+	userID func(ctx context.Context) string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if !cfg.Enabled {
+			return handler(ctx, req)
+		}
+
+		log := logger.FromContext(ctx)
+
+		method := path.Base(info.FullMethod)
+		rule, ok := cfg.Methods[method]
+		if !ok {
+			rule = cfg.Default
+		}
+
+		key := rateLimitKey(ctx, method, rule, proxyCfg.TrustedHops, userID)
+
+		allowed, retryAfter, err := limiter.Allow(ctx, key, rule)
+		if err != nil {
+			log.Error("failed to check rate limit", zap.Error(err))
+			return handler(ctx, req)
+		}
+		if !allowed {
+			log.Warn("rate limit exceeded", zap.String("key", key), zap.Duration("retry_after", retryAfter))
+			return nil, rateLimitedStatus(ctx, retryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitKey — ведро метода для ключа правила; для user без access токена считается адрес клиента
+func rateLimitKey(ctx context.Context, method string, rule model.RateLimitRule, trustedHops int,
+	userID func(ctx context.Context) string) string {
+	switch rule.Key {
+	case model.RateLimitByMethod:
+		return fmt.Sprintf("%s:%s", method, model.RateLimitByMethod)
+	case model.RateLimitByUser:
+		if id := userID(ctx); id != "" {
+			return fmt.Sprintf("%s:%s:%s", method, model.RateLimitByUser, id)
+		}
+	}
+
+	return fmt.Sprintf("%s:%s:%s", method, model.RateLimitByIP, clientip.FromContext(ctx, trustedHops))
+}
+
+// rateLimitedStatus — ResourceExhausted с google.rpc.RetryInfo и metadata retry-after в секундах
+func rateLimitedStatus(ctx context.Context, retryAfter time.Duration) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.FormatInt(seconds, 10))); err != nil {
+		logger.FromContext(ctx).Warn("failed to set retry-after header", zap.Error(err))
+	}
+
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded, try again later")
+
+	stWithDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+
+	return stWithDetails.Err()
+}
@@ -80,3 +80,9 @@ func WithTraceID(ctx context.Context, l *zap.Logger) *zap.Logger {
 
 	return l
 }
+
+// SecurityEvent пишет событие безопасности (повтор токена, подбор пароля и т.п.)
+// с отдельным полем security_event, по которому их удобно выбирать из логов
+func SecurityEvent(ctx context.Context, event string, fields ...zap.Field) {
+	FromContext(ctx).Warn("security event", append([]zap.Field{zap.String("security_event", event)}, fields...)...)
+}
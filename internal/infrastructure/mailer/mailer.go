@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"fmt"
+)
+
+// Message — письмо пользователю, тело — простой текст
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer выбирает реализацию по MAIL_DRIVER: smtp — отправка через SMTP сервер,
+// file — письма дописываются в файл (локальная разработка)
+func NewMailer(cfg *model.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	case "file":
+		return NewFileMailer(cfg.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	msg := Message{To: "user@example.com", Subject: "Сброс пароля", Body: "line1\nline2"}
+
+	raw := string(buildMessage("auth@example.com", msg, time.Unix(0, 0).UTC()))
+
+	require.Contains(t, raw, "From: auth@example.com\r\n")
+	require.Contains(t, raw, "To: user@example.com\r\n")
+	require.Contains(t, raw, "Subject: =?utf-8?q?")
+	require.True(t, strings.HasSuffix(raw, "\r\n\r\nline1\r\nline2"))
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	m := &SMTPMailer{}
+
+	err := m.Send(context.Background(), Message{To: "user@example.com\r\nBcc: other@example.com"})
+	require.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	m := NewFileMailer(path)
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "user@example.com", Subject: "first", Body: "token-1"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "user@example.com", Subject: "second", Body: "token-2"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "token-1")
+	require.Contains(t, string(content), "token-2")
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+
+	_, ok := m.Last("user@example.com")
+	require.False(t, ok)
+
+	go func() {
+		_ = m.Send(context.Background(), Message{To: "user@example.com", Body: "first"})
+		_ = m.Send(context.Background(), Message{To: "other@example.com", Body: "other"})
+		_ = m.Send(context.Background(), Message{To: "user@example.com", Body: "second"})
+	}()
+
+	msg, ok := m.WaitFor("user@example.com", 2, time.Second)
+	require.True(t, ok)
+	require.Equal(t, "second", msg.Body)
+	require.Equal(t, 1, m.Count("other@example.com"))
+}
@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryMailer складывает письма в память — для тестов
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Last возвращает последнее письмо на адрес to
+func (m *MemoryMailer) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
+
+// WaitFor ждёт, пока на адрес to придёт count-е письмо, и возвращает последнее.
+// Сервис отправляет письма в фоне, поэтому тестам нужно подождать доставку
+func (m *MemoryMailer) WaitFor(to string, count int, timeout time.Duration) (Message, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if m.Count(to) >= count {
+			return m.Last(to)
+		}
+		if time.Now().After(deadline) {
+			return Message{}, false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Count возвращает число писем на адрес to
+func (m *MemoryMailer) Count(to string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var count int
+	for _, msg := range m.messages {
+		if msg.To == to {
+			count++
+		}
+	}
+
+	return count
+}
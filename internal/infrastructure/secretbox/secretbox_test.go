@@ -0,0 +1,36 @@
+package secretbox
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{1}, KeySize))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("secret"), []byte("user-1"))
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "secret")
+
+	opened, err := box.Open(sealed, []byte("user-1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), opened)
+
+	_, err = box.Open(sealed, []byte("user-2"))
+	require.ErrorIs(t, err, ErrInvalidCiphertext, "шифртекст привязан к владельцу")
+
+	other, err := New(bytes.Repeat([]byte{2}, KeySize))
+	require.NoError(t, err)
+	_, err = other.Open(sealed, []byte("user-1"))
+	require.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = box.Open([]byte("short"), []byte("user-1"))
+	require.ErrorIs(t, err, ErrInvalidCiphertext)
+}
+
+func TestNewRejectsShortKey(t *testing.T) {
+	_, err := New([]byte("short"))
+	require.Error(t, err)
+}
@@ -0,0 +1,304 @@
+// Package siwe — сообщения Sign-In with Ethereum (EIP-4361): разбор, сборка и проверка полей.
+// Подпись сообщения проверяется через ethereum.VerifyPersonalSignature
+package siwe
+
+import (
+	"crypto/rand"
+	"crypto_analyzer_auth_service/internal/infrastructure/ethereum"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerSuffix = " wants you to sign in with your Ethereum account:"
+
+	tagURI            = "URI: "
+	tagVersion        = "Version: "
+	tagChainID        = "Chain ID: "
+	tagNonce          = "Nonce: "
+	tagIssuedAt       = "Issued At: "
+	tagExpirationTime = "Expiration Time: "
+	tagNotBefore      = "Not Before: "
+	tagRequestID      = "Request ID: "
+	tagResources      = "Resources:"
+
+	// Version — единственная версия сообщения из EIP-4361
+	Version = "1"
+
+	// nonceBytes — случайные байты nonce сервера; в hex получается 32 буквенно-цифровых символа
+	nonceBytes = 16
+
+	// clockSkew — насколько Issued At может опережать часы сервера из-за расхождения часов клиента
+	clockSkew = time.Minute
+)
+
+var (
+	ErrMalformed      = errors.New("malformed siwe message")
+	ErrDomainMismatch = errors.New("siwe message domain mismatch")
+	ErrChainMismatch  = errors.New("siwe message chain id is not allowed")
+	ErrExpired        = errors.New("siwe message has expired")
+	ErrNotYetValid    = errors.New("siwe message is not yet valid")
+)
+
+// nonceRegexp — nonce по EIP-4361: не меньше 8 букв и цифр
+var nonceRegexp = regexp.MustCompile(`^[A-Za-z0-9]{8,}$`)
+
+// Message — поля сообщения EIP-4361. Необязательные времена нулевые, если их нет в сообщении
+type Message struct {
+	Scheme         string
+	Domain         string
+	Address        ethereum.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+	NotBefore      time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// Params — чего сервер ждёт от сообщения: свой домен и разрешённые сети
+type Params struct {
+	Domain   string
+	ChainIDs []int64
+}
+
+// NewNonce — случайный nonce для сообщения
+func NewNonce() (string, error) {
+	b := make([]byte, nonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate siwe nonce: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Parse разбирает текст сообщения. Адрес должен быть записан с контрольной суммой EIP-55,
+// как того требует стандарт, поля — идти в порядке из стандарта
+func Parse(text string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	p := &parser{lines: lines}
+
+	var m Message
+
+	header, ok := p.next()
+	if !ok || !strings.HasSuffix(header, headerSuffix) {
+		return nil, fmt.Errorf("%w: no header", ErrMalformed)
+	}
+	m.Domain = strings.TrimSuffix(header, headerSuffix)
+	if scheme, domain, found := strings.Cut(m.Domain, "://"); found {
+		m.Scheme, m.Domain = scheme, domain
+	}
+	if m.Domain == "" || strings.ContainsAny(m.Domain, " /") {
+		return nil, fmt.Errorf("%w: invalid domain", ErrMalformed)
+	}
+
+	addressLine, _ := p.next()
+	address, err := ethereum.ParseAddress(addressLine)
+	if err != nil || address.String() != addressLine {
+		return nil, fmt.Errorf("%w: address must be EIP-55 checksummed", ErrMalformed)
+	}
+	m.Address = address
+
+	// пустая строка, необязательное утверждение, пустая строка
+	if line, _ := p.next(); line != "" {
+		return nil, fmt.Errorf("%w: no blank line after address", ErrMalformed)
+	}
+	if line, _ := p.peek(); line != "" && !strings.HasPrefix(line, tagURI) {
+		m.Statement, _ = p.next()
+	}
+	if line, _ := p.peek(); line == "" {
+		p.next()
+	}
+
+	if m.URI, err = p.field(tagURI, true); err != nil {
+		return nil, err
+	}
+	if m.Version, err = p.field(tagVersion, true); err != nil {
+		return nil, err
+	}
+
+	chainID, err := p.field(tagChainID, true)
+	if err != nil {
+		return nil, err
+	}
+	if m.ChainID, err = strconv.ParseInt(chainID, 10, 64); err != nil || m.ChainID < 1 {
+		return nil, fmt.Errorf("%w: invalid chain id", ErrMalformed)
+	}
+
+	if m.Nonce, err = p.field(tagNonce, true); err != nil {
+		return nil, err
+	}
+	if !nonceRegexp.MatchString(m.Nonce) {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrMalformed)
+	}
+
+	if m.IssuedAt, err = p.timeField(tagIssuedAt, true); err != nil {
+		return nil, err
+	}
+	if m.ExpirationTime, err = p.timeField(tagExpirationTime, false); err != nil {
+		return nil, err
+	}
+	if m.NotBefore, err = p.timeField(tagNotBefore, false); err != nil {
+		return nil, err
+	}
+	if m.RequestID, err = p.field(tagRequestID, false); err != nil {
+		return nil, err
+	}
+
+	if line, ok := p.peek(); ok && line == tagResources {
+		p.next()
+		for {
+			line, ok := p.peek()
+			if !ok || !strings.HasPrefix(line, "- ") {
+				break
+			}
+			p.next()
+			m.Resources = append(m.Resources, strings.TrimPrefix(line, "- "))
+		}
+	}
+
+	// кошельки и библиотеки иногда добавляют перевод строки в конце
+	for {
+		line, ok := p.next()
+		if !ok {
+			break
+		}
+		if line != "" {
+			return nil, fmt.Errorf("%w: unexpected line %q", ErrMalformed, line)
+		}
+	}
+
+	if m.URI == "" {
+		return nil, fmt.Errorf("%w: empty uri", ErrMalformed)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrMalformed, m.Version)
+	}
+
+	return &m, nil
+}
+
+// String собирает текст сообщения, который подписывает кошелёк
+func (m *Message) String() string {
+	var b strings.Builder
+
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + headerSuffix + "\n")
+	b.WriteString(m.Address.String() + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(tagURI + m.URI + "\n")
+	b.WriteString(tagVersion + m.Version + "\n")
+	b.WriteString(tagChainID + strconv.FormatInt(m.ChainID, 10) + "\n")
+	b.WriteString(tagNonce + m.Nonce + "\n")
+	b.WriteString(tagIssuedAt + m.IssuedAt.UTC().Format(time.RFC3339))
+	if !m.ExpirationTime.IsZero() {
+		b.WriteString("\n" + tagExpirationTime + m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if !m.NotBefore.IsZero() {
+		b.WriteString("\n" + tagNotBefore + m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		b.WriteString("\n" + tagRequestID + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\n" + tagResources)
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+
+	return b.String()
+}
+
+// Validate проверяет домен, сеть и срок действия сообщения на момент now. Nonce проверяет вызывающий:
+// он одноразовый и хранится на сервере
+func (m *Message) Validate(params Params, now time.Time) error {
+	if !strings.EqualFold(m.Domain, params.Domain) {
+		return fmt.Errorf("%w: %s", ErrDomainMismatch, m.Domain)
+	}
+
+	allowed := false
+	for _, chainID := range params.ChainIDs {
+		if m.ChainID == chainID {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %d", ErrChainMismatch, m.ChainID)
+	}
+
+	if !m.ExpirationTime.IsZero() && !now.Before(m.ExpirationTime) {
+		return ErrExpired
+	}
+	if !m.NotBefore.IsZero() && now.Before(m.NotBefore) || m.IssuedAt.After(now.Add(clockSkew)) {
+		return ErrNotYetValid
+	}
+
+	return nil
+}
+
+type parser struct {
+	lines []string
+	pos   int
+}
+
+func (p *parser) peek() (string, bool) {
+	if p.pos >= len(p.lines) {
+		return "", false
+	}
+
+	return p.lines[p.pos], true
+}
+
+func (p *parser) next() (string, bool) {
+	line, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+
+	return line, ok
+}
+
+// field читает строку "<tag><значение>"; необязательное поле, которого нет, даёт пустую строку
+func (p *parser) field(tag string, required bool) (string, error) {
+	line, ok := p.peek()
+	if !ok || !strings.HasPrefix(line, tag) {
+		if required {
+			return "", fmt.Errorf("%w: no %q", ErrMalformed, strings.TrimSpace(tag))
+		}
+		return "", nil
+	}
+	p.next()
+
+	return strings.TrimPrefix(line, tag), nil
+}
+
+func (p *parser) timeField(tag string, required bool) (time.Time, error) {
+	value, err := p.field(tag, required)
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid %q", ErrMalformed, strings.TrimSpace(tag))
+	}
+
+	return t, nil
+}
@@ -0,0 +1,126 @@
+package siwe
+
+import (
+	"crypto_analyzer_auth_service/internal/infrastructure/ethereum"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+// пример из EIP-4361
+const specMessage = `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.invalid/tos
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParseSpecMessage(t *testing.T) {
+	m, err := Parse(specMessage)
+	require.NoError(t, err)
+
+	require.Equal(t, "service.invalid", m.Domain)
+	require.Equal(t, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", m.Address.String())
+	require.Equal(t, "I accept the ServiceOrg Terms of Service: https://service.invalid/tos", m.Statement)
+	require.Equal(t, "https://service.invalid/login", m.URI)
+	require.Equal(t, int64(1), m.ChainID)
+	require.Equal(t, "32891756", m.Nonce)
+	require.Equal(t, time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC), m.IssuedAt.UTC())
+	require.Len(t, m.Resources, 2)
+
+	require.Equal(t, specMessage, m.String(), "сборка возвращает исходный текст")
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	key, err := ethereum.GenerateKey()
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for name, m := range map[string]*Message{
+		"minimal": {Domain: "analyzer.example", Address: key.Address(), URI: "https://analyzer.example", Version: Version,
+			ChainID: 137, Nonce: "abcdef0123456789", IssuedAt: now},
+		"full": {Scheme: "https", Domain: "analyzer.example:8443", Address: key.Address(), Statement: "Sign in",
+			URI: "https://analyzer.example:8443/login", Version: Version, ChainID: 1, Nonce: "abcdef0123456789",
+			IssuedAt: now, ExpirationTime: now.Add(time.Hour), NotBefore: now.Add(-time.Minute), RequestID: "req-1"},
+	} {
+		parsed, err := Parse(m.String())
+		require.NoError(t, err, name)
+		require.Equal(t, m, parsed, name)
+
+		parsed, err = Parse(m.String() + "\n")
+		require.NoError(t, err, "%s: перевод строки в конце", name)
+		require.Equal(t, m, parsed, name)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	lowercase := strings.Replace(specMessage, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", 1)
+
+	for name, text := range map[string]string{
+		"empty":             "",
+		"no header":         strings.Replace(specMessage, "wants you to sign in", "asks you to sign in", 1),
+		"lowercase address": lowercase,
+		"no uri":            strings.Replace(specMessage, "URI: https://service.invalid/login\n", "", 1),
+		"wrong version":     strings.Replace(specMessage, "Version: 1", "Version: 2", 1),
+		"bad chain id":      strings.Replace(specMessage, "Chain ID: 1", "Chain ID: one", 1),
+		"short nonce":       strings.Replace(specMessage, "Nonce: 32891756", "Nonce: 1234", 1),
+		"bad issued at":     strings.Replace(specMessage, "2021-09-30T16:25:24Z", "yesterday", 1),
+		"fields reordered":  strings.Replace(specMessage, "Version: 1\nChain ID: 1", "Chain ID: 1\nVersion: 1", 1),
+		"trailing garbage":  specMessage + "\nP.S. hello",
+	} {
+		_, err := Parse(text)
+		require.ErrorIs(t, err, ErrMalformed, name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+	params := Params{Domain: "analyzer.example", ChainIDs: []int64{1, 137}}
+
+	valid := func() *Message {
+		return &Message{Domain: "analyzer.example", Version: Version, ChainID: 137, Nonce: "abcdef0123456789",
+			IssuedAt: now.Add(-time.Minute), ExpirationTime: now.Add(time.Minute)}
+	}
+
+	require.NoError(t, valid().Validate(params, now))
+
+	m := valid()
+	m.Domain = "phishing.example"
+	require.ErrorIs(t, m.Validate(params, now), ErrDomainMismatch)
+
+	m = valid()
+	m.ChainID = 56
+	require.ErrorIs(t, m.Validate(params, now), ErrChainMismatch)
+
+	m = valid()
+	m.ExpirationTime = now
+	require.ErrorIs(t, m.Validate(params, now), ErrExpired)
+
+	m = valid()
+	m.NotBefore = now.Add(time.Second)
+	require.ErrorIs(t, m.Validate(params, now), ErrNotYetValid)
+
+	m = valid()
+	m.IssuedAt = now.Add(time.Hour)
+	require.ErrorIs(t, m.Validate(params, now), ErrNotYetValid, "сообщение из будущего")
+}
+
+func TestNewNonce(t *testing.T) {
+	nonce, err := NewNonce()
+	require.NoError(t, err)
+	require.Regexp(t, nonceRegexp, nonce, "nonce сервера проходит проверку формата EIP-4361")
+
+	other, err := NewNonce()
+	require.NoError(t, err)
+	require.NotEqual(t, nonce, other)
+}
@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// секрет и коды из приложения B RFC 6238 (SHA1), последние 6 цифр 8-значных кодов
+var rfcSecret = []byte("12345678901234567890")
+
+func TestCodeRFC6238(t *testing.T) {
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.code, Code(rfcSecret, Step(time.Unix(tc.unix, 0))), "unix %d", tc.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := Code(rfcSecret, Step(now))
+
+	step, ok := Validate(rfcSecret, code, now, 1)
+	require.True(t, ok)
+	require.Equal(t, Step(now), step)
+
+	step, ok = Validate(rfcSecret, code, now.Add(Period), 1)
+	require.True(t, ok, "предыдущий шаг допустим при skew 1")
+	require.Equal(t, Step(now), step)
+
+	_, ok = Validate(rfcSecret, code, now.Add(2*Period), 1)
+	require.False(t, ok, "код двух шагов назад устарел")
+
+	_, ok = Validate(rfcSecret, code, now.Add(Period), 0)
+	require.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "12345", now, 1)
+	require.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("Crypto Analyzer", "user@gmail.com", rfcSecret))
+	require.NoError(t, err)
+
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/Crypto Analyzer:user@gmail.com", uri.Path)
+	require.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri.Query().Get("secret"))
+	require.Equal(t, "Crypto Analyzer", uri.Query().Get("issuer"))
+	require.Equal(t, "6", uri.Query().Get("digits"))
+}
@@ -0,0 +1,151 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// maxCBORDepth ограничивает вложенность: в attestationObject и COSE ключах её почти нет,
+// а злонамеренный вход не должен разворачивать глубокую рекурсию
+const maxCBORDepth = 8
+
+// decodeCBOR разбирает один элемент CBOR (RFC 8949) и возвращает его вместе с остатком данных.
+// Поддерживается подмножество, которое используют аутентификаторы (CTAP2 canonical CBOR):
+// целые, байтовые и текстовые строки, массивы, словари, true/false/null. Словари — map[any]any
+// с ключами int64 или string, целые — int64
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, fmt.Errorf("%w: cbor nesting is too deep", ErrMalformed)
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: unexpected end of cbor", ErrMalformed)
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data[1:], nil
+		case 21:
+			return true, data[1:], nil
+		case 22:
+			return nil, data[1:], nil
+		default:
+			return nil, nil, fmt.Errorf("%w: unsupported cbor simple value %d", ErrMalformed, info)
+		}
+	}
+
+	arg, rest, err := decodeCBORArgument(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: cbor integer overflows int64", ErrMalformed)
+		}
+		return int64(arg), rest, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: cbor integer overflows int64", ErrMalformed)
+		}
+		return -1 - int64(arg), rest, nil
+	case 2, 3:
+		if arg > uint64(len(rest)) {
+			return nil, nil, fmt.Errorf("%w: cbor string is longer than data", ErrMalformed)
+		}
+		value := rest[:arg]
+		if major == 3 {
+			return string(value), rest[arg:], nil
+		}
+		return append([]byte(nil), value...), rest[arg:], nil
+	case 4:
+		// каждый элемент занимает хотя бы байт: длина больше остатка — заведомо битые данные
+		if arg > uint64(len(rest)) {
+			return nil, nil, fmt.Errorf("%w: cbor array is longer than data", ErrMalformed)
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item any
+			if item, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case 5:
+		if arg > uint64(len(rest))/2 {
+			return nil, nil, fmt.Errorf("%w: cbor map is longer than data", ErrMalformed)
+		}
+		items := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value any
+			if key, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("%w: unsupported cbor map key %T", ErrMalformed, key)
+			}
+			if _, ok := items[key]; ok {
+				return nil, nil, fmt.Errorf("%w: duplicate cbor map key %v", ErrMalformed, key)
+			}
+			if value, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, rest, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported cbor major type %d", ErrMalformed, major)
+	}
+}
+
+// decodeCBORArgument читает аргумент заголовка: значение, длину строки или число элементов.
+// Неопределённая длина не поддерживается — в canonical CBOR её нет
+func decodeCBORArgument(data []byte) (uint64, []byte, error) {
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, nil, fmt.Errorf("%w: unsupported cbor additional info %d", ErrMalformed, info)
+	}
+
+	if len(data) < size {
+		return 0, nil, fmt.Errorf("%w: unexpected end of cbor", ErrMalformed)
+	}
+
+	var arg uint64
+	switch size {
+	case 1:
+		arg = uint64(data[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(data))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(data))
+	case 8:
+		arg = binary.BigEndian.Uint64(data)
+	}
+
+	return arg, data[size:], nil
+}
@@ -0,0 +1,134 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+)
+
+// Алгоритмы COSE (RFC 9053), которые сервис предлагает аутентификатору, в порядке предпочтения
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms — pubKeyCredParams для параметров регистрации
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// параметры ключа COSE (RFC 9052, RFC 9053)
+const (
+	coseKeyType   int64 = 1
+	coseAlgorithm int64 = 3
+	coseCurve     int64 = -1 // для RSA — модуль n
+	coseX         int64 = -2 // для RSA — экспонента e
+	coseY         int64 = -3
+
+	coseKeyTypeOKP int64 = 1
+	coseKeyTypeEC2 int64 = 2
+	coseKeyTypeRSA int64 = 3
+
+	coseCurveP256    int64 = 1
+	coseCurveEd25519 int64 = 6
+)
+
+// minRSABits — RSA ключи короче не принимаются
+const minRSABits = 2048
+
+// publicKey — открытый ключ учётных данных, разобранный из COSE_Key
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey разбирает COSE_Key и возвращает ключ и остаток данных после него
+func parsePublicKey(data []byte) (*publicKey, []byte, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, ok := item.(map[any]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: cose key is not a map", ErrMalformed)
+	}
+
+	kty, _ := m[coseKeyType].(int64)
+	alg, _ := m[coseAlgorithm].(int64)
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		curve, _ := m[coseCurve].(int64)
+		x, _ := m[coseX].([]byte)
+		y, _ := m[coseY].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, nil, fmt.Errorf("%w: invalid ES256 key", ErrUnsupportedKey)
+		}
+
+		// ecdh проверяет, что точка лежит на кривой
+		point := append(append([]byte{4}, x...), y...)
+		if _, err = ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid ES256 key: %v", ErrUnsupportedKey, err)
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return &publicKey{alg: alg, key: key}, rest, nil
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		curve, _ := m[coseCurve].(int64)
+		x, _ := m[coseX].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("%w: invalid EdDSA key", ErrUnsupportedKey)
+		}
+
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, rest, nil
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := m[coseCurve].([]byte)
+		e, _ := m[coseX].([]byte)
+		if len(n)*8 < minRSABits || len(e) == 0 || len(e) > 4 {
+			return nil, nil, fmt.Errorf("%w: invalid RS256 key", ErrUnsupportedKey)
+		}
+
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}
+		return &publicKey{alg: alg, key: key}, rest, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: key type %d, algorithm %d", ErrUnsupportedKey, kty, alg)
+	}
+}
+
+// verify проверяет подпись message в формате WebAuthn: ES256 — ASN.1 DER, RS256 — PKCS #1 v1.5
+func (k *publicKey) verify(message, signature []byte) bool {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+// x509Algorithm — алгоритм подписи сертификата, соответствующий алгоритму COSE
+func x509Algorithm(alg int64) (x509.SignatureAlgorithm, bool) {
+	switch alg {
+	case AlgES256:
+		return x509.ECDSAWithSHA256, true
+	case AlgEdDSA:
+		return x509.PureEd25519, true
+	case AlgRS256:
+		return x509.SHA256WithRSA, true
+	default:
+		return x509.UnknownSignatureAlgorithm, false
+	}
+}
@@ -0,0 +1,369 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMalformed              = errors.New("malformed webauthn data")
+	ErrUnsupportedKey         = errors.New("unsupported credential public key")
+	ErrUnsupportedAttestation = errors.New("unsupported attestation statement")
+	ErrCeremonyMismatch       = errors.New("webauthn response does not match the ceremony")
+	ErrInvalidSignature       = errors.New("invalid webauthn signature")
+)
+
+// ChallengeSize — байт случайности в челлендже (WebAuthn требует не меньше 16)
+const ChallengeSize = 32
+
+// Типы clientDataJSON для регистрации и входа
+const (
+	TypeCreate = "webauthn.create"
+	TypeGet    = "webauthn.get"
+)
+
+// флаги authenticatorData
+const (
+	flagUserPresent    byte = 0x01
+	flagUserVerified   byte = 0x04
+	flagBackupEligible byte = 0x08
+	flagBackupState    byte = 0x10
+	flagAttestedData   byte = 0x40
+	flagExtensionData  byte = 0x80
+)
+
+// maxCredentialIDLength — предел длины id учётных данных из спецификации WebAuthn
+const maxCredentialIDLength = 1023
+
+// RelyingParty — кто проверяет ответы аутентификатора: RP ID (домен, к которому привязаны ключи)
+// и origin-ы фронтенда, с которых разрешены церемонии
+type RelyingParty struct {
+	ID                      string
+	Origins                 []string
+	RequireUserVerification bool
+}
+
+// ClientData — поля clientDataJSON, которые проверяет сервер
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Credential — учётные данные, принятые при регистрации. PublicKey — COSE_Key в исходном виде
+type Credential struct {
+	ID                []byte
+	PublicKey         []byte
+	SignCount         uint32
+	AAGUID            []byte
+	AttestationFormat string
+	BackupEligible    bool
+	BackupState       bool
+}
+
+// Assertion — проверенный результат входа
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+	BackupState  bool
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    *publicKey
+	rawPublicKey []byte
+}
+
+// NewChallenge генерирует челлендж церемонии в base64url без паддинга — в таком виде
+// браузер кладёт его в clientDataJSON
+func NewChallenge() (string, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", fmt.Errorf("failed to generate webauthn challenge: %w", err)
+	}
+
+	return EncodeChallenge(challenge), nil
+}
+
+func EncodeChallenge(challenge []byte) string {
+	return base64.RawURLEncoding.EncodeToString(challenge)
+}
+
+func DecodeChallenge(challenge string) ([]byte, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: challenge is not base64url: %v", ErrMalformed, err)
+	}
+
+	return raw, nil
+}
+
+// ParseClientData разбирает clientDataJSON. Челлендж из него нужен, чтобы найти церемонию,
+// поэтому разбор отделён от проверки
+func ParseClientData(clientDataJSON []byte) (*ClientData, error) {
+	var clientData ClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return nil, fmt.Errorf("%w: client data: %v", ErrMalformed, err)
+	}
+
+	return &clientData, nil
+}
+
+// VerifyRegistration проверяет ответ navigator.credentials.create(): clientDataJSON, attestationObject
+// и аттестацию форматов none и packed. Цепочка сертификатов аттестации не проверяется —
+// сервис не ограничивает модели аутентификаторов, подпись аттестации лишь должна сходиться
+func VerifyRegistration(rp RelyingParty, challenge string, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := verifyClientData(rp, clientDataJSON, TypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	item, rest, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data after attestation object", ErrMalformed)
+	}
+
+	object, ok := item.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object is not a map", ErrMalformed)
+	}
+
+	format, _ := object["fmt"].(string)
+	statement, _ := object["attStmt"].(map[any]any)
+	rawAuthData, _ := object["authData"].([]byte)
+	if format == "" || statement == nil || rawAuthData == nil {
+		return nil, fmt.Errorf("%w: attestation object misses fmt, attStmt or authData", ErrMalformed)
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedData == 0 {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrMalformed)
+	}
+	if err = checkAuthenticatorData(rp, authData); err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+
+	if err = verifyAttestation(format, statement, signed, authData.publicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:                authData.credentialID,
+		PublicKey:         authData.rawPublicKey,
+		SignCount:         authData.signCount,
+		AAGUID:            authData.aaguid,
+		AttestationFormat: format,
+		BackupEligible:    authData.flags&flagBackupEligible != 0,
+		BackupState:       authData.flags&flagBackupState != 0,
+	}, nil
+}
+
+// VerifyAssertion проверяет ответ navigator.credentials.get() открытым ключом, сохранённым при регистрации.
+// Счётчик подписей возвращается как есть: сравнить его с сохранённым должен вызывающий
+func VerifyAssertion(rp RelyingParty, challenge string, credentialPublicKey, clientDataJSON, rawAuthData, signature []byte) (*Assertion, error) {
+	key, rest, err := parsePublicKey(credentialPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data after stored public key", ErrMalformed)
+	}
+
+	if err = verifyClientData(rp, clientDataJSON, TypeGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkAuthenticatorData(rp, authData); err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+
+	if !key.verify(signed, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	return &Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+		BackupState:  authData.flags&flagBackupState != 0,
+	}, nil
+}
+
+func verifyClientData(rp RelyingParty, clientDataJSON []byte, ceremonyType, challenge string) error {
+	clientData, err := ParseClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	if clientData.Type != ceremonyType {
+		return fmt.Errorf("%w: client data type %q", ErrCeremonyMismatch, clientData.Type)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return fmt.Errorf("%w: challenge", ErrCeremonyMismatch)
+	}
+	// церемония во фрейме чужого сайта — типичный сценарий фишинга
+	if clientData.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremony", ErrCeremonyMismatch)
+	}
+
+	for _, origin := range rp.Origins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: origin %q", ErrCeremonyMismatch, clientData.Origin)
+}
+
+func checkAuthenticatorData(rp RelyingParty, authData *authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return fmt.Errorf("%w: rp id hash", ErrCeremonyMismatch)
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return fmt.Errorf("%w: user is not present", ErrCeremonyMismatch)
+	}
+	if rp.RequireUserVerification && authData.flags&flagUserVerified == 0 {
+		return fmt.Errorf("%w: user is not verified", ErrCeremonyMismatch)
+	}
+	if authData.flags&flagBackupState != 0 && authData.flags&flagBackupEligible == 0 {
+		return fmt.Errorf("%w: backup state without backup eligibility", ErrMalformed)
+	}
+
+	return nil
+}
+
+// parseAuthenticatorData разбирает authenticatorData: хеш RP ID, флаги, счётчик подписей
+// и, при флаге AT, id учётных данных с открытым ключом
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data is too short", ErrMalformed)
+	}
+
+	authData := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if authData.flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data is too short", ErrMalformed)
+		}
+
+		authData.aaguid = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || idLength > maxCredentialIDLength || idLength > len(rest) {
+			return nil, fmt.Errorf("%w: invalid credential id length %d", ErrMalformed, idLength)
+		}
+
+		authData.credentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		key, afterKey, err := parsePublicKey(rest)
+		if err != nil {
+			return nil, err
+		}
+		authData.publicKey = key
+		authData.rawPublicKey = rest[:len(rest)-len(afterKey)]
+		rest = afterKey
+	}
+
+	if authData.flags&flagExtensionData != 0 {
+		extensions, afterExtensions, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := extensions.(map[any]any); !ok {
+			return nil, fmt.Errorf("%w: extensions are not a map", ErrMalformed)
+		}
+		rest = afterExtensions
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data after authenticator data", ErrMalformed)
+	}
+
+	return authData, nil
+}
+
+// verifyAttestation проверяет подпись аттестации над authenticatorData || hash(clientDataJSON).
+// packed без x5c — самоаттестация ключом учётных данных, с x5c — ключом первого сертификата
+func verifyAttestation(format string, statement map[any]any, signed []byte, credentialKey *publicKey) error {
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return fmt.Errorf("%w: none attestation with statement", ErrMalformed)
+		}
+		return nil
+	case "packed":
+		alg, _ := statement["alg"].(int64)
+		signature, _ := statement["sig"].([]byte)
+		if signature == nil {
+			return fmt.Errorf("%w: packed attestation without signature", ErrMalformed)
+		}
+
+		chain, hasChain := statement["x5c"].([]any)
+		if !hasChain {
+			if alg != credentialKey.alg {
+				return fmt.Errorf("%w: self attestation algorithm differs from credential", ErrUnsupportedAttestation)
+			}
+			if !credentialKey.verify(signed, signature) {
+				return fmt.Errorf("%w: self attestation", ErrInvalidSignature)
+			}
+			return nil
+		}
+
+		if len(chain) == 0 {
+			return fmt.Errorf("%w: empty x5c", ErrMalformed)
+		}
+		der, _ := chain[0].([]byte)
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("%w: attestation certificate: %v", ErrMalformed, err)
+		}
+		if certificate.IsCA {
+			return fmt.Errorf("%w: attestation certificate is a CA", ErrUnsupportedAttestation)
+		}
+
+		algorithm, ok := x509Algorithm(alg)
+		if !ok {
+			return fmt.Errorf("%w: attestation algorithm %d", ErrUnsupportedAttestation, alg)
+		}
+		if err = certificate.CheckSignature(algorithm, signed, signature); err != nil {
+			return fmt.Errorf("%w: attestation: %v", ErrInvalidSignature, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: format %q", ErrUnsupportedAttestation, format)
+	}
+}
@@ -0,0 +1,152 @@
+package webauthn
+
+import (
+	"crypto_analyzer_auth_service/internal/infrastructure/webauthn/webauthntest"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var testRP = RelyingParty{ID: "localhost", Origins: []string{"http://localhost:3000"}, RequireUserVerification: true}
+
+func newTestAuthenticator(t *testing.T) *webauthntest.Authenticator {
+	authenticator, err := webauthntest.New(testRP.ID, testRP.Origins[0])
+	require.NoError(t, err)
+
+	return authenticator
+}
+
+func newTestChallenge(t *testing.T) (string, []byte) {
+	challenge, err := NewChallenge()
+	require.NoError(t, err)
+
+	raw, err := DecodeChallenge(challenge)
+	require.NoError(t, err)
+
+	return challenge, raw
+}
+
+func register(t *testing.T, authenticator *webauthntest.Authenticator) *Credential {
+	challenge, raw := newTestChallenge(t)
+
+	attestation, err := authenticator.Create(raw, []byte("user-handle"))
+	require.NoError(t, err)
+
+	credential, err := VerifyRegistration(testRP, challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	require.NoError(t, err)
+
+	return credential
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	credential := register(t, authenticator)
+	require.Equal(t, authenticator.CredentialID, credential.ID)
+	require.Equal(t, authenticator.PublicKey(), credential.PublicKey)
+	require.Equal(t, "none", credential.AttestationFormat)
+	require.Zero(t, credential.SignCount)
+
+	challenge, raw := newTestChallenge(t)
+	assertion, err := authenticator.Get(raw)
+	require.NoError(t, err)
+
+	result, err := VerifyAssertion(testRP, challenge, credential.PublicKey, assertion.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), result.SignCount)
+	require.True(t, result.UserVerified)
+}
+
+func TestRegistrationSelfAttestation(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	authenticator.SelfAttestation = true
+
+	credential := register(t, authenticator)
+	require.Equal(t, "packed", credential.AttestationFormat)
+}
+
+func TestRegistrationRejectsMismatch(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	challenge, raw := newTestChallenge(t)
+
+	attestation, err := authenticator.Create(raw, []byte("user-handle"))
+	require.NoError(t, err)
+
+	otherChallenge, _ := newTestChallenge(t)
+	_, err = VerifyRegistration(testRP, otherChallenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	require.ErrorIs(t, err, ErrCeremonyMismatch, "чужой челлендж")
+
+	otherRP := testRP
+	otherRP.ID = "evil.example"
+	_, err = VerifyRegistration(otherRP, challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	require.ErrorIs(t, err, ErrCeremonyMismatch, "ключ другого RP ID")
+
+	authenticator.Origin = "https://evil.example"
+	attestation, err = authenticator.Create(raw, []byte("user-handle"))
+	require.NoError(t, err)
+	_, err = VerifyRegistration(testRP, challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	require.ErrorIs(t, err, ErrCeremonyMismatch, "чужой origin")
+
+	authenticator.Origin = testRP.Origins[0]
+	authenticator.SkipUserVerification = true
+	attestation, err = authenticator.Create(raw, []byte("user-handle"))
+	require.NoError(t, err)
+	_, err = VerifyRegistration(testRP, challenge, attestation.ClientDataJSON, attestation.AttestationObject)
+	require.ErrorIs(t, err, ErrCeremonyMismatch, "без проверки пользователя")
+
+	_, err = VerifyRegistration(testRP, challenge, attestation.ClientDataJSON, attestation.AttestationObject[:20])
+	require.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestAssertionRejectsForeignSignature(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	credential := register(t, authenticator)
+
+	challenge, raw := newTestChallenge(t)
+
+	// подпись другого ключа под тем же id учётных данных
+	impostor := newTestAuthenticator(t)
+	impostor.CredentialID = authenticator.CredentialID
+	assertion, err := impostor.Get(raw)
+	require.NoError(t, err)
+
+	_, err = VerifyAssertion(testRP, challenge, credential.PublicKey, assertion.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	assertion, err = authenticator.Get(raw)
+	require.NoError(t, err)
+
+	_, err = VerifyAssertion(testRP, challenge, credential.PublicKey, assertion.ClientDataJSON,
+		assertion.AuthenticatorData, append(assertion.Signature[:len(assertion.Signature)-1], 0))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// ответ регистрации нельзя выдать за вход
+	attestation, err := authenticator.Create(raw, nil)
+	require.NoError(t, err)
+	_, err = VerifyAssertion(testRP, challenge, credential.PublicKey, attestation.ClientDataJSON,
+		assertion.AuthenticatorData, assertion.Signature)
+	require.ErrorIs(t, err, ErrCeremonyMismatch)
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	// {1: -7, "a": [h'0102', true, null]}
+	data := []byte{0xa2, 0x01, 0x26, 0x61, 'a', 0x83, 0x42, 0x01, 0x02, 0xf5, 0xf6, 0xff}
+
+	item, rest, err := decodeCBOR(data)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xff}, rest)
+	require.Equal(t, map[any]any{int64(1): int64(-7), "a": []any{[]byte{1, 2}, true, nil}}, item)
+
+	for _, malformed := range [][]byte{
+		{},
+		{0x5f},             // неопределённая длина
+		{0x59, 0xff, 0xff}, // строка длиннее данных
+		{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		{0xa2, 0x01, 0x01, 0x01, 0x02}, // повтор ключа
+		{0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x00},
+	} {
+		_, _, err = decodeCBOR(malformed)
+		require.ErrorIs(t, err, ErrMalformed, "% x", malformed)
+	}
+}
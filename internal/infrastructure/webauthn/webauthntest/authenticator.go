@@ -0,0 +1,230 @@
+// Package webauthntest — программный аутентификатор для тестов церемоний WebAuthn без настоящего ключа.
+// Он отвечает так же, как браузер с платформенным аутентификатором: ES256 ключ,
+// аттестация none или packed (самоаттестация), счётчик подписей растёт с каждым входом
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// Authenticator хранит одну пару ключей учётных данных. Поля можно менять между вызовами,
+// чтобы собрать неправильный ответ: чужой origin, откат счётчика, вход без проверки пользователя
+type Authenticator struct {
+	RPID   string
+	Origin string
+
+	CredentialID []byte
+	UserHandle   []byte
+	SignCount    uint32
+
+	// SkipUserVerification снимает флаг UV, как аутентификатор без PIN и биометрии
+	SkipUserVerification bool
+	// SelfAttestation включает аттестацию packed вместо none
+	SelfAttestation bool
+
+	key *ecdsa.PrivateKey
+}
+
+// Attestation — ответ navigator.credentials.create()
+type Attestation struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// Assertion — ответ navigator.credentials.get()
+type Assertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+func New(rpID, origin string) (*Authenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate authenticator key: %w", err)
+	}
+
+	credentialID := make([]byte, 16)
+	if _, err = rand.Read(credentialID); err != nil {
+		return nil, fmt.Errorf("failed to generate credential id: %w", err)
+	}
+
+	return &Authenticator{RPID: rpID, Origin: origin, CredentialID: credentialID, key: key}, nil
+}
+
+// Create регистрирует учётные данные для пользователя с user handle userHandle
+func (a *Authenticator) Create(challenge, userHandle []byte) (*Attestation, error) {
+	a.UserHandle = userHandle
+
+	clientDataJSON, err := a.clientData("webauthn.create", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.authenticatorData(0x40)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.CredentialID)))
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, a.PublicKey()...)
+
+	format := "none"
+	statement := cborMap{}
+	if a.SelfAttestation {
+		signature, err := a.sign(authData, clientDataJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		format = "packed"
+		statement = cborMap{{"alg", int64(-7)}, {"sig", signature}}
+	}
+
+	// canonical CBOR: ключи-строки упорядочены по длине, затем побайтно
+	attestationObject := appendCBOR(nil, cborMap{
+		{"fmt", format},
+		{"attStmt", statement},
+		{"authData", authData},
+	})
+
+	return &Attestation{
+		CredentialID:      a.CredentialID,
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: attestationObject,
+	}, nil
+}
+
+// Get подписывает челлендж входа, предварительно увеличив счётчик подписей
+func (a *Authenticator) Get(challenge []byte) (*Assertion, error) {
+	a.SignCount++
+
+	clientDataJSON, err := a.clientData("webauthn.get", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.authenticatorData(0)
+
+	signature, err := a.sign(authData, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Assertion{
+		CredentialID:      a.CredentialID,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         signature,
+		UserHandle:        a.UserHandle,
+	}, nil
+}
+
+// PublicKey — открытый ключ в виде COSE_Key (EC2, ES256, P-256)
+func (a *Authenticator) PublicKey() []byte {
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+
+	return appendCBOR(nil, cborMap{
+		{int64(1), int64(2)},
+		{int64(3), int64(-7)},
+		{int64(-1), int64(1)},
+		{int64(-2), x},
+		{int64(-3), y},
+	})
+}
+
+func (a *Authenticator) clientData(ceremonyType string, challenge []byte) ([]byte, error) {
+	clientDataJSON, err := json.Marshal(map[string]any{
+		"type":        ceremonyType,
+		"challenge":   base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode client data: %w", err)
+	}
+
+	return clientDataJSON, nil
+}
+
+func (a *Authenticator) authenticatorData(extraFlags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+
+	flags := byte(0x01) | extraFlags
+	if !a.SkipUserVerification {
+		flags |= 0x04
+	}
+
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+func (a *Authenticator) sign(authData, clientDataJSON []byte) ([]byte, error) {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	return signature, nil
+}
+
+// cborMap — словарь CBOR с ключами в заданном порядке
+type cborMap []cborPair
+
+type cborPair struct {
+	key   any
+	value any
+}
+
+// appendCBOR кодирует подмножество CBOR, которого достаточно для attestationObject и COSE_Key
+func appendCBOR(dst []byte, value any) []byte {
+	switch v := value.(type) {
+	case int64:
+		if v >= 0 {
+			return appendCBORHead(dst, 0, uint64(v))
+		}
+		return appendCBORHead(dst, 1, uint64(-1-v))
+	case []byte:
+		return append(appendCBORHead(dst, 2, uint64(len(v))), v...)
+	case string:
+		return append(appendCBORHead(dst, 3, uint64(len(v))), v...)
+	case cborMap:
+		dst = appendCBORHead(dst, 5, uint64(len(v)))
+		for _, pair := range v {
+			dst = appendCBOR(dst, pair.key)
+			dst = appendCBOR(dst, pair.value)
+		}
+		return dst
+	default:
+		panic(fmt.Sprintf("webauthntest: unsupported cbor value %T", value))
+	}
+}
+
+func appendCBORHead(dst []byte, major byte, arg uint64) []byte {
+	major <<= 5
+
+	switch {
+	case arg < 24:
+		return append(dst, major|byte(arg))
+	case arg <= 0xff:
+		return append(dst, major|24, byte(arg))
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(dst, major|25), uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(dst, major|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, major|27), arg)
+	}
+}
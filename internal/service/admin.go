@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+// UnlockAccount снимает блокировку входа аккаунта и сбрасывает его счётчик неудач;
+// если передан ip, то же делается для адреса
+func (s *ControllerService) UnlockAccount(ctx context.Context, req *auth.UnlockAccountRequest) (*auth.UnlockAccountResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if _, err := actorFromContext(ctx, "UnlockAccount"); err != nil {
+		return nil, err
+	}
+
+	userID := req.GetUserId()
+	email := req.GetEmail()
+	ip := req.GetIp()
+
+	if userID == "" && email == "" && ip == "" {
+		log.Warn("nothing to unlock", zap.Error(domain.ErrNoAccountID))
+		return nil, domain.ErrNoAccountID
+	}
+
+	if userID != "" || email != "" {
+		var user *domain.User
+		var err error
+		if userID != "" {
+			user, err = s.Storage.GetUserByUserID(ctx, userID)
+		} else {
+			user, err = s.Storage.GetUserByUsernameEmail(ctx, "", email)
+		}
+		if err != nil {
+			log.Error("failed to get user", zap.Error(err))
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			log.Warn("nil user", zap.Error(domain.ErrNilUser))
+			return nil, domain.ErrNilUser
+		}
+
+		userID = user.ID
+	}
+
+	if err := s.Attempts.Unlock(ctx, userID, ip); err != nil {
+		log.Error("failed to unlock account", zap.Error(err))
+		return nil, fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	logger.SecurityEvent(ctx, "account_unlocked", zap.String("user_id", userID), zap.String("ip", ip))
+
+	return &auth.UnlockAccountResponse{}, nil
+}
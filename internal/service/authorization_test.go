@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRoleClaims(t *testing.T) {
+	roles, scope := roleClaims([]*domain.Role{
+		{Name: "support", Permissions: []string{"roles:read", "accounts:unlock"}},
+		{Name: "admin", Permissions: []string{"accounts:unlock", "roles:manage", "roles:read"}},
+		{Name: "viewer", Permissions: []string{}},
+	})
+
+	require.Equal(t, []string{"admin", "support", "viewer"}, roles)
+	require.Equal(t, []string{"accounts:unlock", "roles:manage", "roles:read"}, scope, "разрешения без повторов")
+
+	roles, scope = roleClaims(nil)
+	require.NotNil(t, roles, "пользователь без ролей получает пустые claims")
+	require.Empty(t, roles)
+	require.NotNil(t, scope)
+	require.Empty(t, scope)
+}
+
+func TestMethodPermissionsAreKnown(t *testing.T) {
+	known := []string{domain.PermissionAccountsUnlock, domain.PermissionRolesRead, domain.PermissionRolesManage}
+
+	for method, permission := range MethodPermissions {
+		require.Contains(t, known, permission, method)
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := actorFromContext(ctx, "AssignRole")
+	require.ErrorIs(t, err, domain.ErrPermissionDenied, "вызов в обход interceptor'а")
+
+	authorized := withAuthorizedCall(ctx, domain.PermissionRolesManage, "actor-id")
+
+	actorID, err := actorFromContext(authorized, "AssignRole")
+	require.NoError(t, err)
+	require.Equal(t, "actor-id", actorID)
+
+	_, err = actorFromContext(authorized, "UnlockAccount")
+	require.ErrorIs(t, err, domain.ErrPermissionDenied, "проверено другое разрешение")
+
+	_, err = actorFromContext(authorized, "Login")
+	require.ErrorIs(t, err, domain.ErrPermissionDenied, "метод без требования")
+
+	actorID, err = actorFromContext(withAuthorizedCall(ctx, domain.PermissionAccountsUnlock, ""), "UnlockAccount")
+	require.NoError(t, err, "ADMIN_API_TOKEN")
+	require.Empty(t, actorID)
+}
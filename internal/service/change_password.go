@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+// ChangePassword меняет пароль по текущему паролю и access токену. Новый пароль проверяется политикой
+// и историей, после смены отзываются все сессии пользователя, кроме той, из которой пришёл запрос
+func (s *ControllerService) ChangePassword(ctx context.Context, req *auth.ChangePasswordRequest) (*auth.ChangePasswordResponse, error) {
+	log := logger.FromContext(ctx)
+
+	currentPassword := req.GetCurrentPassword()
+	newPassword := req.GetNewPassword()
+
+	if currentPassword == "" || newPassword == "" {
+		log.Warn("not enough data to change password", zap.Error(domain.ErrNotEnoughData))
+		return nil, domain.ErrNotEnoughData
+	}
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *domain.User
+	user, err = s.Storage.GetUserByUserID(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user == nil {
+		log.Error("nil user", zap.Error(domain.ErrNilUser))
+		return nil, domain.ErrNilUser
+	}
+
+	ok, _, err := s.Hasher.Verify(currentPassword, user.PasswordHash)
+	if err != nil {
+		log.Error("failed to compare hash and password", zap.Error(err))
+		return nil, fmt.Errorf("failed to compare hash and password: %w", err)
+	}
+	if !ok {
+		log.Warn("wrong current password", zap.Error(domain.ErrWrongCurrentPassword))
+		return nil, domain.ErrWrongCurrentPassword
+	}
+
+	if err = s.validateNewPassword(newPassword, user.Username, user.Email); err != nil {
+		log.Warn("password validation failed", zap.Error(err))
+		return nil, err
+	}
+
+	passwordHash, err := s.Hasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate password hash", zap.Error(err))
+		return nil, fmt.Errorf("failed to generate password hash: %w", err)
+	}
+
+	err = s.Storage.ChangePassword(ctx, user.ID, passwordHash, s.PasswordCfg.HistorySize, func(hashes []string) error {
+		return s.checkPasswordReuse(hashes, newPassword)
+	})
+	var policyErr *domain.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		log.Warn("password reuse check failed", zap.Error(err))
+		return nil, err
+	}
+	if err != nil {
+		log.Error("failed to change password", zap.Error(err))
+		return nil, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	var currentSessionID string
+	if claims.TokenID != "" {
+		currentSessionID, err = s.Session.SessionIDByAccessToken(ctx, claims.TokenID)
+		if err != nil {
+			log.Error("failed to get current session", zap.Error(err))
+			return nil, fmt.Errorf("failed to get current session: %w", err)
+		}
+	}
+
+	var revoked int
+	revoked, err = s.Session.RevokeOtherSessions(ctx, user.ID, currentSessionID)
+	if err != nil {
+		log.Error("failed to revoke other sessions", zap.Error(err))
+		return nil, fmt.Errorf("failed to revoke other sessions: %w", err)
+	}
+
+	log.Info("password changed", zap.String("kept_session_id", currentSessionID), zap.Int("revoked", revoked))
+
+	return &auth.ChangePasswordResponse{RevokedSessions: int32(revoked)}, nil
+}
+
+// checkPasswordReuse отклоняет пароль, совпадающий с одним из хешей: текущим или последними PASSWORD_HISTORY_SIZE
+func (s *ControllerService) checkPasswordReuse(hashes []string, password string) error {
+	for _, passwordHash := range hashes {
+		ok, _, err := s.Hasher.Verify(password, passwordHash)
+		if err != nil {
+			return fmt.Errorf("failed to compare hash and password: %w", err)
+		}
+		if ok {
+			return &domain.PasswordPolicyError{Violations: []domain.PasswordViolation{{
+				Code:    ViolationReused,
+				Message: "password must differ from recently used passwords",
+			}}}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/clientip"
+	"google.golang.org/grpc/metadata"
+)
+
+// clientInfo собирает данные о клиенте для индекса сессий и учёта неудачных входов.
+// Адрес берётся через clientip.FromContext с TRUSTED_PROXY_HOPS, поэтому подставленный клиентом
+// x-forwarded-for не меняет ключ блокировки. За HTTP шлюзом user agent берётся из grpcgateway-user-agent
+func (s *ControllerService) clientInfo(ctx context.Context) domain.ClientInfo {
+	var info domain.ClientInfo
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	info.IP = clientip.FromContext(ctx, s.ProxyCfg.TrustedHops)
+
+	info.UserAgent = firstMetadata(md, "grpcgateway-user-agent")
+	if info.UserAgent == "" {
+		info.UserAgent = firstMetadata(md, "user-agent")
+	}
+
+	info.ClientName = firstMetadata(md, "x-client-name")
+
+	return info
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
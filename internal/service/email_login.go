@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
+	"encoding/base64"
+	"fmt"
+	"go.uber.org/zap"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emailLoginCodeDigits — длина кода из письма; перебор ограничен EMAIL_LOGIN_MAX_ATTEMPTS
+const emailLoginCodeDigits = 6
+
+// StartEmailLogin отправляет на email код и ссылку для входа без пароля. Новый запрос заменяет
+// предыдущий код и ссылку. Ответ одинаков для зарегистрированного и незарегистрированного email
+func (s *ControllerService) StartEmailLogin(ctx context.Context, req *auth.StartEmailLoginRequest) (*auth.StartEmailLoginResponse, error) {
+	log := logger.FromContext(ctx)
+
+	email := req.GetEmail()
+	if !isValidEmail(email) {
+		log.Warn("invalid email format", zap.Error(domain.ErrWeakEmail))
+		return nil, domain.ErrWeakEmail
+	}
+
+	user, err := s.Storage.GetUserByUsernameEmail(ctx, "", email)
+	if err != nil {
+		log.Error("failed to get user by email", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	if user == nil {
+		log.Info("email login requested for unknown email")
+		return &auth.StartEmailLoginResponse{}, nil
+	}
+
+	code, err := newEmailLoginCode()
+	if err != nil {
+		log.Error("failed to generate email login code", zap.Error(err))
+		return nil, err
+	}
+
+	nonce, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate email login link", zap.Error(err))
+		return nil, err
+	}
+
+	ttl := s.EmailLoginCfg.CodeTTL
+	if err = s.EmailLogins.SaveEmailLogin(ctx, user.ID, code, nonce, ttl); err != nil {
+		log.Error("failed to save email login", zap.Error(err))
+		return nil, fmt.Errorf("failed to save email login: %w", err)
+	}
+
+	token := s.signEmailLoginToken(user.ID, nonce, time.Now().Add(ttl))
+
+	s.sendMail(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in code",
+		Body: fmt.Sprintf("Someone asked to sign in to your account %s without a password.\n\n"+
+			"Your code: %s\n\nOr open the link below:\n%s\n\n"+
+			"The code and the link work once and expire in %s.\n"+
+			"If it was not you, ignore this email and do not share the code with anyone.\n",
+			user.Username, code, s.mailLink("/login/email", token), ttl),
+	})
+
+	log.Info("email login started", zap.String("user_id", user.ID))
+
+	return &auth.StartEmailLoginResponse{}, nil
+}
+
+// CompleteEmailLogin выдаёт токены по коду из письма (вместе с email) или по токену из ссылки.
+// Код и ссылка одноразовые и гасят друг друга. Неверные попытки расходуют лимит входа и учитываются
+// в блокировках как неудачные входы. Вход по письму подтверждает email, а при включённой 2FA
+// требует второй фактор так же, как Login
+func (s *ControllerService) CompleteEmailLogin(ctx context.Context, req *auth.CompleteEmailLoginRequest) (*auth.CompleteEmailLoginResponse, error) {
+	log := logger.FromContext(ctx)
+
+	ip := s.clientInfo(ctx).IP
+
+	var userID string
+	switch {
+	case req.GetToken() != "":
+		var nonce string
+		var ok bool
+		userID, nonce, ok = s.parseEmailLoginToken(req.GetToken(), time.Now())
+		if !ok {
+			log.Warn("invalid email login link", zap.Error(domain.ErrInvalidEmailLoginToken))
+			return nil, domain.ErrInvalidEmailLoginToken
+		}
+
+		if err := s.checkLoginBlock(ctx, userID, ip); err != nil {
+			return nil, err
+		}
+
+		used, err := s.EmailLogins.UseEmailLoginLink(ctx, userID, nonce, s.EmailLoginCfg.MaxAttempts)
+		if err != nil {
+			log.Error("failed to use email login link", zap.Error(err))
+			return nil, fmt.Errorf("failed to use email login link: %w", err)
+		}
+		if !used {
+			s.recordLoginFailure(ctx, userID, ip)
+			log.Warn("email login link has been already used or replaced", zap.Error(domain.ErrInvalidEmailLoginToken))
+			return nil, domain.ErrInvalidEmailLoginToken
+		}
+	case req.GetEmail() != "" && req.GetCode() != "":
+		email := req.GetEmail()
+		if !isValidEmail(email) {
+			log.Warn("invalid email format", zap.Error(domain.ErrWeakEmail))
+			return nil, domain.ErrWeakEmail
+		}
+
+		user, err := s.Storage.GetUserByUsernameEmail(ctx, "", email)
+		if err != nil {
+			log.Error("failed to get user by email", zap.Error(err))
+			return nil, fmt.Errorf("failed to get user by email: %w", err)
+		}
+
+		accountKey := loginAccountKey(user, "", email)
+		if err = s.checkLoginBlock(ctx, accountKey, ip); err != nil {
+			return nil, err
+		}
+
+		used := false
+		if user != nil {
+			used, err = s.EmailLogins.UseEmailLoginCode(ctx, user.ID, strings.TrimSpace(req.GetCode()), s.EmailLoginCfg.MaxAttempts)
+			if err != nil {
+				log.Error("failed to use email login code", zap.Error(err))
+				return nil, fmt.Errorf("failed to use email login code: %w", err)
+			}
+		}
+		if !used {
+			s.recordLoginFailure(ctx, accountKey, ip)
+			log.Warn("invalid email login code", zap.Bool("user_found", user != nil), zap.Error(domain.ErrInvalidEmailLoginCode))
+			return nil, domain.ErrInvalidEmailLoginCode
+		}
+
+		userID = user.ID
+	default:
+		log.Warn("not enough data to complete email login", zap.Error(domain.ErrNoEmailLoginCode))
+		return nil, domain.ErrNoEmailLoginCode
+	}
+
+	user, err := s.Storage.GetUserByUserID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user == nil {
+		log.Warn("email login of deleted user", zap.Error(domain.ErrInvalidEmailLoginToken))
+		return nil, domain.ErrInvalidEmailLoginToken
+	}
+
+	if err = s.Attempts.ResetAccount(ctx, user.ID); err != nil {
+		log.Warn("failed to reset login failures", zap.Error(err))
+	}
+
+	// письмо дошло до адресата — это то же доказательство владения адресом, что и ссылка подтверждения
+	if user.EmailVerifiedAt.IsZero() {
+		user.EmailVerifiedAt = time.Now()
+		if err = s.Storage.MarkEmailVerified(ctx, user.ID, user.EmailVerifiedAt); err != nil {
+			log.Error("failed to mark email verified", zap.Error(err))
+			return nil, fmt.Errorf("failed to mark email verified: %w", err)
+		}
+	}
+
+	mfaToken, err := s.startMFAChallenge(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if mfaToken != "" {
+		return &auth.CompleteEmailLoginResponse{MfaRequired: true, MfaToken: mfaToken}, nil
+	}
+
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("email login completed")
+
+	return &auth.CompleteEmailLoginResponse{
+		Token:        accessToken.Token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// newEmailLoginCode — случайный код из emailLoginCodeDigits цифр с ведущими нулями
+func newEmailLoginCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < emailLoginCodeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate email login code: %w", err)
+	}
+
+	return fmt.Sprintf("%0*d", emailLoginCodeDigits, n), nil
+}
+
+// signEmailLoginToken — токен ссылки вида userID.expires.nonce.signature, подписанный HMAC-SHA256 ключом
+// EMAIL_LOGIN_LINK_SECRET. Подделанные и просроченные ссылки отсекаются без обращения к Redis,
+// а одноразовость обеспечивает nonce, сохранённый вместе с кодом
+func (s *ControllerService) signEmailLoginToken(userID, nonce string, expires time.Time) string {
+	payload := userID + "." + strconv.FormatInt(expires.Unix(), 10) + "." + nonce
+
+	return payload + "." + s.emailLoginSignature(payload)
+}
+
+// parseEmailLoginToken проверяет подпись и срок токена ссылки и возвращает пользователя и nonce
+func (s *ControllerService) parseEmailLoginToken(token string, now time.Time) (string, string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return "", "", false
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(s.emailLoginSignature(payload))) {
+		return "", "", false
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return "", "", false
+	}
+
+	return parts[0], parts[2], true
+}
+
+func (s *ControllerService) emailLoginSignature(payload string) string {
+	mac := hmac.New(sha256.New, s.EmailLoginCfg.LinkSecret)
+	mac.Write([]byte("email_login:" + payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"crypto_analyzer_auth_service/internal/config/model"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEmailLoginCode(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9]{6}$`)
+
+	for i := 0; i < 100; i++ {
+		code, err := newEmailLoginCode()
+		require.NoError(t, err)
+		require.Regexp(t, format, code)
+	}
+}
+
+func TestEmailLoginToken(t *testing.T) {
+	s := &ControllerService{EmailLoginCfg: &model.EmailLoginConfig{LinkSecret: []byte("secret")}}
+	now := time.Unix(1700000000, 0)
+
+	token := s.signEmailLoginToken("user-id", "nonce", now.Add(time.Minute))
+
+	userID, nonce, ok := s.parseEmailLoginToken(token, now)
+	require.True(t, ok)
+	require.Equal(t, "user-id", userID)
+	require.Equal(t, "nonce", nonce)
+
+	_, _, ok = s.parseEmailLoginToken(token, now.Add(time.Minute))
+	require.False(t, ok, "просроченная ссылка")
+
+	forged := strings.Replace(token, "user-id", "other-id", 1)
+	_, _, ok = s.parseEmailLoginToken(forged, now)
+	require.False(t, ok, "подмена пользователя ломает подпись")
+
+	other := &ControllerService{EmailLoginCfg: &model.EmailLoginConfig{LinkSecret: []byte("other secret")}}
+	_, _, ok = other.parseEmailLoginToken(token, now)
+	require.False(t, ok, "подпись другим ключом")
+
+	for _, malformed := range []string{"", "a.b.c", "a.b.c.d.e", token + "x"} {
+		_, _, ok = s.parseEmailLoginToken(malformed, now)
+		require.False(t, ok, malformed)
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
+	"crypto_analyzer_auth_service/internal/storage"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// VerifyEmail подтверждает email по одноразовому токену из письма
+func (s *ControllerService) VerifyEmail(ctx context.Context, req *auth.VerifyEmailRequest) (*auth.VerifyEmailResponse, error) {
+	log := logger.FromContext(ctx)
+
+	token := req.GetToken()
+	if token == "" {
+		log.Warn("empty email verification token", zap.Error(domain.ErrNoVerificationToken))
+		return nil, domain.ErrNoVerificationToken
+	}
+
+	userID, err := s.Tokens.ConsumeToken(ctx, storage.TokenPurposeEmailVerification, token)
+	if err != nil {
+		log.Error("failed to consume email verification token", zap.Error(err))
+		return nil, fmt.Errorf("failed to consume email verification token: %w", err)
+	}
+	if userID == "" {
+		log.Warn("unknown email verification token", zap.Error(domain.ErrInvalidVerification))
+		return nil, domain.ErrInvalidVerification
+	}
+
+	err = s.Storage.MarkEmailVerified(ctx, userID, time.Now())
+	if errors.Is(err, domain.ErrNilUser) {
+		log.Warn("email verification token of deleted user", zap.Error(domain.ErrInvalidVerification))
+		return nil, domain.ErrInvalidVerification
+	}
+	if err != nil {
+		log.Error("failed to mark email verified", zap.Error(err))
+		return nil, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	log.Info("email verified", zap.String("user_id", userID))
+
+	return &auth.VerifyEmailResponse{}, nil
+}
+
+// ResendVerificationEmail повторно отправляет письмо подтверждения. Ответ одинаков для
+// незарегистрированного, уже подтверждённого и неподтверждённого email
+func (s *ControllerService) ResendVerificationEmail(ctx context.Context, req *auth.ResendVerificationEmailRequest) (*auth.ResendVerificationEmailResponse, error) {
+	log := logger.FromContext(ctx)
+
+	email := req.GetEmail()
+	if !isValidEmail(email) {
+		log.Warn("invalid email format", zap.Error(domain.ErrWeakEmail))
+		return nil, domain.ErrWeakEmail
+	}
+
+	user, err := s.Storage.GetUserByUsernameEmail(ctx, "", email)
+	if err != nil {
+		log.Error("failed to get user by email", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	if user == nil || !user.EmailVerifiedAt.IsZero() {
+		log.Info("verification email is not needed")
+		return &auth.ResendVerificationEmailResponse{}, nil
+	}
+
+	s.sendVerificationEmail(ctx, user)
+
+	return &auth.ResendVerificationEmailResponse{}, nil
+}
+
+// sendVerificationEmail выпускает токен подтверждения (предыдущий перестаёт действовать) и отправляет письмо.
+// Ошибка не прерывает вызывающий запрос: письмо можно запросить повторно
+func (s *ControllerService) sendVerificationEmail(ctx context.Context, user *domain.User) {
+	log := logger.FromContext(ctx)
+
+	token, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate email verification token", zap.Error(err))
+		return
+	}
+
+	err = s.Tokens.SaveToken(ctx, storage.TokenPurposeEmailVerification, user.ID, token, s.EmailCfg.TokenTTL)
+	if err != nil {
+		log.Error("failed to save email verification token", zap.Error(err))
+		return
+	}
+
+	s.sendMail(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Welcome to Crypto Analyzer, %s!\n\n"+
+			"To confirm your email, open the link below within %s:\n%s\n\n"+
+			"If you did not sign up, ignore this email.\n",
+			user.Username, s.EmailCfg.TokenTTL, s.mailLink("/verify-email", token)),
+	})
+}
+
+// emailVerificationBlocks — в режиме block вход запрещён, пока email не подтверждён.
+// Аккаунту без email (созданному входом через кошелёк) подтверждать нечего
+func (s *ControllerService) emailVerificationBlocks(user *domain.User) bool {
+	return s.EmailCfg.Mode == model.EmailVerificationBlock && user.Email != "" && user.EmailVerifiedAt.IsZero()
+}
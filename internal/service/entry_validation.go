@@ -1,31 +1,28 @@
 package service
 
 import (
+	"crypto_analyzer_auth_service/internal/domain"
 	"regexp"
-	"unicode"
 )
 
-func isValidPassword(password string) bool {
-	if len(password) < 8 {
-		return false
-	}
+// validateNewPassword проверяет пароль, который пользователь задаёт (регистрация, смена пароля),
+// и возвращает *domain.PasswordPolicyError со всеми нарушениями
+func (s *ControllerService) validateNewPassword(password, username, email string) error {
+	violations := s.PasswordPolicy.Check(password, username, email)
 
-	var hasUpper, hasLower, hasNumber, hasSpecial bool
+	breached, err := s.PasswordPolicy.CheckBreached(password)
+	if err != nil {
+		return err
+	}
+	if breached != nil {
+		violations = append(violations, *breached)
+	}
 
-	for _, char := range password {
-		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsDigit(char):
-			hasNumber = true
-		case unicode.IsPunct(char) || unicode.IsSymbol(char):
-			hasSpecial = true
-		}
+	if len(violations) > 0 {
+		return &domain.PasswordPolicyError{Violations: violations}
 	}
 
-	return hasUpper && hasLower && hasNumber && hasSpecial
+	return nil
 }
 
 func isValidEmail(email string) bool {
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+)
+
+func (s *ControllerService) GetJWKS(_ context.Context, _ *auth.GetJWKSRequest) (*auth.GetJWKSResponse, error) {
+	publicKeys := s.JWTManager.PublicKeys()
+
+	keys := make([]*auth.JWK, 0, len(publicKeys))
+	for _, key := range publicKeys {
+		jwk := &auth.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Use: key.Use,
+			Alg: key.Alg,
+		}
+
+		if key.N != "" {
+			jwk.N = &key.N
+			jwk.E = &key.E
+		}
+		if key.X != "" {
+			jwk.Crv = &key.Crv
+			jwk.X = &key.X
+		}
+
+		keys = append(keys, jwk)
+	}
+
+	return &auth.GetJWKSResponse{Keys: keys}, nil
+}
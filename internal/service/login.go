@@ -7,7 +7,6 @@ import (
 	"crypto_analyzer_auth_service/internal/infrastructure/logger"
 	"fmt"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func (s *ControllerService) Login(ctx context.Context, req *auth.LoginRequest) (*auth.LoginResponse, error) {
@@ -27,49 +26,86 @@ func (s *ControllerService) Login(ctx context.Context, req *auth.LoginRequest) (
 			return nil, domain.ErrWeakEmail
 		}
 	}
-	if !isValidPassword(req.Password) {
-		log.Warn("invalid password format", zap.Error(domain.ErrWeakPassword))
-		return nil, domain.ErrWeakPassword
-	}
 
 	user, err := s.Storage.GetUserByUsernameEmail(ctx, username, email)
 	if err != nil {
 		log.Error("failed to get user by username/email", zap.Error(err))
 		return nil, fmt.Errorf("failed to get user by username/email: %w", err)
 	}
-	if user == nil {
-		log.Error("nil user", zap.Error(domain.ErrNilUser))
-		return nil, domain.ErrNilUser
+
+	ip := s.clientInfo(ctx).IP
+	accountKey := loginAccountKey(user, username, email)
+
+	if err = s.checkLoginBlock(ctx, accountKey, ip); err != nil {
+		return nil, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
-	if err != nil {
-		log.Error("failed to compare hash and password", zap.Error(err))
-		return nil, fmt.Errorf("failed to compare hash and password: %w", err)
+	var ok, needsRehash bool
+	if user != nil {
+		ok, needsRehash, err = s.Hasher.Verify(password, user.PasswordHash)
+		if err != nil {
+			log.Error("failed to compare hash and password", zap.Error(err))
+			return nil, fmt.Errorf("failed to compare hash and password: %w", err)
+		}
+	} else {
+		// без сверки с хешем ответ для несуществующего пользователя приходил бы заметно быстрее
+		s.Hasher.VerifyDummy(password)
 	}
 
-	var accessToken string
-	accessToken, err = s.JWTManager.GenerateAccessToken(user.ID, username, email)
-	if err != nil {
-		log.Error("failed to generate access token", zap.Error(err))
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	// несуществующий пользователь и неверный пароль неотличимы для клиента, различаются только в логах
+	if !ok {
+		s.recordLoginFailure(ctx, accountKey, ip)
+		log.Warn("invalid credentials", zap.Bool("user_found", user != nil), zap.Error(domain.ErrInvalidCredentials))
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if err = s.Attempts.ResetAccount(ctx, user.ID); err != nil {
+		log.Warn("failed to reset login failures", zap.Error(err))
+	}
+
+	if needsRehash {
+		s.rehashPassword(ctx, user.ID, password)
+	}
+
+	if s.emailVerificationBlocks(user) {
+		log.Warn("email is not verified", zap.Error(domain.ErrEmailNotVerified))
+		return nil, domain.ErrEmailNotVerified
 	}
 
-	var refreshToken string
-	refreshToken, err = s.JWTManager.GenerateRefreshToken()
+	mfaToken, err := s.startMFAChallenge(ctx, user)
 	if err != nil {
-		log.Error("failed to generate refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
+	}
+	if mfaToken != "" {
+		return &auth.LoginResponse{MfaRequired: true, MfaToken: mfaToken}, nil
 	}
 
-	err = s.Session.SaveRefreshToken(ctx, user.ID, refreshToken)
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
 	if err != nil {
-		log.Error("failed to save refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+		return nil, err
 	}
 
 	return &auth.LoginResponse{
-		Token:        accessToken,
+		Token:        accessToken.Token,
 		RefreshToken: refreshToken,
 	}, nil
 }
+
+// rehashPassword пересчитывает устаревший хеш пароля текущими параметрами.
+// Ошибка не мешает входу: хеш обновится при следующем логине
+func (s *ControllerService) rehashPassword(ctx context.Context, userID, password string) {
+	log := logger.FromContext(ctx)
+
+	passwordHash, err := s.Hasher.Hash(password)
+	if err != nil {
+		log.Warn("failed to rehash password", zap.Error(err))
+		return
+	}
+
+	if err = s.Storage.UpdatePasswordHash(ctx, userID, passwordHash); err != nil {
+		log.Warn("failed to update password hash", zap.Error(err))
+		return
+	}
+
+	log.Info("password hash upgraded")
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// loginAccountKey — ключ учёта неудачных входов аккаунта: id пользователя, а для несуществующего —
+// хеш логина, под которым пытались войти. Несуществующие аккаунты получают те же задержки и блокировки,
+// что и настоящие, поэтому по ним нельзя понять, зарегистрирован ли логин
+func loginAccountKey(user *domain.User, username, email string) string {
+	if user != nil {
+		return user.ID
+	}
+
+	login := "username:" + strings.ToLower(username)
+	if email != "" {
+		login = "email:" + strings.ToLower(email)
+	}
+
+	sum := sha256.Sum256([]byte(login))
+
+	return "unknown:" + hex.EncodeToString(sum[:])
+}
+
+// checkLoginBlock возвращает *domain.LoginBlockedError, если вход для аккаунта или адреса временно запрещён.
+// Проверка идёт до сверки пароля, поэтому ответ не зависит от того, верен ли пароль
+func (s *ControllerService) checkLoginBlock(ctx context.Context, accountKey, ip string) error {
+	log := logger.FromContext(ctx)
+
+	block, err := s.Attempts.Blocked(ctx, accountKey, ip)
+	if err != nil {
+		log.Error("failed to check login block", zap.Error(err))
+		return fmt.Errorf("failed to check login block: %w", err)
+	}
+	if block == nil {
+		return nil
+	}
+
+	log.Warn("login is blocked", zap.String("scope", block.Scope), zap.Bool("locked", block.Locked),
+		zap.Duration("retry_after", block.RetryAfter))
+
+	return &domain.LoginBlockedError{Locked: block.Locked, RetryAfter: block.RetryAfter}
+}
+
+// recordLoginFailure учитывает неудачный вход. Ошибка учёта только пишется в лог:
+// клиент в любом случае получает ответ о неверных данных
+func (s *ControllerService) recordLoginFailure(ctx context.Context, accountKey, ip string) {
+	log := logger.FromContext(ctx)
+
+	block, err := s.Attempts.RecordFailure(ctx, accountKey, ip)
+	if err != nil {
+		log.Error("failed to record login failure", zap.Error(err))
+		return
+	}
+
+	if block != nil && block.Locked {
+		logger.SecurityEvent(ctx, "login_lockout", zap.String("scope", block.Scope), zap.String("account", accountKey),
+			zap.String("ip", ip), zap.Duration("duration", block.RetryAfter))
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
+	"encoding/base64"
+	"fmt"
+	"go.uber.org/zap"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// oneTimeTokenBytes — энтропия одноразовых токенов из писем
+	oneTimeTokenBytes = 32
+	// mailSendTimeout ограничивает фоновую отправку письма
+	mailSendTimeout = 30 * time.Second
+)
+
+// newOneTimeToken генерирует случайный токен для ссылки в письме
+func newOneTimeToken() (string, error) {
+	b := make([]byte, oneTimeTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// mailLink строит ссылку на страницу фронтенда с токеном в query
+func (s *ControllerService) mailLink(path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", strings.TrimRight(s.MailCfg.LinkBaseURL, "/"), path, url.QueryEscape(token))
+}
+
+// sendMail отправляет письмо в фоне: время ответа и его результат не зависят от доставки,
+// поэтому по ним нельзя понять, существует ли адрес. Ошибка доставки только пишется в лог
+func (s *ControllerService) sendMail(ctx context.Context, msg mailer.Message) {
+	log := logger.FromContext(ctx)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+
+		if err := s.Mailer.Send(ctx, msg); err != nil {
+			log.Error("failed to send mail", zap.String("subject", msg.Subject), zap.Error(err))
+			return
+		}
+
+		log.Info("mail sent", zap.String("subject", msg.Subject))
+	}()
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/totp"
+	"crypto_analyzer_auth_service/internal/storage"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// recoveryCodeLength — символов base32 в коде восстановления (50 бит), показываются двумя группами по 5
+const recoveryCodeLength = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// startMFAChallenge выдаёт токен MFA челленджа, если у пользователя включена 2FA; иначе пустую строку
+func (s *ControllerService) startMFAChallenge(ctx context.Context, user *domain.User) (string, error) {
+	log := logger.FromContext(ctx)
+
+	userTOTP, err := s.Storage.GetTOTP(ctx, user.ID)
+	if err != nil {
+		log.Error("failed to get totp", zap.Error(err))
+		return "", fmt.Errorf("failed to get totp: %w", err)
+	}
+	if userTOTP == nil || userTOTP.ConfirmedAt.IsZero() {
+		return "", nil
+	}
+
+	token, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate mfa token", zap.Error(err))
+		return "", fmt.Errorf("failed to generate mfa token: %w", err)
+	}
+
+	if err = s.Tokens.SaveToken(ctx, storage.TokenPurposeMFAChallenge, user.ID, token, s.MFACfg.ChallengeTTL); err != nil {
+		log.Error("failed to save mfa token", zap.Error(err))
+		return "", fmt.Errorf("failed to save mfa token: %w", err)
+	}
+
+	log.Info("mfa challenge started")
+
+	return token, nil
+}
+
+// CompleteMFA обменивает токен MFA челленджа и код на пару токенов. Неверные коды считаются
+// как неудачные входы аккаунта, поэтому перебор кодов упирается в задержки и блокировку
+func (s *ControllerService) CompleteMFA(ctx context.Context, req *auth.CompleteMFARequest) (*auth.CompleteMFAResponse, error) {
+	log := logger.FromContext(ctx)
+
+	mfaToken := req.GetMfaToken()
+	code := req.GetCode()
+
+	if mfaToken == "" {
+		log.Warn("empty mfa token", zap.Error(domain.ErrNoMFAToken))
+		return nil, domain.ErrNoMFAToken
+	}
+	if code == "" {
+		log.Warn("empty mfa code", zap.Error(domain.ErrNoMFACode))
+		return nil, domain.ErrNoMFACode
+	}
+
+	userID, err := s.Tokens.PeekToken(ctx, storage.TokenPurposeMFAChallenge, mfaToken)
+	if err != nil {
+		log.Error("failed to get mfa token", zap.Error(err))
+		return nil, fmt.Errorf("failed to get mfa token: %w", err)
+	}
+	if userID == "" {
+		log.Warn("unknown mfa token", zap.Error(domain.ErrInvalidMFAToken))
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	ip := s.clientInfo(ctx).IP
+	if err = s.checkLoginBlock(ctx, userID, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Storage.GetUserByUserID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+
+	userTOTP, err := s.Storage.GetTOTP(ctx, userID)
+	if err != nil {
+		log.Error("failed to get totp", zap.Error(err))
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+	if user == nil || userTOTP == nil || userTOTP.ConfirmedAt.IsZero() {
+		log.Warn("mfa token of deleted user or disabled totp", zap.Error(domain.ErrInvalidMFAToken))
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	ok, err := s.verifySecondFactor(ctx, userTOTP, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.recordLoginFailure(ctx, userID, ip)
+		log.Warn("invalid mfa code", zap.Error(domain.ErrInvalidMFACode))
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	consumedBy, err := s.Tokens.ConsumeToken(ctx, storage.TokenPurposeMFAChallenge, mfaToken)
+	if err != nil {
+		log.Error("failed to consume mfa token", zap.Error(err))
+		return nil, fmt.Errorf("failed to consume mfa token: %w", err)
+	}
+	if consumedBy != userID {
+		log.Warn("mfa token has been already used", zap.Error(domain.ErrInvalidMFAToken))
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	if err = s.Attempts.ResetAccount(ctx, userID); err != nil {
+		log.Warn("failed to reset login failures", zap.Error(err))
+	}
+
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.CompleteMFAResponse{
+		Token:        accessToken.Token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// verifySecondFactor принимает TOTP код (каждый шаг только один раз) или неиспользованный код восстановления
+func (s *ControllerService) verifySecondFactor(ctx context.Context, userTOTP *domain.TOTP, code string) (bool, error) {
+	log := logger.FromContext(ctx)
+
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+
+	if isTOTPCode(code) {
+		secret, err := s.openTOTPSecret(userTOTP)
+		if err != nil {
+			log.Error("failed to decrypt totp secret", zap.Error(err))
+			return false, err
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now(), s.MFACfg.TOTPSkew)
+		if !ok {
+			return false, nil
+		}
+
+		// шаг сдвигается атомарно: один и тот же код не пройдёт дважды даже в параллельных запросах
+		fresh, err := s.Storage.UseTOTPStep(ctx, userTOTP.UserID, step)
+		if err != nil {
+			log.Error("failed to use totp step", zap.Error(err))
+			return false, fmt.Errorf("failed to use totp step: %w", err)
+		}
+		if !fresh {
+			log.Warn("totp code replayed", zap.Int64("step", step))
+		}
+
+		return fresh, nil
+	}
+
+	used, err := s.Storage.UseRecoveryCode(ctx, userTOTP.UserID, hashRecoveryCode(code))
+	if err != nil {
+		log.Error("failed to use recovery code", zap.Error(err))
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if used {
+		logger.SecurityEvent(ctx, "recovery_code_used", zap.String("user_id", userTOTP.UserID))
+	}
+
+	return used, nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRecoveryCodes генерирует n кодов вида xxxxx-xxxxx и их хеши для хранения
+func newRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:recoveryCodeLength]
+		codes = append(codes, raw[:recoveryCodeLength/2]+"-"+raw[recoveryCodeLength/2:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode — SHA-256 кода без дефисов и регистра. Коды случайные (50 бит),
+// поэтому медленный хеш не нужен, а поиск по хешу остаётся одним запросом
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+	require.Len(t, hashes, 10)
+
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		require.Regexp(t, format, code)
+		require.False(t, seen[code], "коды не повторяются")
+		seen[code] = true
+
+		require.Equal(t, hashes[i], hashRecoveryCode(code))
+		require.NotContains(t, hashes[i], code[:5], "хранится только хеш")
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	require.Equal(t, hashRecoveryCode("abcde-fghij"), hashRecoveryCode("ABCDEFGHIJ"))
+	require.NotEqual(t, hashRecoveryCode("abcde-fghij"), hashRecoveryCode("abcde-fghik"))
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	require.True(t, isTOTPCode("012345"))
+	require.False(t, isTOTPCode("12345"))
+	require.False(t, isTOTPCode("abcde-fghij"))
+	require.False(t, isTOTPCode("12345a"))
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bufio"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/storage"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Коды нарушений политики паролей, уходят клиенту в google.rpc.BadRequest как reason
+const (
+	ViolationTooShort        = "PASSWORD_TOO_SHORT"
+	ViolationTooLong         = "PASSWORD_TOO_LONG"
+	ViolationNoUpper         = "PASSWORD_NO_UPPERCASE"
+	ViolationNoLower         = "PASSWORD_NO_LOWERCASE"
+	ViolationNoDigit         = "PASSWORD_NO_DIGIT"
+	ViolationNoSpecial       = "PASSWORD_NO_SPECIAL"
+	ViolationContainsAccount = "PASSWORD_CONTAINS_ACCOUNT"
+	ViolationTooWeak         = "PASSWORD_TOO_WEAK"
+	ViolationBlocked         = "PASSWORD_BLOCKED"
+	ViolationBreached        = "PASSWORD_BREACHED"
+	ViolationReused          = "PASSWORD_REUSED"
+)
+
+// minPersonalInfoLength — более короткие username и email не ищутся в пароле, иначе ложные срабатывания
+const minPersonalInfoLength = 3
+
+// keyboardRows — раскладки, последовательности по которым считаются предсказуемыми
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890"}
+
+// PasswordPolicy проверяет новые пароли (регистрация и смена пароля). При входе не применяется:
+// пароль, заданный по старым правилам, должен продолжать работать
+type PasswordPolicy struct {
+	cfg      *model.PasswordConfig
+	blocked  map[string]struct{}
+	breaches storage.BreachCheckerInterface
+}
+
+// NewPasswordPolicy — breaches может быть nil, тогда проверка по утечкам отключена
+func NewPasswordPolicy(cfg *model.PasswordConfig, breaches storage.BreachCheckerInterface) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		cfg:      cfg,
+		blocked:  make(map[string]struct{}),
+		breaches: breaches,
+	}
+
+	if cfg.BlocklistPath != "" {
+		if err := policy.loadBlocklist(cfg.BlocklistPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// loadBlocklist читает запрещённые пароли, по одному в строке; сравнение без учёта регистра
+func (p *PasswordPolicy) loadBlocklist(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.blocked[strings.ToLower(line)] = struct{}{}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read password blocklist: %w", err)
+	}
+
+	return nil
+}
+
+// Check возвращает все нарушения политики; пустой результат означает, что пароль подходит
+func (p *PasswordPolicy) Check(password, username, email string) []domain.PasswordViolation {
+	var violations []domain.PasswordViolation
+	add := func(code, message string) {
+		violations = append(violations, domain.PasswordViolation{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		add(ViolationTooShort, fmt.Sprintf("password must be at least %d characters long", p.cfg.MinLength))
+	}
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		add(ViolationTooLong, fmt.Sprintf("password must be at most %d characters long", p.cfg.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasNumber, hasSpecial bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasNumber = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSpecial = true
+		}
+	}
+
+	if p.cfg.RequireUpper && !hasUpper {
+		add(ViolationNoUpper, "password must contain an uppercase letter")
+	}
+	if p.cfg.RequireLower && !hasLower {
+		add(ViolationNoLower, "password must contain a lowercase letter")
+	}
+	if p.cfg.RequireDigit && !hasNumber {
+		add(ViolationNoDigit, "password must contain a digit")
+	}
+	if p.cfg.RequireSpecial && !hasSpecial {
+		add(ViolationNoSpecial, "password must contain a special character")
+	}
+
+	lower := strings.ToLower(password)
+
+	if p.cfg.ForbidPersonalInfo && containsPersonalInfo(lower, username, email) {
+		add(ViolationContainsAccount, "password must not contain the username or email")
+	}
+
+	if _, ok := p.blocked[lower]; ok {
+		add(ViolationBlocked, "password is too common")
+	}
+
+	if score := strengthScore(password); score < p.cfg.MinStrength {
+		add(ViolationTooWeak, fmt.Sprintf("password is too easy to guess (strength %d of 4, need %d)", score, p.cfg.MinStrength))
+	}
+
+	return violations
+}
+
+// CheckBreached сообщает, встречался ли пароль в утечках не реже PASSWORD_BREACH_MIN_COUNT раз.
+// В отличие от Check обращается к файлу, поэтому может вернуть ошибку
+func (p *PasswordPolicy) CheckBreached(password string) (*domain.PasswordViolation, error) {
+	if p.breaches == nil {
+		return nil, nil
+	}
+
+	count, err := p.breaches.BreachCount(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check password against breach corpus: %w", err)
+	}
+
+	if count < p.cfg.BreachMinCount {
+		return nil, nil
+	}
+
+	return &domain.PasswordViolation{
+		Code:    ViolationBreached,
+		Message: "password has appeared in a data breach",
+	}, nil
+}
+
+func containsPersonalInfo(lowerPassword, username, email string) bool {
+	candidates := []string{strings.ToLower(username)}
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok {
+		candidates = append(candidates, local)
+	}
+
+	for _, candidate := range candidates {
+		if utf8.RuneCountInString(candidate) >= minPersonalInfoLength && strings.Contains(lowerPassword, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// strengthScore — упрощённая оценка в духе zxcvbn: 0 (угадывается мгновенно) … 4 (очень стойкий).
+// Энтропия считается по алфавиту пароля, а повторы, последовательности и клавиатурные ряды
+// почти не добавляют длины
+func strengthScore(password string) int {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	var pool int
+	var hasUpper, hasLower, hasNumber, hasOther bool
+	for _, char := range runes {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasNumber = true
+		default:
+			hasOther = true
+		}
+	}
+	if hasUpper {
+		pool += 26
+	}
+	if hasLower {
+		pool += 26
+	}
+	if hasNumber {
+		pool += 10
+	}
+	if hasOther {
+		pool += 33
+	}
+
+	effective := 1.0
+	lower := []rune(strings.ToLower(password))
+	for i := 1; i < len(lower); i++ {
+		switch {
+		case lower[i] == lower[i-1]:
+			// повтор символа длины не добавляет
+		case predictable(lower[i-1], lower[i]):
+			effective += 0.25
+		default:
+			effective++
+		}
+	}
+
+	bits := effective * math.Log2(float64(pool))
+
+	switch {
+	case bits < 10: // < 10^3 попыток
+		return 0
+	case bits < 20: // < 10^6
+		return 1
+	case bits < 27: // < 10^8
+		return 2
+	case bits < 33: // < 10^10
+		return 3
+	default:
+		return 4
+	}
+}
+
+// predictable — символ продолжает алфавитную последовательность или клавиатурный ряд
+func predictable(prev, cur rune) bool {
+	if cur == prev+1 || cur == prev-1 {
+		return true
+	}
+
+	for _, row := range keyboardRows {
+		i := strings.IndexRune(row, prev)
+		j := strings.IndexRune(row, cur)
+		if i >= 0 && j >= 0 && (j-i == 1 || i-j == 1) {
+			return true
+		}
+	}
+
+	return false
+}
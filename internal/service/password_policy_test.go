@@ -0,0 +1,137 @@
+package service
+
+import (
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func defaultPolicyConfig() *model.PasswordConfig {
+	return &model.PasswordConfig{
+		MinLength:          8,
+		MaxLength:          128,
+		RequireUpper:       true,
+		RequireLower:       true,
+		RequireDigit:       true,
+		RequireSpecial:     true,
+		ForbidPersonalInfo: true,
+		MinStrength:        2,
+		BreachMinCount:     1,
+	}
+}
+
+func violationCodes(violations []domain.PasswordViolation) []string {
+	codes := make([]string, 0, len(violations))
+	for _, v := range violations {
+		codes = append(codes, v.Code)
+	}
+
+	return codes
+}
+
+func TestPasswordPolicyAcceptsStrongPassword(t *testing.T) {
+	policy, err := NewPasswordPolicy(defaultPolicyConfig(), nil)
+	require.NoError(t, err)
+
+	require.Empty(t, policy.Check("New12321_new", "Shellshocker", "newemail@gmail.com"))
+}
+
+func TestPasswordPolicyViolations(t *testing.T) {
+	policy, err := NewPasswordPolicy(defaultPolicyConfig(), nil)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		password string
+		codes    []string
+	}{
+		{"short", "Ab1_x", []string{ViolationTooShort}},
+		{"classes", "longpassword", []string{ViolationNoUpper, ViolationNoDigit, ViolationNoSpecial}},
+		{"username", "Shellshocker_1", []string{ViolationContainsAccount}},
+		{"email", "XnewEmail_1q", []string{ViolationContainsAccount}},
+		{"sequence", "Aaaaaaa1!", []string{ViolationTooWeak}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			codes := violationCodes(policy.Check(tc.password, "Shellshocker", "newemail@gmail.com"))
+			for _, code := range tc.codes {
+				require.Contains(t, codes, code)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# common\nP@ssw0rd2024!\n"), 0o600))
+
+	cfg := defaultPolicyConfig()
+	cfg.BlocklistPath = path
+
+	policy, err := NewPasswordPolicy(cfg, nil)
+	require.NoError(t, err)
+
+	require.Contains(t, violationCodes(policy.Check("p@ssw0rd2024!", "", "")), ViolationBlocked)
+	require.NotContains(t, violationCodes(policy.Check("New12321_new", "", "")), ViolationBlocked)
+}
+
+func TestPasswordPolicyConfigurable(t *testing.T) {
+	cfg := &model.PasswordConfig{MinLength: 4, MaxLength: 10}
+
+	policy, err := NewPasswordPolicy(cfg, nil)
+	require.NoError(t, err)
+
+	require.Empty(t, policy.Check("plainword", "", ""))
+	require.Equal(t, []string{ViolationTooLong}, violationCodes(policy.Check("plainwordtoolong", "", "")))
+}
+
+func TestStrengthScore(t *testing.T) {
+	require.Equal(t, 0, strengthScore(""))
+	require.Less(t, strengthScore("qwertyui"), 2)
+	require.Less(t, strengthScore("12345678"), 2)
+	require.Equal(t, 4, strengthScore("New12321_new"))
+}
+
+type fakeBreachChecker map[string]int
+
+func (f fakeBreachChecker) BreachCount(password string) (int, error) {
+	return f[password], nil
+}
+
+func (f fakeBreachChecker) Close() error {
+	return nil
+}
+
+func TestPasswordPolicyCheckBreached(t *testing.T) {
+	cfg := defaultPolicyConfig()
+	cfg.BreachMinCount = 10
+
+	policy, err := NewPasswordPolicy(cfg, fakeBreachChecker{"Breached12321_": 10, "Rare12321_": 9})
+	require.NoError(t, err)
+
+	violation, err := policy.CheckBreached("Breached12321_")
+	require.NoError(t, err)
+	require.NotNil(t, violation)
+	require.Equal(t, ViolationBreached, violation.Code)
+
+	violation, err = policy.CheckBreached("Rare12321_")
+	require.NoError(t, err)
+	require.Nil(t, violation)
+
+	violation, err = policy.CheckBreached("New12321_new")
+	require.NoError(t, err)
+	require.Nil(t, violation)
+}
+
+func TestPasswordPolicyWithoutBreachChecker(t *testing.T) {
+	policy, err := NewPasswordPolicy(defaultPolicyConfig(), nil)
+	require.NoError(t, err)
+
+	violation, err := policy.CheckBreached("password")
+	require.NoError(t, err)
+	require.Nil(t, violation)
+}
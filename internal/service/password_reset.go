@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
+	"crypto_analyzer_auth_service/internal/storage"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+// RequestPasswordReset отправляет письмо со ссылкой сброса пароля. Ответ одинаков
+// для зарегистрированного и незарегистрированного email
+func (s *ControllerService) RequestPasswordReset(ctx context.Context, req *auth.RequestPasswordResetRequest) (*auth.RequestPasswordResetResponse, error) {
+	log := logger.FromContext(ctx)
+
+	email := req.GetEmail()
+	if !isValidEmail(email) {
+		log.Warn("invalid email format", zap.Error(domain.ErrWeakEmail))
+		return nil, domain.ErrWeakEmail
+	}
+
+	user, err := s.Storage.GetUserByUsernameEmail(ctx, "", email)
+	if err != nil {
+		log.Error("failed to get user by email", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	if user == nil {
+		log.Info("password reset requested for unknown email")
+		return &auth.RequestPasswordResetResponse{}, nil
+	}
+
+	token, err := newOneTimeToken()
+	if err != nil {
+		log.Error("failed to generate password reset token", zap.Error(err))
+		return nil, err
+	}
+
+	ttl := s.PasswordCfg.ResetTokenTTL
+	err = s.Tokens.SaveToken(ctx, storage.TokenPurposePasswordReset, user.ID, token, ttl)
+	if err != nil {
+		log.Error("failed to save password reset token", zap.Error(err))
+		return nil, fmt.Errorf("failed to save password reset token: %w", err)
+	}
+
+	s.sendMail(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Someone requested a password reset for your account %s.\n\n"+
+			"To set a new password, open the link below within %s:\n%s\n\n"+
+			"If it was not you, ignore this email: your password stays the same.\n",
+			user.Username, ttl, s.mailLink("/reset-password", token)),
+	})
+
+	log.Info("password reset requested", zap.String("user_id", user.ID))
+
+	return &auth.RequestPasswordResetResponse{}, nil
+}
+
+// ConfirmPasswordReset задаёт новый пароль по токену из письма. Токен одноразовый,
+// после смены пароля отзываются все сессии пользователя
+func (s *ControllerService) ConfirmPasswordReset(ctx context.Context, req *auth.ConfirmPasswordResetRequest) (*auth.ConfirmPasswordResetResponse, error) {
+	log := logger.FromContext(ctx)
+
+	token := req.GetToken()
+	newPassword := req.GetNewPassword()
+
+	if token == "" {
+		log.Warn("empty password reset token", zap.Error(domain.ErrNoResetToken))
+		return nil, domain.ErrNoResetToken
+	}
+	if newPassword == "" {
+		log.Warn("not enough data to reset password", zap.Error(domain.ErrNotEnoughData))
+		return nil, domain.ErrNotEnoughData
+	}
+
+	// токен гасится только после проверки пароля, чтобы слабый пароль не сжигал ссылку
+	userID, err := s.Tokens.PeekToken(ctx, storage.TokenPurposePasswordReset, token)
+	if err != nil {
+		log.Error("failed to get password reset token", zap.Error(err))
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	if userID == "" {
+		log.Warn("unknown password reset token", zap.Error(domain.ErrInvalidResetToken))
+		return nil, domain.ErrInvalidResetToken
+	}
+
+	user, err := s.Storage.GetUserByUserID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user == nil {
+		log.Warn("password reset token of deleted user", zap.Error(domain.ErrInvalidResetToken))
+		return nil, domain.ErrInvalidResetToken
+	}
+
+	if err = s.validateNewPassword(newPassword, user.Username, user.Email); err != nil {
+		log.Warn("password validation failed", zap.Error(err))
+		return nil, err
+	}
+
+	passwordHash, err := s.Hasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate password hash", zap.Error(err))
+		return nil, fmt.Errorf("failed to generate password hash: %w", err)
+	}
+
+	// история сверяется и токен гасится под блокировкой строки пользователя:
+	// повторный пароль не сжигает ссылку, а второй запрос с тем же токеном пароль не сменит
+	err = s.Storage.ChangePassword(ctx, user.ID, passwordHash, s.PasswordCfg.HistorySize, func(hashes []string) error {
+		if err := s.checkPasswordReuse(hashes, newPassword); err != nil {
+			return err
+		}
+
+		consumedBy, err := s.Tokens.ConsumeToken(ctx, storage.TokenPurposePasswordReset, token)
+		if err != nil {
+			return fmt.Errorf("failed to consume password reset token: %w", err)
+		}
+		if consumedBy != user.ID {
+			return domain.ErrInvalidResetToken
+		}
+
+		return nil
+	})
+	var policyErr *domain.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		log.Warn("password reuse check failed", zap.Error(err))
+		return nil, err
+	}
+	if errors.Is(err, domain.ErrInvalidResetToken) {
+		log.Warn("password reset token has been already used", zap.Error(err))
+		return nil, domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		log.Error("failed to change password", zap.Error(err))
+		return nil, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	var revoked int
+	revoked, err = s.Session.RevokeAllSessions(ctx, user.ID)
+	if err != nil {
+		log.Error("failed to revoke sessions", zap.Error(err))
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	logger.SecurityEvent(ctx, "password_reset", zap.String("user_id", user.ID), zap.Int("revoked_sessions", revoked))
+
+	return &auth.ConfirmPasswordResetResponse{RevokedSessions: int32(revoked)}, nil
+}
@@ -5,6 +5,7 @@ import (
 	auth "crypto_analyzer_auth_service/gen/go"
 	"crypto_analyzer_auth_service/internal/domain"
 	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 )
@@ -18,47 +19,57 @@ func (s *ControllerService) Refresh(ctx context.Context, req *auth.RefreshReques
 		return nil, domain.ErrNoRefreshToken
 	}
 
-	userID, err := s.Session.GetUserIDByRefreshToken(ctx, req.RefreshToken)
+	newRefreshToken, err := s.JWTManager.GenerateRefreshToken()
 	if err != nil {
-		log.Error("failed to get userID by refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to get userID by refresh token: %w", err)
+		log.Error("failed to generate refresh token", zap.Error(err))
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	err = s.Session.DeleteRefreshToken(ctx, req.RefreshToken)
+	var session *domain.RefreshSession
+	session, err = s.Session.RotateRefreshToken(ctx, refreshToken, newRefreshToken, s.clientInfo(ctx))
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		logger.SecurityEvent(ctx, "refresh_token_reuse",
+			zap.String("user_id", session.UserID),
+			zap.String("family_id", session.FamilyID),
+			zap.Int("generation", session.Generation),
+		)
+		return nil, domain.ErrRefreshTokenReused
+	}
 	if err != nil {
-		log.Error("failed to delete refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to delete refresh token: %w", err)
+		log.Error("failed to rotate refresh token", zap.Error(err))
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
 	}
 
+	log = log.With(zap.String("family_id", session.FamilyID), zap.Int("generation", session.Generation))
+
 	var user *domain.User
-	user, err = s.Storage.GetUserByUserID(ctx, userID)
+	user, err = s.Storage.GetUserByUserID(ctx, session.UserID)
 	if err != nil {
 		log.Error("failed to get user by userID", zap.Error(err))
 		return nil, fmt.Errorf("failed to get user by userID: %w", err)
 	}
+	if user == nil {
+		log.Error("nil user", zap.Error(domain.ErrNilUser))
+		return nil, domain.ErrNilUser
+	}
 
-	var accessToken string
-	accessToken, err = s.JWTManager.GenerateAccessToken(userID, user.Username, user.Email)
+	var accessToken *domain.AccessToken
+	accessToken, err = s.generateAccessToken(ctx, user)
 	if err != nil {
 		log.Error("failed to generate access token", zap.Error(err))
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	var newRefreshToken string
-	newRefreshToken, err = s.JWTManager.GenerateRefreshToken()
+	err = s.Session.RecordAccessToken(ctx, session.FamilyID, accessToken)
 	if err != nil {
-		log.Error("failed to generate refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		log.Error("failed to record access token", zap.Error(err))
+		return nil, fmt.Errorf("failed to record access token: %w", err)
 	}
 
-	err = s.Session.SaveRefreshToken(ctx, userID, newRefreshToken)
-	if err != nil {
-		log.Error("failed to save refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to save refresh token: %w", err)
-	}
+	log.Info("refresh token rotated")
 
 	return &auth.RefreshResponse{
-		Token:        accessToken,
+		Token:        accessToken.Token,
 		RefreshToken: newRefreshToken,
 	}, nil
 }
@@ -3,12 +3,15 @@ package service
 import (
 	"context"
 	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
 	"crypto_analyzer_auth_service/internal/domain"
 	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"time"
 )
 
@@ -29,74 +32,72 @@ func (s *ControllerService) Register(ctx context.Context, req *auth.RegisterRequ
 		return nil, domain.ErrWeakEmail
 	}
 
-	if !isValidPassword(password) {
-		log.Warn("invalid password format", zap.Error(domain.ErrWeakPassword))
-		return nil, domain.ErrWeakPassword
+	if err := s.validateNewPassword(password, username, email); err != nil {
+		log.Warn("password validation failed", zap.Error(err))
+		return nil, err
 	}
 
-	exists, err := s.Storage.EmailExists(ctx, email)
-	if err != nil {
-		log.Error("failed to check email", zap.Error(err))
-		return nil, fmt.Errorf("failed to check email: %w", err)
-	}
-	if exists {
-		return nil, fmt.Errorf("email has been already taken")
-	}
-
-	/*exists, err = s.Storage.UsernameExists(ctx, req.Username)
-	if err != nil {
-		logger.Log.Error("failed to check username existence", zap.Error(err))
-		return nil, errors_my.New("failed to register user")
-	}
-	if exists {
-		return nil, errors_my.New("the username has been already taken")
-	}*/
-
-	var passwordHash []byte
-	passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	passwordHash, err := s.Hasher.Hash(password)
 	if err != nil {
 		log.Error("failed to generate password hash", zap.Error(err))
 		return nil, fmt.Errorf("failed to generate password hash: %w", err)
 	}
 
-	userID := uuid.New().String()
-
 	user := &domain.User{
-		ID:           userID,
+		ID:           uuid.New().String(),
 		Username:     username,
 		Email:        email,
-		PasswordHash: string(passwordHash),
+		PasswordHash: passwordHash,
 		CreatedAt:    time.Now(),
 	}
 
+	// уникальность email и username гарантирует база, отдельная проверка перед вставкой дала бы гонку
 	err = s.Storage.CreateUser(ctx, user)
+	var conflictErr *domain.ConflictError
+	if errors.As(err, &conflictErr) {
+		log.Warn("user already exists", zap.String("field", conflictErr.Field), zap.Error(conflictErr))
+
+		// в режиме block успешная регистрация тоже не выдаёт токены, поэтому занятый email можно
+		// скрыть за тем же ответом, а владельца адреса предупредить письмом
+		if s.EmailCfg.Mode == model.EmailVerificationBlock && errors.Is(conflictErr, domain.ErrEmailAlreadyTaken) {
+			s.sendAccountExistsEmail(ctx, email)
+			return &auth.RegisterResponse{EmailVerificationRequired: true}, nil
+		}
+
+		return nil, conflictErr
+	}
 	if err != nil {
 		log.Error("failed to create user", zap.Error(err))
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	var accessToken string
-	accessToken, err = s.JWTManager.GenerateAccessToken(userID, username, email)
-	if err != nil {
-		log.Error("failed to generate access token", zap.Error(err))
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
-	}
+	s.sendVerificationEmail(ctx, user)
 
-	var refreshToken string
-	refreshToken, err = s.JWTManager.GenerateRefreshToken()
-	if err != nil {
-		log.Error("failed to generate refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	if s.EmailCfg.Mode == model.EmailVerificationBlock {
+		log.Info("user registered, waiting for email verification")
+		return &auth.RegisterResponse{EmailVerificationRequired: true}, nil
 	}
 
-	err = s.Session.SaveRefreshToken(ctx, userID, refreshToken)
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
 	if err != nil {
-		log.Error("failed to save refresh token", zap.Error(err))
-		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+		return nil, err
 	}
 
 	return &auth.RegisterResponse{
-		Token:        accessToken,
+		Token:        accessToken.Token,
 		RefreshToken: refreshToken,
 	}, nil
 }
+
+// sendAccountExistsEmail сообщает владельцу email о повторной регистрации на его адрес
+func (s *ControllerService) sendAccountExistsEmail(ctx context.Context, email string) {
+	s.sendMail(ctx, mailer.Message{
+		To:      email,
+		Subject: "You already have an account",
+		Body: fmt.Sprintf("Someone tried to sign up for Crypto Analyzer with this email, "+
+			"but an account for it already exists.\n\n"+
+			"If it was you, sign in or reset your password here:\n%s\n\n"+
+			"If you did not sign up, ignore this email.\n",
+			strings.TrimRight(s.MailCfg.LinkBaseURL, "/")+"/reset-password"),
+	})
+}
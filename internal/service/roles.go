@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+func (s *ControllerService) ListRoles(ctx context.Context, req *auth.ListRolesRequest) (*auth.ListRolesResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if _, err := actorFromContext(ctx, "ListRoles"); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.Storage.ListRoles(ctx)
+	if err != nil {
+		log.Error("failed to list roles", zap.Error(err))
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return &auth.ListRolesResponse{Roles: rolesResponse(roles)}, nil
+}
+
+func (s *ControllerService) GetUserRoles(ctx context.Context, req *auth.GetUserRolesRequest) (*auth.GetUserRolesResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if _, err := actorFromContext(ctx, "GetUserRoles"); err != nil {
+		return nil, err
+	}
+
+	userID := req.GetUserId()
+	if userID == "" {
+		log.Warn("empty user id", zap.Error(domain.ErrNoUserID))
+		return nil, domain.ErrNoUserID
+	}
+
+	user, err := s.Storage.GetUserByUserID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user == nil {
+		log.Warn("nil user", zap.Error(domain.ErrNilUser))
+		return nil, domain.ErrNilUser
+	}
+
+	roles, err := s.Storage.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		log.Error("failed to get user roles", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	return &auth.GetUserRolesResponse{Roles: rolesResponse(roles)}, nil
+}
+
+// AssignRole назначает пользователю роль. Новые разрешения попадут в токены пользователя
+// при следующем входе или обновлении токена
+func (s *ControllerService) AssignRole(ctx context.Context, req *auth.AssignRoleRequest) (*auth.AssignRoleResponse, error) {
+	log := logger.FromContext(ctx)
+
+	actorID, err := actorFromContext(ctx, "AssignRole")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateRoleRequest(req.GetUserId(), req.GetRole()); err != nil {
+		log.Warn("invalid assign role request", zap.Error(err))
+		return nil, err
+	}
+
+	assigned, err := s.Storage.AssignRole(ctx, req.GetUserId(), req.GetRole(), actorID, time.Now())
+	if errors.Is(err, domain.ErrNilUser) || errors.Is(err, domain.ErrNoSuchRole) {
+		log.Warn("role has not been assigned", zap.Error(err))
+		return nil, err
+	}
+	if err != nil {
+		log.Error("failed to assign role", zap.Error(err))
+		return nil, fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	if assigned {
+		logger.SecurityEvent(ctx, "role_assigned", zap.String("user_id", req.GetUserId()), zap.String("role", req.GetRole()),
+			zap.String("actor_id", actorID))
+	}
+
+	return &auth.AssignRoleResponse{Assigned: assigned}, nil
+}
+
+// RevokeRole снимает с пользователя роль. Уже выданные токены сохраняют её до истечения
+func (s *ControllerService) RevokeRole(ctx context.Context, req *auth.RevokeRoleRequest) (*auth.RevokeRoleResponse, error) {
+	log := logger.FromContext(ctx)
+
+	actorID, err := actorFromContext(ctx, "RevokeRole")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateRoleRequest(req.GetUserId(), req.GetRole()); err != nil {
+		log.Warn("invalid revoke role request", zap.Error(err))
+		return nil, err
+	}
+
+	revoked, err := s.Storage.RevokeRole(ctx, req.GetUserId(), req.GetRole())
+	if err != nil {
+		log.Error("failed to revoke role", zap.Error(err))
+		return nil, fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	if revoked {
+		logger.SecurityEvent(ctx, "role_revoked", zap.String("user_id", req.GetUserId()), zap.String("role", req.GetRole()),
+			zap.String("actor_id", actorID))
+	}
+
+	return &auth.RevokeRoleResponse{Revoked: revoked}, nil
+}
+
+func validateRoleRequest(userID, role string) error {
+	if userID == "" {
+		return domain.ErrNoUserID
+	}
+	if role == "" {
+		return domain.ErrNoRole
+	}
+
+	return nil
+}
+
+func rolesResponse(roles []*domain.Role) []*auth.Role {
+	resp := make([]*auth.Role, 0, len(roles))
+	for _, role := range roles {
+		resp = append(resp, &auth.Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	return resp
+}
@@ -1,19 +1,71 @@
 package service
 
 import (
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
+	"crypto_analyzer_auth_service/internal/infrastructure/secretbox"
 	"crypto_analyzer_auth_service/internal/storage"
 )
 
 type ControllerService struct {
-	Storage    storage.UsersStorageInterface
-	Session    storage.SessionManagerInterface
-	JWTManager storage.JWTManagerInterface
+	Storage     storage.UsersStorageInterface
+	Session     storage.SessionManagerInterface
+	JWTManager  storage.JWTManagerInterface
+	Hasher      storage.PasswordHasherInterface
+	Tokens      storage.OneTimeTokenStorageInterface
+	Attempts    storage.LoginAttemptsInterface
+	EmailLogins storage.EmailLoginStorageInterface
+	Mailer      mailer.Mailer
+	SecretBox   *secretbox.Box
+
+	PasswordPolicy *PasswordPolicy
+	PasswordCfg    *model.PasswordConfig
+	MailCfg        *model.MailConfig
+	EmailCfg       *model.EmailVerificationConfig
+	AdminCfg       *model.AdminConfig
+	ProxyCfg       *model.ProxyConfig
+	MFACfg         *model.MFAConfig
+	WebAuthnCfg    *model.WebAuthnConfig
+	EmailLoginCfg  *model.EmailLoginConfig
+	SiweCfg        *model.SiweConfig
+}
+
+// Deps — хранилища и внешние зависимости сервиса
+type Deps struct {
+	Storage        storage.UsersStorageInterface
+	Session        storage.SessionManagerInterface
+	JWTManager     storage.JWTManagerInterface
+	Hasher         storage.PasswordHasherInterface
+	Tokens         storage.OneTimeTokenStorageInterface
+	Attempts       storage.LoginAttemptsInterface
+	EmailLogins    storage.EmailLoginStorageInterface
+	Mailer         mailer.Mailer
+	SecretBox      *secretbox.Box
+	PasswordPolicy *PasswordPolicy
 }
 
-func NewService(storage storage.UsersStorageInterface, session storage.SessionManagerInterface, JWTManager storage.JWTManagerInterface) *ControllerService {
+// NewService собирает сервис из зависимостей и настроек cfg
+func NewService(deps Deps, cfg *model.Config) *ControllerService {
 	return &ControllerService{
-		Storage:    storage,
-		Session:    session,
-		JWTManager: JWTManager,
+		Storage:     deps.Storage,
+		Session:     deps.Session,
+		JWTManager:  deps.JWTManager,
+		Hasher:      deps.Hasher,
+		Tokens:      deps.Tokens,
+		Attempts:    deps.Attempts,
+		EmailLogins: deps.EmailLogins,
+		Mailer:      deps.Mailer,
+		SecretBox:   deps.SecretBox,
+
+		PasswordPolicy: deps.PasswordPolicy,
+		PasswordCfg:    cfg.PasswordCfg,
+		MailCfg:        cfg.MailCfg,
+		EmailCfg:       cfg.EmailCfg,
+		AdminCfg:       cfg.AdminCfg,
+		ProxyCfg:       cfg.ProxyCfg,
+		MFACfg:         cfg.MFACfg,
+		WebAuthnCfg:    cfg.WebAuthnCfg,
+		EmailLoginCfg:  cfg.EmailLoginCfg,
+		SiweCfg:        cfg.SiweCfg,
 	}
 }
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+func (s *ControllerService) ListSessions(ctx context.Context, req *auth.ListSessionsRequest) (*auth.ListSessionsResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []domain.Session
+	sessions, err = s.Session.ListSessions(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to list sessions", zap.Error(err))
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	resp := &auth.ListSessionsResponse{Sessions: make([]*auth.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &auth.Session{
+			Id:         session.ID,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastUsedAt: session.LastUsedAt.Unix(),
+			Ip:         session.IP,
+			UserAgent:  session.UserAgent,
+			ClientName: session.ClientName,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *ControllerService) RevokeSession(ctx context.Context, req *auth.RevokeSessionRequest) (*auth.RevokeSessionResponse, error) {
+	log := logger.FromContext(ctx)
+
+	sessionID := req.GetSessionId()
+	if sessionID == "" {
+		log.Warn("empty session id", zap.Error(domain.ErrNoSessionID))
+		return nil, domain.ErrNoSessionID
+	}
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Session.RevokeSession(ctx, claims.User.ID, sessionID)
+	if err != nil {
+		log.Error("failed to revoke session", zap.String("session_id", sessionID), zap.Error(err))
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	log.Info("session revoked", zap.String("session_id", sessionID))
+
+	return &auth.RevokeSessionResponse{}, nil
+}
+
+func (s *ControllerService) RevokeAllSessions(ctx context.Context, req *auth.RevokeAllSessionsRequest) (*auth.RevokeAllSessionsResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var revoked int
+	revoked, err = s.Session.RevokeAllSessions(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to revoke sessions", zap.Error(err))
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	log.Info("all sessions revoked", zap.Int("revoked", revoked))
+
+	return &auth.RevokeAllSessionsResponse{Revoked: int32(revoked)}, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+// issueTokens выдаёт пару токенов для новой сессии: access токен и refresh токен нового семейства
+func (s *ControllerService) issueTokens(ctx context.Context, user *domain.User) (*domain.AccessToken, string, error) {
+	log := logger.FromContext(ctx)
+
+	accessToken, err := s.generateAccessToken(ctx, user)
+	if err != nil {
+		log.Error("failed to generate access token", zap.Error(err))
+		return nil, "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	var refreshToken string
+	refreshToken, err = s.JWTManager.GenerateRefreshToken()
+	if err != nil {
+		log.Error("failed to generate refresh token", zap.Error(err))
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	var session *domain.RefreshSession
+	session, err = s.Session.SaveRefreshToken(ctx, user.ID, refreshToken, s.clientInfo(ctx))
+	if err != nil {
+		log.Error("failed to save refresh token", zap.Error(err))
+		return nil, "", fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	log.Info("refresh token family started", zap.String("family_id", session.FamilyID), zap.Int("generation", session.Generation))
+
+	err = s.Session.RecordAccessToken(ctx, session.FamilyID, accessToken)
+	if err != nil {
+		log.Error("failed to record access token", zap.Error(err))
+		return nil, "", fmt.Errorf("failed to record access token: %w", err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// generateAccessToken выпускает access токен пользователя с его ролями и разрешениями;
+// при SIWE_WALLETS_CLAIM в него попадают и привязанные адреса
+func (s *ControllerService) generateAccessToken(ctx context.Context, user *domain.User) (*domain.AccessToken, error) {
+	var extra domain.AccessTokenExtra
+
+	roles, err := s.Storage.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+	extra.Roles, extra.Scope = roleClaims(roles)
+
+	if s.SiweCfg != nil && s.SiweCfg.WalletsClaim {
+		wallets, err := s.Storage.ListWalletAddresses(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list wallet addresses: %w", err)
+		}
+
+		extra.Wallets = make([]string, 0, len(wallets))
+		for _, wallet := range wallets {
+			extra.Wallets = append(extra.Wallets, wallet.Address)
+		}
+	}
+
+	return s.JWTManager.GenerateAccessToken(user, extra)
+}
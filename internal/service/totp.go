@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/totp"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// EnrollTOTP начинает подключение TOTP: генерирует секрет и коды восстановления.
+// 2FA включается только после ConfirmTOTP; повторный EnrollTOTP до подтверждения заменяет секрет и коды
+func (s *ControllerService) EnrollTOTP(ctx context.Context, req *auth.EnrollTOTPRequest) (*auth.EnrollTOTPResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.SecretBox == nil {
+		log.Warn("totp encryption key is not configured", zap.Error(domain.ErrMFANotConfigured))
+		return nil, domain.ErrMFANotConfigured
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		log.Error("failed to generate totp secret", zap.Error(err))
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	encryptedSecret, err := s.SecretBox.Seal(secret, []byte(claims.User.ID))
+	if err != nil {
+		log.Error("failed to encrypt totp secret", zap.Error(err))
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	recoveryCodes, recoveryCodeHashes, err := newRecoveryCodes(s.MFACfg.RecoveryCodes)
+	if err != nil {
+		log.Error("failed to generate recovery codes", zap.Error(err))
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	err = s.Storage.SaveTOTP(ctx, claims.User.ID, encryptedSecret, recoveryCodeHashes)
+	if errors.Is(err, domain.ErrTOTPAlreadyEnabled) {
+		log.Warn("totp is already enabled", zap.Error(err))
+		return nil, err
+	}
+	if err != nil {
+		log.Error("failed to save totp", zap.Error(err))
+		return nil, fmt.Errorf("failed to save totp: %w", err)
+	}
+
+	log.Info("totp enrollment started")
+
+	return &auth.EnrollTOTPResponse{
+		Secret:        totp.EncodeSecret(secret),
+		OtpauthUri:    totp.URI(s.MFACfg.Issuer, accountName(&claims.User), secret),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// ConfirmTOTP включает 2FA, если код из приложения совпал с начатым подключением
+func (s *ControllerService) ConfirmTOTP(ctx context.Context, req *auth.ConfirmTOTPRequest) (*auth.ConfirmTOTPResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	code := req.GetCode()
+	if code == "" {
+		log.Warn("empty totp code", zap.Error(domain.ErrNoMFACode))
+		return nil, domain.ErrNoMFACode
+	}
+
+	userTOTP, err := s.Storage.GetTOTP(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to get totp", zap.Error(err))
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+	if userTOTP == nil {
+		log.Warn("totp enrollment not found", zap.Error(domain.ErrTOTPNotEnrolled))
+		return nil, domain.ErrTOTPNotEnrolled
+	}
+	if !userTOTP.ConfirmedAt.IsZero() {
+		log.Warn("totp is already enabled", zap.Error(domain.ErrTOTPAlreadyEnabled))
+		return nil, domain.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := s.openTOTPSecret(userTOTP)
+	if err != nil {
+		log.Error("failed to decrypt totp secret", zap.Error(err))
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), s.MFACfg.TOTPSkew)
+	if !ok {
+		log.Warn("invalid totp code", zap.Error(domain.ErrInvalidMFACode))
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	if err = s.Storage.ConfirmTOTP(ctx, claims.User.ID, step, time.Now()); err != nil {
+		if errors.Is(err, domain.ErrTOTPNotEnrolled) {
+			log.Warn("totp enrollment changed while confirming", zap.Error(err))
+			return nil, err
+		}
+		log.Error("failed to confirm totp", zap.Error(err))
+		return nil, fmt.Errorf("failed to confirm totp: %w", err)
+	}
+
+	logger.SecurityEvent(ctx, "totp_enabled", zap.String("user_id", claims.User.ID))
+
+	return &auth.ConfirmTOTPResponse{}, nil
+}
+
+// DisableTOTP отключает 2FA. Нужен текущий код или код восстановления: одного access токена мало.
+// Неверные коды считаются как неудачные входы
+func (s *ControllerService) DisableTOTP(ctx context.Context, req *auth.DisableTOTPRequest) (*auth.DisableTOTPResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	code := req.GetCode()
+	if code == "" {
+		log.Warn("empty totp code", zap.Error(domain.ErrNoMFACode))
+		return nil, domain.ErrNoMFACode
+	}
+
+	userTOTP, err := s.Storage.GetTOTP(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to get totp", zap.Error(err))
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+	if userTOTP == nil || userTOTP.ConfirmedAt.IsZero() {
+		log.Warn("totp is not enabled", zap.Error(domain.ErrTOTPNotEnabled))
+		return nil, domain.ErrTOTPNotEnabled
+	}
+
+	ip := s.clientInfo(ctx).IP
+	if err = s.checkLoginBlock(ctx, claims.User.ID, ip); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.verifySecondFactor(ctx, userTOTP, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.recordLoginFailure(ctx, claims.User.ID, ip)
+		log.Warn("invalid totp code", zap.Error(domain.ErrInvalidMFACode))
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	if err = s.Storage.DeleteTOTP(ctx, claims.User.ID); err != nil {
+		log.Error("failed to delete totp", zap.Error(err))
+		return nil, fmt.Errorf("failed to delete totp: %w", err)
+	}
+
+	logger.SecurityEvent(ctx, "totp_disabled", zap.String("user_id", claims.User.ID))
+
+	return &auth.DisableTOTPResponse{}, nil
+}
+
+// openTOTPSecret расшифровывает секрет; без ключа шифрования проверить TOTP код нельзя
+func (s *ControllerService) openTOTPSecret(userTOTP *domain.TOTP) ([]byte, error) {
+	if s.SecretBox == nil {
+		return nil, domain.ErrMFANotConfigured
+	}
+
+	secret, err := s.SecretBox.Open(userTOTP.Secret, []byte(userTOTP.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// accountName — как подписать аккаунт в приложении-аутентификаторе и менеджере паролей:
+// email, а у аккаунта без email — username
+func accountName(user *domain.User) string {
+	if user.Email != "" {
+		return user.Email
+	}
+
+	return user.Username
+}
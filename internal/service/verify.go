@@ -9,25 +9,37 @@ import (
 	"fmt"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/metadata"
+	"strings"
 )
 
 func (s *ControllerService) Verify(ctx context.Context, req *auth.VerifyRequest) (*auth.VerifyResponse, error) {
-	log := logger.FromContext(ctx)
-
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		log.Warn("no access token", zap.Error(domain.ErrNoAccessToken))
-		return nil, domain.ErrNoAccessToken
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	accessTokenSlice := md.Get("authorization")
-	if len(accessTokenSlice) == 0 || accessTokenSlice[0] == "" {
-		log.Warn("no access token", zap.Error(domain.ErrNoAccessToken))
-		return nil, domain.ErrNoAccessToken
+	return &auth.VerifyResponse{
+		UserId:        claims.User.ID,
+		Email:         claims.User.Email,
+		Username:      claims.User.Username,
+		EmailVerified: claims.EmailVerified,
+		Wallets:       claims.Wallets,
+		Roles:         claims.Roles,
+		Scope:         strings.Join(claims.Scope, " "),
+	}, nil
+}
+
+// authenticate проверяет access токен вызывающего (подпись, срок и denylist) и возвращает его claims
+func (s *ControllerService) authenticate(ctx context.Context) (*domain.AccessClaims, error) {
+	log := logger.FromContext(ctx)
+
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		log.Warn("no access token", zap.Error(err))
+		return nil, err
 	}
-	accessToken := accessTokenSlice[0]
 
-	user, err := s.JWTManager.ParseAccessToken(accessToken)
+	claims, err := s.JWTManager.ParseAccessToken(accessToken)
 	if err != nil {
 		if errors.Is(err, domain.ErrInvalidAccessToken) {
 			log.Error("invalid access token", zap.Error(err))
@@ -37,14 +49,54 @@ func (s *ControllerService) Verify(ctx context.Context, req *auth.VerifyRequest)
 		return nil, fmt.Errorf("failed to parse access token: %w", err)
 	}
 
-	if user == nil {
-		log.Error("nil user", zap.Error(domain.ErrNilUser))
-		return nil, domain.ErrNilUser
+	if claims.TokenID != "" {
+		var revoked bool
+		revoked, err = s.Session.IsAccessTokenRevoked(ctx, claims.TokenID)
+		if err != nil {
+			log.Error("failed to check access token denylist", zap.Error(err))
+			return nil, fmt.Errorf("failed to check access token denylist: %w", err)
+		}
+		if revoked {
+			log.Warn("revoked access token", zap.String("jti", claims.TokenID), zap.Error(domain.ErrRevokedAccessToken))
+			return nil, domain.ErrRevokedAccessToken
+		}
 	}
 
-	return &auth.VerifyResponse{
-		UserId:   user.ID,
-		Email:    user.Email,
-		Username: user.Username,
-	}, nil
+	return claims, nil
+}
+
+// accessTokenFromContext достаёт access токен из metadata "authorization", допуская префикс "Bearer "
+func accessTokenFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", domain.ErrNoAccessToken
+	}
+
+	accessTokenSlice := md.Get("authorization")
+	if len(accessTokenSlice) == 0 {
+		return "", domain.ErrNoAccessToken
+	}
+
+	accessToken := strings.TrimSpace(strings.TrimPrefix(accessTokenSlice[0], "Bearer "))
+	if accessToken == "" {
+		return "", domain.ErrNoAccessToken
+	}
+
+	return accessToken, nil
+}
+
+// UserIDFromContext возвращает id пользователя из access токена вызывающего или пустую строку.
+// Проверяются только подпись и срок, без denylist: результат годится для лимитов, но не для авторизации
+func (s *ControllerService) UserIDFromContext(ctx context.Context) string {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return ""
+	}
+
+	claims, err := s.JWTManager.ParseAccessToken(accessToken)
+	if err != nil {
+		return ""
+	}
+
+	return claims.User.ID
 }
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/ethereum"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/siwe"
+	"crypto_analyzer_auth_service/internal/storage"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// GetSiweNonce выдаёт одноразовый nonce для сообщения Sign-In with Ethereum. Nonce живёт SIWE_NONCE_TTL
+// и не привязан к адресу: кошелёк выбирается на клиенте уже после запроса
+func (s *ControllerService) GetSiweNonce(ctx context.Context, req *auth.GetSiweNonceRequest) (*auth.GetSiweNonceResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.checkSiweConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	nonce, err := siwe.NewNonce()
+	if err != nil {
+		log.Error("failed to generate siwe nonce", zap.Error(err))
+		return nil, err
+	}
+
+	// владельцем nonce записывается он сам, чтобы параллельные входы не вытесняли друг друга
+	if err = s.Tokens.SaveToken(ctx, storage.TokenPurposeSiweNonce, nonce, nonce, s.SiweCfg.NonceTTL); err != nil {
+		log.Error("failed to save siwe nonce", zap.Error(err))
+		return nil, fmt.Errorf("failed to save siwe nonce: %w", err)
+	}
+
+	return &auth.GetSiweNonceResponse{
+		Nonce:    nonce,
+		Domain:   s.SiweCfg.Domain,
+		ChainIds: s.SiweCfg.ChainIDs,
+	}, nil
+}
+
+// LoginWithWallet проверяет подписанное сообщение EIP-4361 и выдаёт пару токенов владельцу адреса.
+// Адрес, который ещё ни к кому не привязан, получает новый аккаунт без email и пароля.
+// Подделать подпись перебором нельзя, поэтому неудачи не идут в учёт блокировок
+func (s *ControllerService) LoginWithWallet(ctx context.Context, req *auth.LoginWithWalletRequest) (*auth.LoginWithWalletResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.checkSiweConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.GetMessage() == "" || req.GetSignature() == "" {
+		log.Warn("not enough data to login with wallet", zap.Error(domain.ErrNoWalletSignature))
+		return nil, domain.ErrNoWalletSignature
+	}
+
+	message, err := siwe.Parse(req.GetMessage())
+	if err != nil {
+		log.Warn("invalid siwe message", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidSiweMessage))
+		return nil, domain.ErrInvalidSiweMessage
+	}
+
+	// nonce гасится до остальных проверок: подписанное сообщение нельзя предъявить второй раз
+	owner, err := s.Tokens.ConsumeToken(ctx, storage.TokenPurposeSiweNonce, message.Nonce)
+	if err != nil {
+		log.Error("failed to consume siwe nonce", zap.Error(err))
+		return nil, fmt.Errorf("failed to consume siwe nonce: %w", err)
+	}
+	if owner != message.Nonce {
+		log.Warn("unknown siwe nonce", zap.Error(domain.ErrInvalidSiweNonce))
+		return nil, domain.ErrInvalidSiweNonce
+	}
+
+	if err = message.Validate(s.siweParams(), time.Now()); err != nil {
+		log.Warn("siwe message rejected", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidSiweMessage))
+		return nil, domain.ErrInvalidSiweMessage
+	}
+
+	if err = s.verifyWalletSignature(ctx, message.Address, req.GetMessage(), req.GetSignature()); err != nil {
+		return nil, err
+	}
+
+	address := message.Address.Hex()
+	now := time.Now()
+
+	wallet, err := s.Storage.GetWalletAddress(ctx, address)
+	if err != nil {
+		log.Error("failed to get wallet address", zap.Error(err))
+		return nil, fmt.Errorf("failed to get wallet address: %w", err)
+	}
+
+	newAccount := false
+	if wallet == nil {
+		wallet, newAccount, err = s.createWalletUser(ctx, message.Address, message.ChainID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		used, err := s.Storage.UseWalletAddress(ctx, address, message.ChainID, now)
+		if err != nil {
+			log.Error("failed to update wallet address", zap.Error(err))
+			return nil, fmt.Errorf("failed to update wallet address: %w", err)
+		}
+		if !used {
+			log.Warn("wallet address has been unlinked during login", zap.Error(domain.ErrInvalidWalletSignature))
+			return nil, domain.ErrInvalidWalletSignature
+		}
+	}
+
+	user, err := s.Storage.GetUserByUserID(ctx, wallet.UserID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user == nil {
+		log.Warn("wallet address of deleted user", zap.Error(domain.ErrInvalidWalletSignature))
+		return nil, domain.ErrInvalidWalletSignature
+	}
+
+	if s.emailVerificationBlocks(user) {
+		log.Warn("email is not verified", zap.Error(domain.ErrEmailNotVerified))
+		return nil, domain.ErrEmailNotVerified
+	}
+
+	mfaToken, err := s.startMFAChallenge(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if mfaToken != "" {
+		return &auth.LoginWithWalletResponse{MfaRequired: true, MfaToken: mfaToken, NewAccount: newAccount}, nil
+	}
+
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("wallet login succeeded", zap.Bool("new_account", newAccount), zap.Int64("chain_id", message.ChainID))
+
+	return &auth.LoginWithWalletResponse{
+		Token:        accessToken.Token,
+		RefreshToken: refreshToken,
+		NewAccount:   newAccount,
+	}, nil
+}
+
+// createWalletUser заводит аккаунт для адреса. Если параллельный вход уже привязал адрес,
+// возвращается его привязка, а новым аккаунт не считается
+func (s *ControllerService) createWalletUser(ctx context.Context, address ethereum.Address, chainID int64) (*domain.WalletAddress, bool, error) {
+	log := logger.FromContext(ctx)
+
+	user := &domain.User{
+		ID:        uuid.New().String(),
+		Username:  address.String(),
+		CreatedAt: time.Now(),
+	}
+	wallet := &domain.WalletAddress{Address: address.Hex(), ChainID: chainID}
+
+	err := s.Storage.CreateWalletUser(ctx, user, wallet)
+
+	// имя занято пользователем, который сам выбрал адрес в качестве username
+	if errors.Is(err, domain.ErrUsernameAlreadyTaken) {
+		user.Username = address.String()[:10] + "-" + user.ID[:8]
+		err = s.Storage.CreateWalletUser(ctx, user, wallet)
+	}
+
+	if errors.Is(err, domain.ErrWalletAlreadyLinked) {
+		existing, err := s.Storage.GetWalletAddress(ctx, wallet.Address)
+		if err != nil {
+			log.Error("failed to get wallet address", zap.Error(err))
+			return nil, false, fmt.Errorf("failed to get wallet address: %w", err)
+		}
+		if existing == nil {
+			log.Warn("wallet address has been unlinked during login", zap.Error(domain.ErrInvalidWalletSignature))
+			return nil, false, domain.ErrInvalidWalletSignature
+		}
+
+		return existing, false, nil
+	}
+	if err != nil {
+		log.Error("failed to create wallet user", zap.Error(err))
+		return nil, false, fmt.Errorf("failed to create wallet user: %w", err)
+	}
+
+	logger.SecurityEvent(ctx, "wallet_account_created", zap.String("user_id", user.ID), zap.String("address", wallet.Address),
+		zap.Int64("chain_id", chainID))
+
+	return wallet, true, nil
+}
+
+// verifyWalletSignature проверяет, что message подписан через personal_sign ключом address.
+// signature — hex строка, которую вернул кошелёк
+func (s *ControllerService) verifyWalletSignature(ctx context.Context, address ethereum.Address, message, signature string) error {
+	log := logger.FromContext(ctx)
+
+	raw, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(raw) != ethereum.SignatureLength {
+		log.Warn("malformed wallet signature", zap.Error(domain.ErrInvalidWalletSignature))
+		return domain.ErrInvalidWalletSignature
+	}
+
+	if err = ethereum.VerifyPersonalSignature(address, []byte(message), raw); err != nil {
+		log.Warn("wallet signature does not match address", zap.NamedError("reason", err),
+			zap.Error(domain.ErrInvalidWalletSignature))
+		return domain.ErrInvalidWalletSignature
+	}
+
+	return nil
+}
+
+func (s *ControllerService) checkSiweConfigured(ctx context.Context) error {
+	if s.SiweCfg == nil || s.SiweCfg.Domain == "" {
+		logger.FromContext(ctx).Warn("sign-in with ethereum is not configured", zap.Error(domain.ErrSiweNotConfigured))
+		return domain.ErrSiweNotConfigured
+	}
+
+	return nil
+}
+
+func (s *ControllerService) siweParams() siwe.Params {
+	return siwe.Params{
+		Domain:   s.SiweCfg.Domain,
+		ChainIDs: s.SiweCfg.ChainIDs,
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/ethereum"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/siwe"
+	"crypto_analyzer_auth_service/internal/storage"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// GetWalletLinkNonce выдаёт вызывающему nonce для LinkWallet. У пользователя действует один nonce привязки:
+// новый запрос отменяет прежний
+func (s *ControllerService) GetWalletLinkNonce(ctx context.Context, req *auth.GetWalletLinkNonceRequest) (*auth.GetWalletLinkNonceResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.checkSiweConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := siwe.NewNonce()
+	if err != nil {
+		log.Error("failed to generate wallet link nonce", zap.Error(err))
+		return nil, err
+	}
+
+	if err = s.Tokens.SaveToken(ctx, storage.TokenPurposeWalletLink, claims.User.ID, nonce, s.SiweCfg.NonceTTL); err != nil {
+		log.Error("failed to save wallet link nonce", zap.Error(err))
+		return nil, fmt.Errorf("failed to save wallet link nonce: %w", err)
+	}
+
+	return &auth.GetWalletLinkNonceResponse{
+		Nonce:    nonce,
+		Domain:   s.SiweCfg.Domain,
+		ChainIds: s.SiweCfg.ChainIDs,
+	}, nil
+}
+
+// LinkWallet привязывает адрес к аккаунту вызывающего. Владение адресом подтверждается сообщением EIP-4361
+// с nonce из GetWalletLinkNonce, подписанным этим адресом. Адрес, привязанный к другому аккаунту, не переносится
+func (s *ControllerService) LinkWallet(ctx context.Context, req *auth.LinkWalletRequest) (*auth.LinkWalletResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.checkSiweConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.GetMessage() == "" || req.GetSignature() == "" {
+		log.Warn("not enough data to link wallet", zap.Error(domain.ErrNoWalletSignature))
+		return nil, domain.ErrNoWalletSignature
+	}
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := siwe.Parse(req.GetMessage())
+	if err != nil {
+		log.Warn("invalid siwe message", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidSiweMessage))
+		return nil, domain.ErrInvalidSiweMessage
+	}
+
+	// nonce выдан конкретному пользователю: подпись, собранная для чужого аккаунта, не подойдёт
+	owner, err := s.Tokens.ConsumeToken(ctx, storage.TokenPurposeWalletLink, message.Nonce)
+	if err != nil {
+		log.Error("failed to consume wallet link nonce", zap.Error(err))
+		return nil, fmt.Errorf("failed to consume wallet link nonce: %w", err)
+	}
+	if owner == "" || owner != claims.User.ID {
+		log.Warn("unknown wallet link nonce", zap.Error(domain.ErrInvalidSiweNonce))
+		return nil, domain.ErrInvalidSiweNonce
+	}
+
+	if err = message.Validate(s.siweParams(), time.Now()); err != nil {
+		log.Warn("siwe message rejected", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidSiweMessage))
+		return nil, domain.ErrInvalidSiweMessage
+	}
+
+	if err = s.verifyWalletSignature(ctx, message.Address, req.GetMessage(), req.GetSignature()); err != nil {
+		return nil, err
+	}
+
+	wallet := &domain.WalletAddress{
+		Address: message.Address.Hex(),
+		UserID:  claims.User.ID,
+		ChainID: message.ChainID,
+	}
+
+	err = s.Storage.LinkWalletAddress(ctx, wallet, s.SiweCfg.MaxWallets)
+	if errors.Is(err, domain.ErrWalletAlreadyLinked) {
+		// повторная привязка своего адреса ничего не меняет
+		existing, getErr := s.Storage.GetWalletAddress(ctx, wallet.Address)
+		if getErr != nil {
+			log.Error("failed to get wallet address", zap.Error(getErr))
+			return nil, fmt.Errorf("failed to get wallet address: %w", getErr)
+		}
+		if existing != nil && existing.UserID == claims.User.ID {
+			return &auth.LinkWalletResponse{Wallet: walletResponse(existing)}, nil
+		}
+
+		logger.SecurityEvent(ctx, "wallet_link_conflict", zap.String("user_id", claims.User.ID),
+			zap.String("address", wallet.Address))
+		return nil, domain.ErrWalletAlreadyLinked
+	}
+	if errors.Is(err, domain.ErrWalletLimitReached) || errors.Is(err, domain.ErrNilUser) {
+		log.Warn("wallet address has not been linked", zap.Error(err))
+		return nil, err
+	}
+	if err != nil {
+		log.Error("failed to link wallet address", zap.Error(err))
+		return nil, fmt.Errorf("failed to link wallet address: %w", err)
+	}
+
+	logger.SecurityEvent(ctx, "wallet_linked", zap.String("user_id", claims.User.ID), zap.String("address", wallet.Address),
+		zap.Int64("chain_id", wallet.ChainID))
+
+	return &auth.LinkWalletResponse{Wallet: walletResponse(wallet)}, nil
+}
+
+// UnlinkWallet отвязывает адрес от аккаунта вызывающего. Адрес, который остался единственным способом входа
+// (нет пароля, email и passkey), отвязать нельзя. Claim wallets уже выданных токенов не меняется до их истечения
+func (s *ControllerService) UnlinkWallet(ctx context.Context, req *auth.UnlinkWalletRequest) (*auth.UnlinkWalletResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if req.GetAddress() == "" {
+		log.Warn("empty wallet address", zap.Error(domain.ErrNoWalletAddress))
+		return nil, domain.ErrNoWalletAddress
+	}
+
+	address, err := ethereum.ParseAddress(req.GetAddress())
+	if err != nil {
+		log.Warn("invalid wallet address", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidWalletAddress))
+		return nil, domain.ErrInvalidWalletAddress
+	}
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.Storage.GetUserByUserID(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user == nil {
+		log.Warn("nil user", zap.Error(domain.ErrNilUser))
+		return nil, domain.ErrNilUser
+	}
+
+	keepLast := false
+	if user.PasswordHash == "" && user.Email == "" {
+		credentials, err := s.Storage.ListWebAuthnCredentials(ctx, user.ID)
+		if err != nil {
+			log.Error("failed to list webauthn credentials", zap.Error(err))
+			return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+		}
+		keepLast = len(credentials) == 0
+	}
+
+	deleted, err := s.Storage.DeleteWalletAddress(ctx, user.ID, address.Hex(), keepLast)
+	if errors.Is(err, domain.ErrLastLoginMethod) || errors.Is(err, domain.ErrNilUser) {
+		log.Warn("wallet address has not been unlinked", zap.Error(err))
+		return nil, err
+	}
+	if err != nil {
+		log.Error("failed to unlink wallet address", zap.Error(err))
+		return nil, fmt.Errorf("failed to unlink wallet address: %w", err)
+	}
+	if !deleted {
+		log.Warn("wallet address is not linked", zap.Error(domain.ErrNoSuchWallet))
+		return nil, domain.ErrNoSuchWallet
+	}
+
+	logger.SecurityEvent(ctx, "wallet_unlinked", zap.String("user_id", user.ID), zap.String("address", address.Hex()))
+
+	return &auth.UnlinkWalletResponse{}, nil
+}
+
+func (s *ControllerService) ListWallets(ctx context.Context, req *auth.ListWalletsRequest) (*auth.ListWalletsResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets, err := s.Storage.ListWalletAddresses(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to list wallet addresses", zap.Error(err))
+		return nil, fmt.Errorf("failed to list wallet addresses: %w", err)
+	}
+
+	resp := &auth.ListWalletsResponse{Wallets: make([]*auth.Wallet, 0, len(wallets))}
+	for _, wallet := range wallets {
+		resp.Wallets = append(resp.Wallets, walletResponse(wallet))
+	}
+
+	return resp, nil
+}
+
+// walletResponse отдаёт адрес клиенту в формате EIP-55; в базе он хранится в нижнем регистре
+func walletResponse(wallet *domain.WalletAddress) *auth.Wallet {
+	address := wallet.Address
+	if parsed, err := ethereum.ParseAddress(wallet.Address); err == nil {
+		address = parsed.String()
+	}
+
+	resp := &auth.Wallet{
+		Address:   address,
+		ChainId:   wallet.ChainID,
+		CreatedAt: wallet.CreatedAt.Unix(),
+	}
+	if !wallet.LastUsedAt.IsZero() {
+		resp.LastUsedAt = wallet.LastUsedAt.Unix()
+	}
+
+	return resp
+}
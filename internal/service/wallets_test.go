@@ -0,0 +1,29 @@
+package service
+
+import (
+	"crypto_analyzer_auth_service/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWalletResponse(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0)
+
+	resp := walletResponse(&domain.WalletAddress{
+		Address:   "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf",
+		ChainID:   137,
+		CreatedAt: createdAt,
+	})
+	require.Equal(t, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", resp.Address, "адрес в формате EIP-55")
+	require.Equal(t, int64(137), resp.ChainId)
+	require.Equal(t, createdAt.Unix(), resp.CreatedAt)
+	require.Zero(t, resp.LastUsedAt, "с адреса ещё не входили")
+
+	resp = walletResponse(&domain.WalletAddress{
+		Address:    "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf",
+		CreatedAt:  createdAt,
+		LastUsedAt: createdAt.Add(time.Hour),
+	})
+	require.Equal(t, createdAt.Add(time.Hour).Unix(), resp.LastUsedAt)
+}
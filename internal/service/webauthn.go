@@ -0,0 +1,312 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	auth "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/webauthn"
+	"crypto_analyzer_auth_service/internal/storage"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+// BeginWebAuthnRegistration выдаёт параметры создания ключа для авторизованного пользователя.
+// Челлендж живёт WEBAUTHN_CHALLENGE_TTL; новый вызов вытесняет незавершённую регистрацию
+func (s *ControllerService) BeginWebAuthnRegistration(ctx context.Context, req *auth.BeginWebAuthnRegistrationRequest) (*auth.BeginWebAuthnRegistrationResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkWebAuthnConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	userHandle, err := webAuthnUserHandle(claims.User.ID)
+	if err != nil {
+		log.Error("failed to build webauthn user handle", zap.Error(err))
+		return nil, err
+	}
+
+	credentials, err := s.Storage.ListWebAuthnCredentials(ctx, claims.User.ID)
+	if err != nil {
+		log.Error("failed to list webauthn credentials", zap.Error(err))
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+
+	excludeCredentials := make([][]byte, 0, len(credentials))
+	for _, credential := range credentials {
+		excludeCredentials = append(excludeCredentials, credential.ID)
+	}
+
+	challenge, err := s.startWebAuthnCeremony(ctx, storage.TokenPurposeWebAuthnRegistration, claims.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("webauthn registration started")
+
+	return &auth.BeginWebAuthnRegistrationResponse{
+		Challenge:          challenge,
+		RpId:               s.WebAuthnCfg.RPID,
+		RpName:             s.WebAuthnCfg.RPName,
+		UserHandle:         userHandle,
+		UserName:           accountName(&claims.User),
+		UserDisplayName:    claims.User.Username,
+		Algorithms:         webauthn.SupportedAlgorithms,
+		ExcludeCredentials: excludeCredentials,
+		TimeoutMs:          s.WebAuthnCfg.ChallengeTTL.Milliseconds(),
+	}, nil
+}
+
+// FinishWebAuthnRegistration проверяет ответ аутентификатора и сохраняет ключ.
+// Челлендж гасится до проверки: неудачный ответ требует начать регистрацию заново
+func (s *ControllerService) FinishWebAuthnRegistration(ctx context.Context, req *auth.FinishWebAuthnRegistrationRequest) (*auth.FinishWebAuthnRegistrationResponse, error) {
+	log := logger.FromContext(ctx)
+
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkWebAuthnConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(req.GetClientDataJson()) == 0 || len(req.GetAttestationObject()) == 0 {
+		log.Warn("not enough data to finish webauthn registration", zap.Error(domain.ErrNoWebAuthnResponse))
+		return nil, domain.ErrNoWebAuthnResponse
+	}
+
+	challenge, err := s.consumeWebAuthnChallenge(ctx, storage.TokenPurposeWebAuthnRegistration, req.GetClientDataJson(), claims.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := webauthn.VerifyRegistration(s.relyingParty(), challenge, req.GetClientDataJson(), req.GetAttestationObject())
+	if err != nil {
+		log.Warn("invalid webauthn attestation", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidWebAuthnAttestation))
+		return nil, domain.ErrInvalidWebAuthnAttestation
+	}
+
+	err = s.Storage.SaveWebAuthnCredential(ctx, &domain.WebAuthnCredential{
+		ID:                credential.ID,
+		UserID:            claims.User.ID,
+		PublicKey:         credential.PublicKey,
+		SignCount:         credential.SignCount,
+		AAGUID:            credential.AAGUID,
+		AttestationFormat: credential.AttestationFormat,
+		BackupEligible:    credential.BackupEligible,
+		BackupState:       credential.BackupState,
+	})
+	if errors.Is(err, domain.ErrWebAuthnCredentialExists) {
+		log.Warn("webauthn credential is already registered", zap.Error(err))
+		return nil, err
+	}
+	if err != nil {
+		log.Error("failed to save webauthn credential", zap.Error(err))
+		return nil, fmt.Errorf("failed to save webauthn credential: %w", err)
+	}
+
+	logger.SecurityEvent(ctx, "webauthn_credential_added", zap.String("user_id", claims.User.ID),
+		zap.String("attestation_format", credential.AttestationFormat), zap.Bool("backup_eligible", credential.BackupEligible))
+
+	return &auth.FinishWebAuthnRegistrationResponse{CredentialId: credential.ID}, nil
+}
+
+// BeginWebAuthnLogin выдаёт челлендж входа без логина: пользователь выбирает passkey в браузере,
+// а аккаунт определяется по ключу. Поэтому ответ не зависит от того, какие аккаунты существуют
+func (s *ControllerService) BeginWebAuthnLogin(ctx context.Context, req *auth.BeginWebAuthnLoginRequest) (*auth.BeginWebAuthnLoginResponse, error) {
+	if err := s.checkWebAuthnConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	// до входа пользователь неизвестен: владельцем челленджа записывается он сам, чтобы параллельные
+	// входы не вытесняли друг друга
+	challenge, err := s.startWebAuthnCeremony(ctx, storage.TokenPurposeWebAuthnLogin, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.BeginWebAuthnLoginResponse{
+		Challenge: challenge,
+		RpId:      s.WebAuthnCfg.RPID,
+		TimeoutMs: s.WebAuthnCfg.ChallengeTTL.Milliseconds(),
+	}, nil
+}
+
+// FinishWebAuthnLogin проверяет подпись ключа и выдаёт пару токенов, как Login.
+// Проверка пользователя (PIN, биометрия) обязательна, поэтому вход по ключу уже двухфакторный
+// и MFA челлендж не запускает. Подобрать подпись нельзя, так что неудачи не идут в учёт блокировок,
+// а блокировка входа по паролю не мешает войти по ключу
+func (s *ControllerService) FinishWebAuthnLogin(ctx context.Context, req *auth.FinishWebAuthnLoginRequest) (*auth.FinishWebAuthnLoginResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.checkWebAuthnConfigured(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(req.GetCredentialId()) == 0 || len(req.GetClientDataJson()) == 0 || len(req.GetAuthenticatorData()) == 0 ||
+		len(req.GetSignature()) == 0 {
+		log.Warn("not enough data to finish webauthn login", zap.Error(domain.ErrNoWebAuthnResponse))
+		return nil, domain.ErrNoWebAuthnResponse
+	}
+
+	challenge, err := s.consumeWebAuthnChallenge(ctx, storage.TokenPurposeWebAuthnLogin, req.GetClientDataJson(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.Storage.GetWebAuthnCredential(ctx, req.GetCredentialId())
+	if err != nil {
+		log.Error("failed to get webauthn credential", zap.Error(err))
+		return nil, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+	if credential == nil {
+		log.Warn("unknown webauthn credential", zap.Error(domain.ErrInvalidWebAuthnAssertion))
+		return nil, domain.ErrInvalidWebAuthnAssertion
+	}
+
+	userHandle, err := webAuthnUserHandle(credential.UserID)
+	if err != nil {
+		log.Error("failed to build webauthn user handle", zap.Error(err))
+		return nil, err
+	}
+	if !bytes.Equal(req.GetUserHandle(), userHandle) {
+		log.Warn("webauthn user handle does not match credential", zap.Error(domain.ErrInvalidWebAuthnAssertion))
+		return nil, domain.ErrInvalidWebAuthnAssertion
+	}
+
+	assertion, err := webauthn.VerifyAssertion(s.relyingParty(), challenge, credential.PublicKey, req.GetClientDataJson(),
+		req.GetAuthenticatorData(), req.GetSignature())
+	if err != nil {
+		log.Warn("invalid webauthn assertion", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidWebAuthnAssertion))
+		return nil, domain.ErrInvalidWebAuthnAssertion
+	}
+
+	// счётчик меняется атомарно: подпись с тем же или меньшим счётчиком сделал другой экземпляр ключа
+	fresh, err := s.Storage.UseWebAuthnCredential(ctx, credential.ID, assertion.SignCount, assertion.BackupState, time.Now())
+	if err != nil {
+		log.Error("failed to update webauthn sign counter", zap.Error(err))
+		return nil, fmt.Errorf("failed to update webauthn sign counter: %w", err)
+	}
+	if !fresh {
+		logger.SecurityEvent(ctx, "webauthn_clone_detected", zap.String("user_id", credential.UserID),
+			zap.Uint32("stored_sign_count", credential.SignCount), zap.Uint32("sign_count", assertion.SignCount))
+		return nil, domain.ErrWebAuthnCloneDetected
+	}
+
+	user, err := s.Storage.GetUserByUserID(ctx, credential.UserID)
+	if err != nil {
+		log.Error("failed to get user by id", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	if user == nil {
+		log.Warn("webauthn credential of deleted user", zap.Error(domain.ErrInvalidWebAuthnAssertion))
+		return nil, domain.ErrInvalidWebAuthnAssertion
+	}
+
+	if s.emailVerificationBlocks(user) {
+		log.Warn("email is not verified", zap.Error(domain.ErrEmailNotVerified))
+		return nil, domain.ErrEmailNotVerified
+	}
+
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("webauthn login succeeded")
+
+	return &auth.FinishWebAuthnLoginResponse{
+		Token:        accessToken.Token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *ControllerService) checkWebAuthnConfigured(ctx context.Context) error {
+	if s.WebAuthnCfg == nil || s.WebAuthnCfg.RPID == "" {
+		logger.FromContext(ctx).Warn("webauthn relying party is not configured", zap.Error(domain.ErrWebAuthnNotConfigured))
+		return domain.ErrWebAuthnNotConfigured
+	}
+
+	return nil
+}
+
+func (s *ControllerService) relyingParty() webauthn.RelyingParty {
+	return webauthn.RelyingParty{
+		ID:                      s.WebAuthnCfg.RPID,
+		Origins:                 s.WebAuthnCfg.Origins,
+		RequireUserVerification: true,
+	}
+}
+
+// startWebAuthnCeremony сохраняет новый челлендж и возвращает его байты для клиента.
+// Пустой userID — церемония без пользователя, её владельцем записывается сам челлендж
+func (s *ControllerService) startWebAuthnCeremony(ctx context.Context, purpose, userID string) ([]byte, error) {
+	log := logger.FromContext(ctx)
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		log.Error("failed to generate webauthn challenge", zap.Error(err))
+		return nil, err
+	}
+
+	owner := userID
+	if owner == "" {
+		owner = challenge
+	}
+
+	if err = s.Tokens.SaveToken(ctx, purpose, owner, challenge, s.WebAuthnCfg.ChallengeTTL); err != nil {
+		log.Error("failed to save webauthn challenge", zap.Error(err))
+		return nil, fmt.Errorf("failed to save webauthn challenge: %w", err)
+	}
+
+	return webauthn.DecodeChallenge(challenge)
+}
+
+// consumeWebAuthnChallenge гасит челлендж из clientDataJSON и проверяет, что церемонию начинал userID
+// (для церемонии без пользователя — пустой userID)
+func (s *ControllerService) consumeWebAuthnChallenge(ctx context.Context, purpose string, clientDataJSON []byte, userID string) (string, error) {
+	log := logger.FromContext(ctx)
+
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil || clientData.Challenge == "" {
+		log.Warn("invalid webauthn client data", zap.NamedError("reason", err), zap.Error(domain.ErrInvalidWebAuthnChallenge))
+		return "", domain.ErrInvalidWebAuthnChallenge
+	}
+
+	owner, err := s.Tokens.ConsumeToken(ctx, purpose, clientData.Challenge)
+	if err != nil {
+		log.Error("failed to consume webauthn challenge", zap.Error(err))
+		return "", fmt.Errorf("failed to consume webauthn challenge: %w", err)
+	}
+
+	expectedOwner := userID
+	if expectedOwner == "" {
+		expectedOwner = clientData.Challenge
+	}
+	if owner == "" || owner != expectedOwner {
+		log.Warn("unknown webauthn challenge", zap.Error(domain.ErrInvalidWebAuthnChallenge))
+		return "", domain.ErrInvalidWebAuthnChallenge
+	}
+
+	return clientData.Challenge, nil
+}
+
+// webAuthnUserHandle — user.id для WebAuthn: 16 байт UUID пользователя, без email и других личных данных
+func webAuthnUserHandle(userID string) ([]byte, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	return id[:], nil
+}
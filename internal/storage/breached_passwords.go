@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// breachLineWindow — сколько байт читать вокруг точки бинарного поиска. Строка файла
+// (40 символов хеша, ':' и счётчик) заведомо короче половины окна
+const breachLineWindow = 256
+
+type BreachCheckerInterface interface {
+	// BreachCount возвращает, сколько раз пароль встречался в утечках (0 — не встречался)
+	BreachCount(password string) (int, error)
+	Close() error
+}
+
+func NewBreachedPasswordsFile(path string) (BreachCheckerInterface, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to stat breached passwords file: %w", err)
+	}
+
+	return &BreachedPasswordsFile{file: file, size: info.Size()}, nil
+}
+
+func (f *BreachedPasswordsFile) BreachCount(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// искомая строка, если она есть, начинается в [lo, hi)
+	lo, hi := int64(0), f.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, end, line, ok, err := f.lineFrom(mid)
+		if err != nil {
+			return 0, err
+		}
+		if !ok || start >= hi {
+			hi = mid
+			continue
+		}
+
+		hash, count, err := parseBreachLine(line)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case hash == target:
+			return count, nil
+		case hash < target:
+			lo = end
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+func (f *BreachedPasswordsFile) Close() error {
+	return f.file.Close()
+}
+
+// lineFrom находит первую строку, начинающуюся не раньше offset, и возвращает её границы
+func (f *BreachedPasswordsFile) lineFrom(offset int64) (int64, int64, []byte, bool, error) {
+	readFrom := offset
+	if offset > 0 {
+		// по предыдущему байту видно, начинается ли строка ровно в offset
+		readFrom = offset - 1
+	}
+
+	buf := make([]byte, breachLineWindow)
+	n, err := f.file.ReadAt(buf, readFrom)
+	if err != nil && err != io.EOF {
+		return 0, 0, nil, false, fmt.Errorf("failed to read breached passwords file: %w", err)
+	}
+	buf = buf[:n]
+
+	start := int64(0)
+	if offset > 0 {
+		newline := bytes.IndexByte(buf, '\n')
+		if newline < 0 {
+			return 0, 0, nil, false, nil
+		}
+		start = int64(newline + 1)
+	}
+	if start >= int64(len(buf)) {
+		return 0, 0, nil, false, nil
+	}
+
+	rest := buf[start:]
+	length := bytes.IndexByte(rest, '\n')
+	lineEnd := int64(length + 1)
+	if length < 0 {
+		if readFrom+int64(n) < f.size {
+			return 0, 0, nil, false, fmt.Errorf("breached passwords file line is too long")
+		}
+		// последняя строка без перевода строки
+		length = len(rest)
+		lineEnd = int64(length)
+	}
+
+	lineStart := readFrom + start
+
+	return lineStart, lineStart + lineEnd, rest[:length], true, nil
+}
+
+func parseBreachLine(line []byte) (string, int, error) {
+	hash, countString, ok := strings.Cut(strings.TrimSpace(string(line)), ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid breached passwords line %q", line)
+	}
+
+	count, err := strconv.Atoi(countString)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid breach count in line %q: %w", line, err)
+	}
+
+	return strings.ToUpper(hash), count, nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func writeBreachFile(t *testing.T, counts map[string]int, lineEnding string, trailingNewline bool) string {
+	lines := make([]string, 0, len(counts))
+	for password, count := range counts {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), count))
+	}
+	sort.Strings(lines)
+
+	content := strings.Join(lines, lineEnding)
+	if trailingNewline {
+		content += lineEnding
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestBreachedPasswordsFile(t *testing.T) {
+	counts := make(map[string]int)
+	for i := 0; i < 500; i++ {
+		counts[fmt.Sprintf("password%d", i)] = i + 1
+	}
+
+	for _, format := range []struct {
+		name            string
+		lineEnding      string
+		trailingNewline bool
+	}{
+		{"lf", "\n", true},
+		{"crlf", "\r\n", true},
+		{"no trailing newline", "\n", false},
+	} {
+		t.Run(format.name, func(t *testing.T) {
+			checker, err := NewBreachedPasswordsFile(writeBreachFile(t, counts, format.lineEnding, format.trailingNewline))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = checker.Close() })
+
+			for password, expected := range counts {
+				count, err := checker.BreachCount(password)
+				require.NoError(t, err)
+				require.Equal(t, expected, count, password)
+			}
+
+			count, err := checker.BreachCount("New12321_new")
+			require.NoError(t, err)
+			require.Zero(t, count)
+		})
+	}
+}
+
+func TestBreachedPasswordsFileEmpty(t *testing.T) {
+	checker, err := NewBreachedPasswordsFile(writeBreachFile(t, map[string]int{}, "\n", false))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = checker.Close() })
+
+	count, err := checker.BreachCount("password")
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
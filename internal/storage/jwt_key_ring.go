@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// keyRing хранит активный ключ подписи и выведенные из оборота ключи,
+// которые ещё нужны для проверки выпущенных ими токенов
+type keyRing struct {
+	mu      sync.RWMutex
+	active  *signingKey
+	retired map[string]retiredKey
+}
+
+type retiredKey struct {
+	key *signingKey
+	// validUntil — момент, когда истекает последний подписанный этим ключом токен
+	validUntil time.Time
+}
+
+func newKeyRing(active *signingKey) *keyRing {
+	return &keyRing{
+		active:  active,
+		retired: make(map[string]retiredKey),
+	}
+}
+
+func (r *keyRing) activeKey() *signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.active
+}
+
+// lookup ищет ключ проверки по kid среди активного и выведенных ключей
+func (r *keyRing) lookup(kid string) (*signingKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.active.id == kid {
+		return r.active, true
+	}
+
+	retired, ok := r.retired[kid]
+	if !ok {
+		return nil, false
+	}
+
+	return retired.key, true
+}
+
+// rotate делает key активным. Прежний активный ключ продолжает проверять токены до validUntil
+func (r *keyRing) rotate(key *signingKey, validUntil time.Time) (previousID string, rotated bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active.id == key.id {
+		return "", false
+	}
+
+	previous := r.active
+	r.retired[previous.id] = retiredKey{key: previous, validUntil: validUntil}
+	// при откате на ранее выведенный ключ он снова становится активным
+	delete(r.retired, key.id)
+	r.active = key
+
+	return previous.id, true
+}
+
+// prune удаляет выведенные ключи, все токены которых уже истекли
+func (r *keyRing) prune(now time.Time) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var dropped []string
+	for kid, retired := range r.retired {
+		if now.After(retired.validUntil) {
+			delete(r.retired, kid)
+			dropped = append(dropped, kid)
+		}
+	}
+
+	return dropped
+}
+
+// keys возвращает активный ключ первым, затем выведенные
+func (r *keyRing) keys() []*signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*signingKey, 0, len(r.retired)+1)
+	keys = append(keys, r.active)
+	for _, retired := range r.retired {
+		keys = append(keys, retired.key)
+	}
+
+	return keys
+}
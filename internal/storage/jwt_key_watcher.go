@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"go.uber.org/zap"
+	"os"
+	"time"
+)
+
+// watchKeyFile периодически перечитывает файл ключа: новый ключ становится активным,
+// прежний остаётся для проверки, пока не истекут подписанные им токены
+func (j *JWTManager) watchKeyFile(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			j.reloadKey(ctx, now)
+		}
+	}
+}
+
+func (j *JWTManager) reloadKey(ctx context.Context, now time.Time) {
+	log := logger.FromContext(ctx)
+
+	for _, kid := range j.keys.prune(now) {
+		log.Info("retired signing key dropped", zap.String("kid", kid))
+	}
+
+	material, err := os.ReadFile(j.keyPath)
+	if err != nil {
+		log.Error("failed to read signing key file", zap.String("path", j.keyPath), zap.Error(err))
+		return
+	}
+
+	key, err := newSigningKey(j.algorithm, material)
+	if err != nil {
+		log.Error("failed to load signing key", zap.String("path", j.keyPath), zap.Error(err))
+		return
+	}
+
+	previousID, rotated := j.keys.rotate(key, now.Add(j.accessTokenTTL))
+	if rotated {
+		log.Info("signing key rotated", zap.String("kid", key.id), zap.String("previous_kid", previousID))
+	}
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto_analyzer_auth_service/internal/domain"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+)
+
+// signingKey — ключ, которым подписываются access токены
+type signingKey struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey interface{}
+	publicKey  interface{}
+}
+
+// newSigningKey строит ключ из секрета (HS256) или PEM (RS256, EdDSA).
+// kid всегда выводится из самого ключа, поэтому у разных ключей он не совпадает
+func newSigningKey(algorithm string, material []byte) (*signingKey, error) {
+	key := &signingKey{}
+
+	switch algorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		secretKey := bytes.TrimSpace(material)
+		if len(secretKey) == 0 {
+			return nil, fmt.Errorf("secret key is empty")
+		}
+
+		// kid — префикс хеша секрета, сам секрет по нему не восстановить
+		sum := sha256.Sum256(secretKey)
+
+		key.id = base64.RawURLEncoding.EncodeToString(sum[:12])
+		key.method = jwt.SigningMethodHS256
+		key.privateKey = secretKey
+		key.publicKey = secretKey
+
+		return key, nil
+	case jwt.SigningMethodRS256.Alg():
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(material)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+
+		key.method = jwt.SigningMethodRS256
+		key.privateKey = privateKey
+		key.publicKey = &privateKey.PublicKey
+	case jwt.SigningMethodEdDSA.Alg():
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(material)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 private key: %w", err)
+		}
+
+		edPrivateKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is not Ed25519")
+		}
+
+		key.method = jwt.SigningMethodEdDSA
+		key.privateKey = edPrivateKey
+		key.publicKey = edPrivateKey.Public()
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %s", algorithm)
+	}
+
+	thumbprint, err := key.thumbprint()
+	if err != nil {
+		return nil, err
+	}
+
+	key.id = thumbprint
+
+	return key, nil
+}
+
+// jwk возвращает публичную часть ключа в формате JWK (RFC 7517).
+// Для симметричного ключа публичной части нет, тогда ok == false
+func (k *signingKey) jwk() (jwk domain.JWK, ok bool) {
+	switch publicKey := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		return domain.JWK{
+			Kty: "RSA",
+			Kid: k.id,
+			Use: "sig",
+			Alg: k.method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return domain.JWK{
+			Kty: "OKP",
+			Kid: k.id,
+			Use: "sig",
+			Alg: k.method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(publicKey),
+		}, true
+	default:
+		return domain.JWK{}, false
+	}
+}
+
+// thumbprint считает JWK thumbprint (RFC 7638), который служит kid по умолчанию
+func (k *signingKey) thumbprint() (string, error) {
+	jwk, ok := k.jwk()
+	if !ok {
+		return "", fmt.Errorf("thumbprint is only defined for asymmetric keys")
+	}
+
+	// json.Marshal сортирует ключи map, что и требует RFC 7638
+	members := map[string]string{"kty": jwk.Kty}
+	switch jwk.Kty {
+	case "RSA":
+		members["n"] = jwk.N
+		members["e"] = jwk.E
+	case "OKP":
+		members["crv"] = jwk.Crv
+		members["x"] = jwk.X
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal jwk: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -6,32 +6,58 @@ import (
 	"encoding/base64"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"strings"
 	"time"
 )
 
 type JWTManagerInterface interface {
-	GenerateAccessToken(userID, username, email string) (string, error)
+	GenerateAccessToken(user *domain.User, extra domain.AccessTokenExtra) (*domain.AccessToken, error)
 	GenerateRefreshToken() (string, error)
-	ParseAccessToken(tokenStr string) (*domain.User, error) // возвращает userID
+	ParseAccessToken(tokenStr string) (*domain.AccessClaims, error)
+	PublicKeys() []domain.JWK
 }
 
-func (j *JWTManager) GenerateAccessToken(userID, username, email string) (string, error) {
+func (j *JWTManager) GenerateAccessToken(user *domain.User, extra domain.AccessTokenExtra) (*domain.AccessToken, error) {
+	now := time.Now()
+	tokenID := uuid.New().String()
+	expiresAt := now.Add(j.accessTokenTTL)
+
 	claims := jwt.MapClaims{
-		"user_id":  userID,
-		"username": username,
-		"email":    email,
-		"exp":      time.Now().Add(j.accessTokenTTL).Unix(),
-		"iat":      time.Now().Unix(),
+		"jti":            tokenID,
+		"user_id":        user.ID,
+		"username":       user.Username,
+		"email":          user.Email,
+		"email_verified": !user.EmailVerifiedAt.IsZero(),
+		"exp":            expiresAt.Unix(),
+		"iat":            now.Unix(),
+	}
+	if extra.Wallets != nil {
+		claims["wallets"] = extra.Wallets
+	}
+	if extra.Roles != nil {
+		claims["roles"] = extra.Roles
 	}
+	// scope — строка разрешений через пробел, как в OAuth 2.0 (RFC 8693)
+	if extra.Scope != nil {
+		claims["scope"] = strings.Join(extra.Scope, " ")
+	}
+
+	key := j.keys.activeKey()
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	accessToken := jwt.NewWithClaims(key.method, claims)
+	accessToken.Header["kid"] = key.id
 
-	accessTokenSigned, err := accessToken.SignedString(j.secretKey)
+	accessTokenSigned, err := accessToken.SignedString(key.privateKey)
 	if err != nil {
-		return "", fmt.Errorf("error to generate access token: %w", err)
+		return nil, fmt.Errorf("error to generate access token: %w", err)
 	}
 
-	return accessTokenSigned, nil
+	return &domain.AccessToken{
+		Token:     accessTokenSigned,
+		ID:        tokenID,
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0),
+	}, nil
 }
 
 func (j *JWTManager) GenerateRefreshToken() (string, error) {
@@ -44,16 +70,29 @@ func (j *JWTManager) GenerateRefreshToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(byteSlice), nil
 }
 
-func (j *JWTManager) ParseAccessToken(tokenStr string) (*domain.User, error) {
+func (j *JWTManager) ParseAccessToken(tokenStr string) (*domain.AccessClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
+		key := j.keys.activeKey()
+
+		// токены, выпущенные до появления kid, заголовка не содержат и проверяются активным ключом
+		if kidValue, ok := token.Header["kid"]; ok {
+			kid, _ := kidValue.(string)
+
+			key, ok = j.keys.lookup(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown key id %v", kidValue)
+			}
+		}
+
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
 		}
-		return j.secretKey, nil
+
+		return key.publicKey, nil
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("error to parse access token: %w", err)
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidAccessToken, err)
 	}
 
 	if !token.Valid {
@@ -62,34 +101,109 @@ func (j *JWTManager) ParseAccessToken(tokenStr string) (*domain.User, error) {
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, fmt.Errorf("invalid claims")
+		return nil, fmt.Errorf("%w: invalid claims", domain.ErrInvalidAccessToken)
 	}
 
 	var userID string
 	userID, ok = claims["user_id"].(string)
 	if !ok {
-		return nil, fmt.Errorf("user_id has been not found in access token")
+		return nil, fmt.Errorf("%w: user_id has been not found in access token", domain.ErrInvalidAccessToken)
 	}
 
 	var username string
 	username, ok = claims["username"].(string)
 	if !ok {
-		return nil, fmt.Errorf("username has been not found in access token")
+		return nil, fmt.Errorf("%w: username has been not found in access token", domain.ErrInvalidAccessToken)
 	}
 
 	var email string
 	email, ok = claims["email"].(string)
 	if !ok {
-		return nil, fmt.Errorf("email has been not found in access token")
+		return nil, fmt.Errorf("%w: email has been not found in access token", domain.ErrInvalidAccessToken)
+	}
+
+	// токены, выпущенные до появления jti, его не содержат и не могут быть отозваны до истечения
+	tokenID, _ := claims["jti"].(string)
+
+	// в старых токенах email_verified нет, такие считаются неподтверждёнными
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	var wallets []string
+	if walletsValue, ok := claims["wallets"]; ok {
+		wallets, ok = stringSlice(walletsValue)
+		if !ok {
+			return nil, fmt.Errorf("%w: wallets claim is malformed", domain.ErrInvalidAccessToken)
+		}
+	}
+
+	var roles []string
+	if rolesValue, ok := claims["roles"]; ok {
+		roles, ok = stringSlice(rolesValue)
+		if !ok {
+			return nil, fmt.Errorf("%w: roles claim is malformed", domain.ErrInvalidAccessToken)
+		}
 	}
 
-	user := &domain.User{
-		ID:       userID,
-		Username: username,
-		Email:    email,
+	var scope []string
+	if scopeValue, ok := claims["scope"]; ok {
+		scopeString, ok := scopeValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: scope claim is malformed", domain.ErrInvalidAccessToken)
+		}
+		scope = strings.Fields(scopeString)
+	}
+
+	var expiresAt *jwt.NumericDate
+	expiresAt, err = claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return nil, fmt.Errorf("%w: exp has been not found in access token", domain.ErrInvalidAccessToken)
+	}
+
+	return &domain.AccessClaims{
+		User: domain.User{
+			ID:       userID,
+			Username: username,
+			Email:    email,
+		},
+		EmailVerified: emailVerified,
+		Wallets:       wallets,
+		Roles:         roles,
+		Scope:         scope,
+		TokenID:       tokenID,
+		ExpiresAt:     expiresAt.Time,
+	}, nil
+}
+
+// stringSlice приводит JSON массив строк из claims к []string
+func stringSlice(value interface{}) ([]string, bool) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, str)
+	}
+
+	return result, true
+}
+
+func (j *JWTManager) PublicKeys() []domain.JWK {
+	keys := j.keys.keys()
+
+	jwks := make([]domain.JWK, 0, len(keys))
+	for _, key := range keys {
+		if jwk, ok := key.jwk(); ok {
+			jwks = append(jwks, jwk)
+		}
 	}
 
-	return user, nil
+	return jwks
 }
 
 /*
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeyFile(t *testing.T, path string, key interface{}) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+}
+
+// publicKeyFromJWK восстанавливает ключ так, как это сделал бы внешний сервис по JWKS
+func publicKeyFromJWK(t *testing.T, jwk domain.JWK) interface{} {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		require.NoError(t, err)
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		require.NoError(t, err)
+
+		return ed25519.PublicKey(x)
+	}
+
+	t.Fatalf("unexpected kty %s", jwk.Kty)
+	return nil
+}
+
+func TestJWTManagerAsymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cases := []struct {
+		alg string
+		key interface{}
+	}{
+		{"RS256", rsaKey},
+		{"EdDSA", edKey},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.alg, func(t *testing.T) {
+			keyPath := filepath.Join(t.TempDir(), "jwt.pem")
+			writeKeyFile(t, keyPath, tc.key)
+
+			manager, err := NewJWTManager(context.Background(), &model.JwtConfig{
+				SigningAlgorithm:  tc.alg,
+				KeyPath:           keyPath,
+				AccessTokenTTL:    time.Minute,
+				RefreshTokenBytes: 32,
+			})
+			require.NoError(t, err)
+
+			accessToken, err := manager.GenerateAccessToken(&domain.User{ID: "user-id", Username: "user", Email: "user@gmail.com"}, domain.AccessTokenExtra{})
+			require.NoError(t, err)
+
+			claims, err := manager.ParseAccessToken(accessToken.Token)
+			require.NoError(t, err)
+			require.Equal(t, "user-id", claims.User.ID)
+			require.Equal(t, accessToken.ID, claims.TokenID)
+			require.Equal(t, accessToken.ExpiresAt, claims.ExpiresAt)
+
+			keys := manager.PublicKeys()
+			require.Len(t, keys, 1)
+			require.Equal(t, tc.alg, keys[0].Alg)
+			require.NotEmpty(t, keys[0].Kid)
+
+			token, err := jwt.Parse(accessToken.Token, func(token *jwt.Token) (interface{}, error) {
+				require.Equal(t, keys[0].Kid, token.Header["kid"])
+				return publicKeyFromJWK(t, keys[0]), nil
+			}, jwt.WithValidMethods([]string{tc.alg}))
+			require.NoError(t, err)
+			require.True(t, token.Valid)
+		})
+	}
+}
+
+func TestJWTManagerRejectsOtherKey(t *testing.T) {
+	newManager := func(secret string) JWTManagerInterface {
+		manager, err := NewJWTManager(context.Background(), &model.JwtConfig{
+			SecretKey:         []byte(secret),
+			AccessTokenTTL:    time.Minute,
+			RefreshTokenBytes: 32,
+		})
+		require.NoError(t, err)
+
+		return manager
+	}
+
+	accessToken, err := newManager("first-secret").GenerateAccessToken(&domain.User{ID: "user-id", Username: "user", Email: "user@gmail.com"}, domain.AccessTokenExtra{})
+	require.NoError(t, err)
+
+	_, err = newManager("second-secret").ParseAccessToken(accessToken.Token)
+	require.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+	require.Empty(t, newManager("first-secret").PublicKeys())
+}
+
+func TestJWTManagerKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	keyPath := filepath.Join(t.TempDir(), "jwt.pem")
+
+	firstKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKeyFile(t, keyPath, firstKey)
+
+	managerInterface, err := NewJWTManager(ctx, &model.JwtConfig{
+		SigningAlgorithm:  "RS256",
+		KeyPath:           keyPath,
+		AccessTokenTTL:    time.Minute,
+		RefreshTokenBytes: 32,
+	})
+	require.NoError(t, err)
+	manager := managerInterface.(*JWTManager)
+
+	oldToken, err := manager.GenerateAccessToken(&domain.User{ID: "user-id", Username: "user", Email: "user@gmail.com"}, domain.AccessTokenExtra{})
+	require.NoError(t, err)
+	oldKid := manager.PublicKeys()[0].Kid
+
+	secondKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKeyFile(t, keyPath, secondKey)
+
+	rotatedAt := time.Now()
+	manager.reloadKey(ctx, rotatedAt)
+
+	newToken, err := manager.GenerateAccessToken(&domain.User{ID: "user-id", Username: "user", Email: "user@gmail.com"}, domain.AccessTokenExtra{})
+	require.NoError(t, err)
+
+	keys := manager.PublicKeys()
+	require.Len(t, keys, 2)
+	require.NotEqual(t, oldKid, keys[0].Kid, "новый ключ становится активным")
+
+	_, err = manager.ParseAccessToken(oldToken.Token)
+	require.NoError(t, err, "старый ключ продолжает проверять выпущенные токены")
+	_, err = manager.ParseAccessToken(newToken.Token)
+	require.NoError(t, err)
+
+	manager.reloadKey(ctx, rotatedAt.Add(30*time.Second))
+	require.Len(t, manager.PublicKeys(), 2, "окно старого ключа ещё не закрыто")
+
+	manager.reloadKey(ctx, rotatedAt.Add(2*time.Minute))
+	require.Len(t, manager.PublicKeys(), 1, "старый ключ удалён после истечения всех его токенов")
+
+	_, err = manager.ParseAccessToken(oldToken.Token)
+	require.ErrorIs(t, err, domain.ErrInvalidAccessToken)
+}
+
+func TestJWTManagerEmailVerifiedClaim(t *testing.T) {
+	manager, err := NewJWTManager(context.Background(), &model.JwtConfig{
+		SecretKey:         []byte("secret"),
+		AccessTokenTTL:    time.Minute,
+		RefreshTokenBytes: 32,
+	})
+	require.NoError(t, err)
+
+	for _, verifiedAt := range []time.Time{{}, time.Now()} {
+		accessToken, err := manager.GenerateAccessToken(&domain.User{ID: "user-id", Username: "user", Email: "user@gmail.com", EmailVerifiedAt: verifiedAt}, domain.AccessTokenExtra{})
+		require.NoError(t, err)
+
+		claims, err := manager.ParseAccessToken(accessToken.Token)
+		require.NoError(t, err)
+		require.Equal(t, !verifiedAt.IsZero(), claims.EmailVerified)
+	}
+}
+
+func TestJWTManagerWalletsClaim(t *testing.T) {
+	manager, err := NewJWTManager(context.Background(), &model.JwtConfig{
+		SecretKey:         []byte("secret"),
+		AccessTokenTTL:    time.Minute,
+		RefreshTokenBytes: 32,
+	})
+	require.NoError(t, err)
+
+	user := &domain.User{ID: "user-id", Username: "user", Email: "user@gmail.com"}
+
+	// без адресов claim не выпускается
+	accessToken, err := manager.GenerateAccessToken(user, domain.AccessTokenExtra{})
+	require.NoError(t, err)
+
+	claims, err := manager.ParseAccessToken(accessToken.Token)
+	require.NoError(t, err)
+	require.Nil(t, claims.Wallets)
+
+	wallets := []string{"0x7e5f4552091a69125d5dfcb7b8c2659029395bdf", "0x2b5ad5c4795c026514f8317c7a215e218dccd6cf"}
+	accessToken, err = manager.GenerateAccessToken(user, domain.AccessTokenExtra{Wallets: wallets})
+	require.NoError(t, err)
+
+	claims, err = manager.ParseAccessToken(accessToken.Token)
+	require.NoError(t, err)
+	require.Equal(t, wallets, claims.Wallets)
+
+	// включённый claim без адресов — пустой массив, а не отсутствие claim
+	accessToken, err = manager.GenerateAccessToken(user, domain.AccessTokenExtra{Wallets: []string{}})
+	require.NoError(t, err)
+
+	claims, err = manager.ParseAccessToken(accessToken.Token)
+	require.NoError(t, err)
+	require.NotNil(t, claims.Wallets)
+	require.Empty(t, claims.Wallets)
+}
+
+func TestJWTManagerRolesClaims(t *testing.T) {
+	manager, err := NewJWTManager(context.Background(), &model.JwtConfig{
+		SecretKey:         []byte("secret"),
+		AccessTokenTTL:    time.Minute,
+		RefreshTokenBytes: 32,
+	})
+	require.NoError(t, err)
+
+	user := &domain.User{ID: "user-id", Username: "user", Email: "user@gmail.com"}
+
+	accessToken, err := manager.GenerateAccessToken(user, domain.AccessTokenExtra{
+		Roles: []string{"admin", "support"},
+		Scope: []string{"accounts:unlock", "roles:manage", "roles:read"},
+	})
+	require.NoError(t, err)
+
+	claims, err := manager.ParseAccessToken(accessToken.Token)
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin", "support"}, claims.Roles)
+	require.Equal(t, []string{"accounts:unlock", "roles:manage", "roles:read"}, claims.Scope)
+
+	token, _, err := jwt.NewParser().ParseUnverified(accessToken.Token, jwt.MapClaims{})
+	require.NoError(t, err)
+	require.Equal(t, "accounts:unlock roles:manage roles:read", token.Claims.(jwt.MapClaims)["scope"], "scope — строка через пробел")
+
+	// токены без ролей (в том числе выпущенные до их появления) разбираются с пустыми ролями
+	accessToken, err = manager.GenerateAccessToken(user, domain.AccessTokenExtra{})
+	require.NoError(t, err)
+
+	claims, err = manager.ParseAccessToken(accessToken.Token)
+	require.NoError(t, err)
+	require.Empty(t, claims.Roles)
+	require.Empty(t, claims.Scope)
+}
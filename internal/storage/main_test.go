@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.InitTestLogger(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+
+	// пределы для параметров, прочитанных из хеша: испорченный или подложенный хеш
+	// не должен заставить сервер выделять гигабайты памяти или считать ключ минутами
+	argon2MaxMemory    = 1 << 20 // KiB, 1 GiB
+	argon2MaxTime      = 32
+	argon2MinKeyLength = 16
+	argon2MaxKeyLength = 64
+)
+
+// соль и ключ для VerifyDummy: результат не важен, важны только параметры вычисления
+var (
+	dummySalt [argon2SaltLength]byte
+	dummyKey  [argon2KeyLength]byte
+)
+
+type PasswordHasherInterface interface {
+	Hash(password string) (string, error)
+	// Verify сравнивает пароль с хешем; needsRehash означает, что хеш записан устаревшим
+	// алгоритмом или с другими параметрами и его стоит пересчитать
+	Verify(password, hash string) (ok bool, needsRehash bool, err error)
+	// VerifyDummy тратит на пароль столько же времени, сколько Verify с хешем текущих параметров.
+	// Нужен, чтобы ответ для несуществующего пользователя не был быстрее ответа с неверным паролем
+	VerifyDummy(password string)
+}
+
+// Hash возвращает argon2id хеш в формате PHC: $argon2id$v=19$m=...,t=...,p=...$<salt>$<hash>
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.memory, h.time, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *PasswordHasher) Verify(password, hash string) (bool, bool, error) {
+	// у аккаунта, созданного входом через кошелёк, пароля нет: вход по паролю всегда неудачен
+	// и занимает столько же времени, сколько с паролем
+	if hash == "" {
+		h.VerifyDummy(password)
+		return false, false, nil
+	}
+
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return h.verifyArgon2(password, hash)
+	}
+
+	// всё остальное — хеши bcrypt, созданные до перехода на argon2id
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to compare bcrypt hash: %w", err)
+	}
+
+	return true, true, nil
+}
+
+func (h *PasswordHasher) VerifyDummy(password string) {
+	candidate := argon2.IDKey([]byte(password), dummySalt[:], h.time, h.memory, h.parallelism, argon2KeyLength)
+	subtle.ConstantTimeCompare(candidate, dummyKey[:])
+}
+
+func (h *PasswordHasher) verifyArgon2(password, hash string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, fmt.Errorf("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, false, fmt.Errorf("invalid argon2id hash version: %w", err)
+	}
+	if version != argon2.Version {
+		return false, false, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var memory, time uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &parallelism); err != nil {
+		return false, false, fmt.Errorf("invalid argon2id hash params: %w", err)
+	}
+
+	if memory == 0 || time == 0 || parallelism == 0 {
+		return false, false, fmt.Errorf("invalid argon2id hash params: m=%d, t=%d, p=%d", memory, time, parallelism)
+	}
+	if memory > max(argon2MaxMemory, h.memory) || time > max(argon2MaxTime, h.time) {
+		return false, false, fmt.Errorf("argon2id hash params out of range: m=%d, t=%d", memory, time)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	// пустой ключ совпал бы с любым паролем
+	if len(key) < argon2MinKeyLength || len(key) > argon2MaxKeyLength {
+		return false, false, fmt.Errorf("invalid argon2id key length %d", len(key))
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, time, memory, parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash := memory != h.memory || time != h.time || parallelism != h.parallelism ||
+		len(salt) != argon2SaltLength || len(key) != argon2KeyLength
+
+	return true, needsRehash, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"crypto_analyzer_auth_service/internal/config/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHasher(memory, time uint32) PasswordHasherInterface {
+	return NewPasswordHasher(&model.PasswordConfig{Argon2Memory: memory, Argon2Time: time, Argon2Parallelism: 1})
+}
+
+func TestPasswordHasherArgon2(t *testing.T) {
+	hasher := newTestHasher(1024, 1)
+
+	hash, err := hasher.Hash("New12321_new")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	ok, needsRehash, err := hasher.Verify("New12321_new", hash)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.False(t, needsRehash)
+
+	ok, _, err = hasher.Verify("Wrong12321_new", hash)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	other, err := hasher.Hash("New12321_new")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other, "соль случайная")
+}
+
+func TestPasswordHasherRehashOnParamsChange(t *testing.T) {
+	hash, err := newTestHasher(1024, 1).Hash("New12321_new")
+	require.NoError(t, err)
+
+	ok, needsRehash, err := newTestHasher(2048, 1).Verify("New12321_new", hash)
+	require.NoError(t, err)
+	require.True(t, ok, "старый хеш проверяется со своими параметрами")
+	require.True(t, needsRehash)
+}
+
+func TestPasswordHasherBcrypt(t *testing.T) {
+	hasher := newTestHasher(1024, 1)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("New12321_new"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, needsRehash, err := hasher.Verify("New12321_new", string(hash))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, needsRehash, "bcrypt хеши переводятся на argon2id")
+
+	ok, needsRehash, err = hasher.Verify("Wrong12321_new", string(hash))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.False(t, needsRehash)
+}
+
+func TestPasswordHasherInvalidHash(t *testing.T) {
+	hasher := newTestHasher(1024, 1)
+
+	_, _, err := hasher.Verify("New12321_new", "$argon2id$v=19$m=1024,t=1,p=1$broken")
+	require.Error(t, err)
+
+	_, _, err = hasher.Verify("New12321_new", "not a hash")
+	require.Error(t, err)
+}
+
+func TestPasswordHasherRejectsUnsafeParams(t *testing.T) {
+	hasher := newTestHasher(1024, 1)
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	for _, hash := range []string{
+		"$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key,
+		"$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=4294967295,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$",
+	} {
+		ok, _, err := hasher.Verify("New12321_new", hash)
+		require.Error(t, err, hash)
+		require.False(t, ok, hash)
+	}
+}
+
+func TestPasswordHasherEmptyHash(t *testing.T) {
+	ok, needsRehash, err := newTestHasher(1024, 1).Verify("", "")
+	require.NoError(t, err, "аккаунт без пароля")
+	require.False(t, ok)
+	require.False(t, needsRehash)
+}
+
+func TestPasswordHasherVerifyDummyTakesAsLong(t *testing.T) {
+	hasher := newTestHasher(16*1024, 2)
+
+	hash, err := hasher.Hash("New12321_new")
+	require.NoError(t, err)
+
+	median := func(f func()) time.Duration {
+		durations := make([]time.Duration, 5)
+		for i := range durations {
+			start := time.Now()
+			f()
+			durations[i] = time.Since(start)
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+		return durations[len(durations)/2]
+	}
+
+	verify := median(func() { _, _, _ = hasher.Verify("Wrong12321_new", hash) })
+	dummy := median(func() { hasher.VerifyDummy("Wrong12321_new") })
+
+	require.Greater(t, dummy, verify/2, "фиктивная сверка считает argon2id с теми же параметрами")
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+// pqForeignKeyViolation — SQLSTATE нарушения внешнего ключа
+const pqForeignKeyViolation = "23503"
+
+const (
+	queryListRoles = `
+		SELECT r.name, r.description, rp.permission
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		ORDER BY r.name, rp.permission
+	`
+
+	queryGetUserRoles = `
+		SELECT r.name, r.description, rp.permission
+		FROM user_roles ur
+		JOIN roles r ON r.name = ur.role
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		WHERE ur.user_uuid = $1
+		ORDER BY r.name, rp.permission
+	`
+
+	queryAssignRole = `
+		INSERT INTO user_roles (user_uuid, role, granted_by, granted_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		ON CONFLICT (user_uuid, role) DO NOTHING
+	`
+
+	queryRevokeRole = `
+		DELETE FROM user_roles WHERE user_uuid = $1 AND role = $2
+	`
+)
+
+// RoleStorageInterface — роли, их разрешения и роли пользователей
+type RoleStorageInterface interface {
+	// ListRoles возвращает все роли с разрешениями, по имени
+	ListRoles(ctx context.Context) ([]*domain.Role, error)
+	// GetUserRoles возвращает роли пользователя с разрешениями, по имени
+	GetUserRoles(ctx context.Context, userID string) ([]*domain.Role, error)
+	// AssignRole назначает роль; false — она уже была назначена. Несуществующая роль — domain.ErrNoSuchRole,
+	// пользователь — domain.ErrNilUser. grantedBy — id администратора или пустая строка
+	AssignRole(ctx context.Context, userID, role, grantedBy string, grantedAt time.Time) (bool, error)
+	// RevokeRole снимает роль; false — она не была назначена
+	RevokeRole(ctx context.Context, userID, role string) (bool, error)
+}
+
+func (s *UserPostgresStorage) ListRoles(ctx context.Context) ([]*domain.Role, error) {
+	return s.queryRoles(ctx, "failed to list roles", queryListRoles)
+}
+
+func (s *UserPostgresStorage) GetUserRoles(ctx context.Context, userID string) ([]*domain.Role, error) {
+	return s.queryRoles(ctx, "failed to get user roles", queryGetUserRoles, userID)
+}
+
+func (s *UserPostgresStorage) AssignRole(ctx context.Context, userID, role, grantedBy string, grantedAt time.Time) (bool, error) {
+	assigned, err := s.execAffected(ctx, "failed to assign role", queryAssignRole, userID, role, grantedBy, grantedAt)
+	if err != nil {
+		return false, userRoleReferenceError(err)
+	}
+
+	return assigned, nil
+}
+
+func (s *UserPostgresStorage) RevokeRole(ctx context.Context, userID, role string) (bool, error) {
+	return s.execAffected(ctx, "failed to revoke role", queryRevokeRole, userID, role)
+}
+
+// userRoleReferenceError переводит нарушение внешних ключей user_roles (SQLSTATE 23503) в domain.ErrNilUser
+// или domain.ErrNoSuchRole; остальные ошибки возвращаются как есть
+func userRoleReferenceError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != pqForeignKeyViolation {
+		return err
+	}
+
+	switch pqErr.Constraint {
+	case "user_roles_user_uuid_fkey":
+		return domain.ErrNilUser
+	case "user_roles_role_fkey":
+		return domain.ErrNoSuchRole
+	}
+
+	return err
+}
+
+// queryRoles собирает роли из строк (роль, описание, разрешение), отсортированных по роли;
+// у роли без разрешений разрешение NULL
+func (s *UserPostgresStorage) queryRoles(ctx context.Context, msg, query string, args ...any) ([]*domain.Role, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", msg, err)
+	}
+	defer rows.Close()
+
+	var roles []*domain.Role
+	for rows.Next() {
+		var name, description string
+		var permission sql.NullString
+		if err = rows.Scan(&name, &description, &permission); err != nil {
+			return nil, fmt.Errorf("%s: %w", msg, err)
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].Name != name {
+			roles = append(roles, &domain.Role{Name: name, Description: description, Permissions: []string{}})
+		}
+		if permission.Valid {
+			role := roles[len(roles)-1]
+			role.Permissions = append(role.Permissions, permission.String)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", msg, err)
+	}
+
+	return roles, nil
+}
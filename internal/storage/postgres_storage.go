@@ -6,35 +6,71 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
 	"time"
 )
 
+// pqUniqueViolation — SQLSTATE нарушения уникальности
+const pqUniqueViolation = "23505"
+
 const (
 	queryCreateUser = `
 		INSERT INTO users (uuid, username, email, password_hash, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
 	`
 
 	queryGetUserByUsername = `
-		SELECT uuid, username, email, password_hash, created_at
+		SELECT uuid, username, COALESCE(email, ''), password_hash, created_at, email_verified_at
 		FROM users
-		WHERE username = $1
+		WHERE LOWER(username) = LOWER($1)
+	`
+
+	queryGetUserByUsernameAndEmail = `
+		SELECT uuid, username, COALESCE(email, ''), password_hash, created_at, email_verified_at
+		FROM users
+		WHERE LOWER(username) = LOWER($1) AND email = $2
 	`
 
 	queryGetUserByEmail = `
-		SELECT uuid, username, email, password_hash, created_at
+		SELECT uuid, username, COALESCE(email, ''), password_hash, created_at, email_verified_at
 		FROM users
 		WHERE email = $1
 	`
 
 	queryGetUserByID = `
-		SELECT username, email
+		SELECT uuid, username, COALESCE(email, ''), password_hash, created_at, email_verified_at
 		FROM users
 		WHERE uuid = $1
 	`
 
-	queryCheckEmailExists = `
-		SELECT 1 FROM users WHERE email = $1 LIMIT 1
+	queryUpdatePasswordHash = `
+		UPDATE users SET password_hash = $2 WHERE uuid = $1
+	`
+
+	queryLockPasswordHash = `
+		SELECT password_hash FROM users WHERE uuid = $1 FOR UPDATE
+	`
+
+	queryInsertPasswordHistory = `
+		INSERT INTO password_history (user_uuid, password_hash) VALUES ($1, $2)
+	`
+
+	queryTrimPasswordHistory = `
+		DELETE FROM password_history
+		WHERE user_uuid = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_uuid = $1 ORDER BY id DESC LIMIT $2
+		)
+	`
+
+	queryGetPasswordHistory = `
+		SELECT password_hash FROM password_history
+		WHERE user_uuid = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	queryMarkEmailVerified = `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, $2) WHERE uuid = $1
 	`
 )
 
@@ -42,45 +78,81 @@ type UsersStorageInterface interface {
 	CreateUser(ctx context.Context, user *domain.User) error
 	GetUserByUsernameEmail(ctx context.Context, username, email string) (*domain.User, error)
 	GetUserByUserID(ctx context.Context, userID string) (*domain.User, error)
-	EmailExists(ctx context.Context, email string) (bool, error)
-	//UsernameExists(ctx context.Context, username string) (bool, error)
+	UpdatePasswordHash(ctx context.Context, userID, passwordHash string) error
+	ChangePassword(ctx context.Context, userID, passwordHash string, historySize int, check func(hashes []string) error) error
+	MarkEmailVerified(ctx context.Context, userID string, verifiedAt time.Time) error
+
+	TOTPStorageInterface
+	WebAuthnStorageInterface
+	WalletStorageInterface
+	RoleStorageInterface
 }
 
+// CreateUser создаёт пользователя в транзакции. Уникальность email и username проверяет база:
+// нарушение ограничения возвращается как *domain.ConflictError. Пустой email хранится как NULL
 func (s *UserPostgresStorage) CreateUser(ctx context.Context, user *domain.User) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		return createUser(ctx, tx, user)
+	})
+}
+
+func createUser(ctx context.Context, tx *sql.Tx, user *domain.User) error {
 	if user.CreatedAt.IsZero() {
 		user.CreatedAt = time.Now()
 	}
-	_, err := s.DB.ExecContext(ctx, queryCreateUser, user.ID, user.Username, user.Email, user.PasswordHash, user.CreatedAt)
+
+	_, err := tx.ExecContext(ctx, queryCreateUser, user.ID, user.Username, user.Email, user.PasswordHash, user.CreatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		return fmt.Errorf("failed to create user: %w", conflictError(err))
 	}
 
 	return nil
 }
 
+// GetUserByUsernameEmail ищет пользователя по username (без учёта регистра) или email.
+// Если переданы оба, они должны принадлежать одному аккаунту, иначе пользователь не найден
 func (s *UserPostgresStorage) GetUserByUsernameEmail(ctx context.Context, username, email string) (*domain.User, error) {
 	var query string
-	var value string
+	var values []interface{}
 
-	if username != "" {
+	switch {
+	case username != "" && email != "":
+		query = queryGetUserByUsernameAndEmail
+		values = []interface{}{username, email}
+	case username != "":
 		query = queryGetUserByUsername
-		value = username
-	} else if email != "" {
+		values = []interface{}{username}
+	case email != "":
 		query = queryGetUserByEmail
-		value = email
-	} else {
+		values = []interface{}{email}
+	default:
 		return nil, fmt.Errorf("username or email must be provided")
 	}
 
-	row := s.DB.QueryRowContext(ctx, query, value)
+	return scanUser(s.DB.QueryRowContext(ctx, query, values...))
+}
+
+func (s *UserPostgresStorage) GetUserByUserID(ctx context.Context, userID string) (*domain.User, error) {
+
+	if userID == "" {
+		return nil, fmt.Errorf("userID is empty")
+	}
+
+	return scanUser(s.DB.QueryRowContext(ctx, queryGetUserByID, userID))
+}
 
+// scanUser читает пользователя из строки запроса; если строки нет, возвращает nil без ошибки
+func scanUser(row *sql.Row) (*domain.User, error) {
 	var user domain.User
+	var emailVerifiedAt sql.NullTime
+
 	err := row.Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
 		&user.CreatedAt,
+		&emailVerifiedAt,
 	)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -90,65 +162,156 @@ func (s *UserPostgresStorage) GetUserByUsernameEmail(ctx context.Context, userna
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = emailVerifiedAt.Time
+	}
+
 	return &user, nil
 }
 
-func (s *UserPostgresStorage) GetUserByUserID(ctx context.Context, userID string) (*domain.User, error) {
+func (s *UserPostgresStorage) UpdatePasswordHash(ctx context.Context, userID, passwordHash string) error {
+	result, err := s.DB.ExecContext(ctx, queryUpdatePasswordHash, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
 
-	if userID == "" {
-		return nil, fmt.Errorf("userID is empty")
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNilUser
 	}
 
-	row := s.DB.QueryRowContext(ctx, queryGetUserByID, userID)
+	return nil
+}
 
-	var user domain.User
-	err := row.Scan(
-		&user.Username,
-		&user.Email,
-	)
+// ChangePassword заменяет хеш пароля, а прежний переносит в password_history,
+// где остаются только historySize последних записей (0 — история не ведётся).
+// check получает текущий хеш и историю, прочитанные под блокировкой строки пользователя,
+// так что параллельная смена пароля не обойдёт проверку; его ошибка отменяет смену
+func (s *UserPostgresStorage) ChangePassword(ctx context.Context, userID, passwordHash string, historySize int,
+	check func(hashes []string) error) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var previousHash string
+		err := tx.QueryRowContext(ctx, queryLockPasswordHash, userID).Scan(&previousHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrNilUser
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get password hash: %w", err)
+		}
+
+		if check != nil {
+			var history []string
+			history, err = passwordHistory(ctx, tx, userID, historySize)
+			if err != nil {
+				return err
+			}
+			if err = check(append([]string{previousHash}, history...)); err != nil {
+				return err
+			}
+		}
+
+		if historySize > 0 {
+			if _, err = tx.ExecContext(ctx, queryInsertPasswordHistory, userID, previousHash); err != nil {
+				return fmt.Errorf("failed to save password history: %w", err)
+			}
+		}
+
+		if _, err = tx.ExecContext(ctx, queryTrimPasswordHistory, userID, historySize); err != nil {
+			return fmt.Errorf("failed to trim password history: %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, queryUpdatePasswordHash, userID, passwordHash); err != nil {
+			return fmt.Errorf("failed to update password hash: %w", err)
+		}
+
+		return nil
+	})
+}
 
-	if errors.Is(err, sql.ErrNoRows) {
+// passwordHistory возвращает до limit предыдущих хешей пароля, начиная с последнего
+func passwordHistory(ctx context.Context, tx *sql.Tx, userID string, limit int) ([]string, error) {
+	if limit <= 0 {
 		return nil, nil
 	}
 
+	rows, err := tx.QueryContext(ctx, queryGetPasswordHistory, userID, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err = rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan password history: %w", err)
+		}
+		hashes = append(hashes, hash)
 	}
 
-	return &user, nil
-}
-
-func (s *UserPostgresStorage) EmailExists(ctx context.Context, email string) (bool, error) {
-	row := s.DB.QueryRowContext(ctx, queryCheckEmailExists, email)
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
 
-	var result int
-	err := row.Scan(&result)
+	return hashes, nil
+}
 
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
+// MarkEmailVerified отмечает email подтверждённым; повторное подтверждение время не меняет
+func (s *UserPostgresStorage) MarkEmailVerified(ctx context.Context, userID string, verifiedAt time.Time) error {
+	result, err := s.DB.ExecContext(ctx, queryMarkEmailVerified, userID, verifiedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
 	}
 
+	rows, err := result.RowsAffected()
 	if err != nil {
-		return false, fmt.Errorf("failed to check user email: %w", err)
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNilUser
 	}
 
-	return true, nil
+	return nil
 }
 
-/*func (s *UserPostgresStorage) UsernameExists(ctx context.Context, username string) (bool, error) {
-	query := `SELECT 1 FROM users WHERE username = $1 LIMIT 1`
+// withTx выполняет fn в транзакции: коммит при успехе, откат при ошибке
+func (s *UserPostgresStorage) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", conflictError(err))
+	}
 
-	row := s.DB.QueryRowContext(ctx, query, username)
+	return nil
+}
 
-	var result int
-	err := row.Scan(&result)
-	if errors_my.Is(err, sql.ErrNoRows) {
-		return false, nil
+// conflictError переводит нарушение уникального ограничения (SQLSTATE 23505) таблиц users и wallet_addresses
+// в *domain.ConflictError с именем поля; остальные ошибки возвращаются как есть
+func conflictError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != pqUniqueViolation {
+		return err
 	}
 
-	if err != nil {
-		return false, fmt.Errorf("failed to check user username: %w", err)
+	switch pqErr.Constraint {
+	case "users_email_key":
+		return &domain.ConflictError{Field: "email", Err: domain.ErrEmailAlreadyTaken}
+	case "users_username_lower_key":
+		return &domain.ConflictError{Field: "username", Err: domain.ErrUsernameAlreadyTaken}
+	case "wallet_addresses_pkey":
+		return &domain.ConflictError{Field: "address", Err: domain.ErrWalletAlreadyLinked}
 	}
 
-	return true, nil
-}*/
+	return err
+}
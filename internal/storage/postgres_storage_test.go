@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"crypto_analyzer_auth_service/internal/domain"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestConflictError(t *testing.T) {
+	cases := []struct {
+		constraint string
+		field      string
+		err        error
+	}{
+		{"users_email_key", "email", domain.ErrEmailAlreadyTaken},
+		{"users_username_lower_key", "username", domain.ErrUsernameAlreadyTaken},
+		{"wallet_addresses_pkey", "address", domain.ErrWalletAlreadyLinked},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.constraint, func(t *testing.T) {
+			err := fmt.Errorf("failed to create user: %w", conflictError(&pq.Error{Code: pqUniqueViolation, Constraint: tc.constraint}))
+
+			var conflictErr *domain.ConflictError
+			require.True(t, errors.As(err, &conflictErr))
+			require.Equal(t, tc.field, conflictErr.Field)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestConflictErrorKeepsOtherErrors(t *testing.T) {
+	foreignKey := &pq.Error{Code: "23503", Constraint: "users_email_key"}
+	require.Same(t, foreignKey, conflictError(foreignKey))
+
+	unknownConstraint := &pq.Error{Code: pqUniqueViolation, Constraint: "users_uuid_key"}
+	require.Same(t, unknownConstraint, conflictError(unknownConstraint))
+
+	plain := errors.New("connection refused")
+	require.Equal(t, plain, conflictError(plain))
+}
+
+func TestUserRoleReferenceError(t *testing.T) {
+	wrap := func(constraint string) error {
+		return fmt.Errorf("failed to assign role: %w", &pq.Error{Code: pqForeignKeyViolation, Constraint: constraint})
+	}
+
+	require.Equal(t, domain.ErrNilUser, userRoleReferenceError(wrap("user_roles_user_uuid_fkey")))
+	require.Equal(t, domain.ErrNoSuchRole, userRoleReferenceError(wrap("user_roles_role_fkey")))
+
+	other := wrap("role_permissions_role_fkey")
+	require.Equal(t, other, userRoleReferenceError(other))
+
+	unique := &pq.Error{Code: pqUniqueViolation, Constraint: "user_roles_role_fkey"}
+	require.Same(t, unique, userRoleReferenceError(unique))
+}
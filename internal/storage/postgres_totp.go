@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	queryUpsertTOTP = `
+		INSERT INTO user_totp (user_uuid, secret_encrypted, confirmed_at, last_used_step)
+		VALUES ($1, $2, NULL, 0)
+		ON CONFLICT (user_uuid) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, confirmed_at = NULL, last_used_step = 0,
+		    created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.confirmed_at IS NULL
+	`
+
+	queryGetTOTP = `
+		SELECT user_uuid, secret_encrypted, confirmed_at, last_used_step
+		FROM user_totp
+		WHERE user_uuid = $1
+	`
+
+	queryConfirmTOTP = `
+		UPDATE user_totp SET confirmed_at = $2, last_used_step = $3
+		WHERE user_uuid = $1 AND confirmed_at IS NULL
+	`
+
+	queryUseTOTPStep = `
+		UPDATE user_totp SET last_used_step = $2
+		WHERE user_uuid = $1 AND last_used_step < $2
+	`
+
+	queryDeleteTOTP = `
+		DELETE FROM user_totp WHERE user_uuid = $1
+	`
+
+	queryDeleteRecoveryCodes = `
+		DELETE FROM recovery_codes WHERE user_uuid = $1
+	`
+
+	queryInsertRecoveryCode = `
+		INSERT INTO recovery_codes (user_uuid, code_hash) VALUES ($1, $2)
+	`
+
+	queryUseRecoveryCode = `
+		UPDATE recovery_codes SET used_at = $3
+		WHERE user_uuid = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+)
+
+// TOTPStorageInterface — TOTP секреты и коды восстановления пользователей
+type TOTPStorageInterface interface {
+	// SaveTOTP начинает подключение TOTP: сохраняет неподтверждённый секрет и заменяет коды восстановления.
+	// Если TOTP уже подтверждён, ничего не меняет и возвращает domain.ErrTOTPAlreadyEnabled
+	SaveTOTP(ctx context.Context, userID string, encryptedSecret []byte, recoveryCodeHashes []string) error
+	// GetTOTP возвращает TOTP пользователя или nil, если он не подключался
+	GetTOTP(ctx context.Context, userID string) (*domain.TOTP, error)
+	// ConfirmTOTP отмечает подключение подтверждённым кодом шага step
+	ConfirmTOTP(ctx context.Context, userID string, step int64, confirmedAt time.Time) error
+	// UseTOTPStep принимает код шага step, только если он новее последнего принятого
+	UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+	// UseRecoveryCode гасит неиспользованный код восстановления с хешем codeHash
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	// DeleteTOTP отключает TOTP и удаляет коды восстановления
+	DeleteTOTP(ctx context.Context, userID string) error
+}
+
+func (s *UserPostgresStorage) SaveTOTP(ctx context.Context, userID string, encryptedSecret []byte, recoveryCodeHashes []string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, queryUpsertTOTP, userID, encryptedSecret)
+		if err != nil {
+			return fmt.Errorf("failed to save totp: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to save totp: %w", err)
+		}
+		if rows == 0 {
+			return domain.ErrTOTPAlreadyEnabled
+		}
+
+		if _, err = tx.ExecContext(ctx, queryDeleteRecoveryCodes, userID); err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		for _, hash := range recoveryCodeHashes {
+			if _, err = tx.ExecContext(ctx, queryInsertRecoveryCode, userID, hash); err != nil {
+				return fmt.Errorf("failed to save recovery code: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *UserPostgresStorage) GetTOTP(ctx context.Context, userID string) (*domain.TOTP, error) {
+	var totp domain.TOTP
+	var confirmedAt sql.NullTime
+
+	err := s.DB.QueryRowContext(ctx, queryGetTOTP, userID).Scan(&totp.UserID, &totp.Secret, &confirmedAt, &totp.LastUsedStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+
+	if confirmedAt.Valid {
+		totp.ConfirmedAt = confirmedAt.Time
+	}
+
+	return &totp, nil
+}
+
+func (s *UserPostgresStorage) ConfirmTOTP(ctx context.Context, userID string, step int64, confirmedAt time.Time) error {
+	result, err := s.DB.ExecContext(ctx, queryConfirmTOTP, userID, confirmedAt, step)
+	if err != nil {
+		return fmt.Errorf("failed to confirm totp: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to confirm totp: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrTOTPNotEnrolled
+	}
+
+	return nil
+}
+
+func (s *UserPostgresStorage) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	return s.execAffected(ctx, "failed to use totp step", queryUseTOTPStep, userID, step)
+}
+
+func (s *UserPostgresStorage) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	return s.execAffected(ctx, "failed to use recovery code", queryUseRecoveryCode, userID, codeHash, time.Now())
+}
+
+func (s *UserPostgresStorage) DeleteTOTP(ctx context.Context, userID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, queryDeleteRecoveryCodes, userID); err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryDeleteTOTP, userID); err != nil {
+			return fmt.Errorf("failed to delete totp: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// execAffected выполняет запрос и сообщает, изменил ли он хотя бы одну строку
+func (s *UserPostgresStorage) execAffected(ctx context.Context, message, query string, args ...any) (bool, error) {
+	result, err := s.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", message, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", message, err)
+	}
+
+	return rows > 0, nil
+}
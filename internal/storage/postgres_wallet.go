@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	queryInsertWalletAddress = `
+		INSERT INTO wallet_addresses (address, user_uuid, chain_id, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	queryGetWalletAddress = `
+		SELECT address, user_uuid, chain_id, created_at, last_used_at
+		FROM wallet_addresses
+		WHERE address = $1
+	`
+
+	queryUseWalletAddress = `
+		UPDATE wallet_addresses SET chain_id = $2, last_used_at = $3 WHERE address = $1
+	`
+
+	queryListWalletAddresses = `
+		SELECT address, user_uuid, chain_id, created_at, last_used_at
+		FROM wallet_addresses
+		WHERE user_uuid = $1
+		ORDER BY created_at, address
+	`
+
+	queryLockUser = `
+		SELECT 1 FROM users WHERE uuid = $1 FOR UPDATE
+	`
+
+	queryCountWalletAddresses = `
+		SELECT COUNT(*) FROM wallet_addresses WHERE user_uuid = $1
+	`
+
+	queryDeleteWalletAddress = `
+		DELETE FROM wallet_addresses WHERE user_uuid = $1 AND address = $2
+	`
+)
+
+// WalletStorageInterface — адреса Ethereum пользователей; адрес принадлежит не больше чем одному аккаунту
+type WalletStorageInterface interface {
+	// GetWalletAddress возвращает адрес или nil, если он ни к кому не привязан
+	GetWalletAddress(ctx context.Context, address string) (*domain.WalletAddress, error)
+	// CreateWalletUser в одной транзакции создаёт пользователя и привязывает к нему адрес.
+	// Если адрес уже привязан, возвращает *domain.ConflictError с domain.ErrWalletAlreadyLinked
+	CreateWalletUser(ctx context.Context, user *domain.User, wallet *domain.WalletAddress) error
+	// UseWalletAddress отмечает вход с адреса; false — адрес уже отвязан
+	UseWalletAddress(ctx context.Context, address string, chainID int64, usedAt time.Time) (bool, error)
+	// LinkWalletAddress привязывает адрес к существующему пользователю wallet.UserID, если у него меньше limit адресов,
+	// иначе возвращает domain.ErrWalletLimitReached. Занятый адрес — *domain.ConflictError с domain.ErrWalletAlreadyLinked
+	LinkWalletAddress(ctx context.Context, wallet *domain.WalletAddress, limit int) error
+	ListWalletAddresses(ctx context.Context, userID string) ([]*domain.WalletAddress, error)
+	// DeleteWalletAddress отвязывает адрес пользователя; false — такого адреса у него нет.
+	// Если keepLast, последний адрес не удаляется: возвращается domain.ErrLastLoginMethod
+	DeleteWalletAddress(ctx context.Context, userID, address string, keepLast bool) (bool, error)
+}
+
+func (s *UserPostgresStorage) GetWalletAddress(ctx context.Context, address string) (*domain.WalletAddress, error) {
+	wallet, err := scanWalletAddress(s.DB.QueryRowContext(ctx, queryGetWalletAddress, address))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet address: %w", err)
+	}
+
+	return wallet, nil
+}
+
+func (s *UserPostgresStorage) CreateWalletUser(ctx context.Context, user *domain.User, wallet *domain.WalletAddress) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := createUser(ctx, tx, user); err != nil {
+			return err
+		}
+
+		wallet.UserID = user.ID
+		if wallet.CreatedAt.IsZero() {
+			wallet.CreatedAt = user.CreatedAt
+		}
+
+		_, err := tx.ExecContext(ctx, queryInsertWalletAddress, wallet.Address, wallet.UserID, wallet.ChainID, wallet.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save wallet address: %w", conflictError(err))
+		}
+
+		return nil
+	})
+}
+
+func (s *UserPostgresStorage) UseWalletAddress(ctx context.Context, address string, chainID int64, usedAt time.Time) (bool, error) {
+	return s.execAffected(ctx, "failed to use wallet address", queryUseWalletAddress, address, chainID, usedAt)
+}
+
+func (s *UserPostgresStorage) LinkWalletAddress(ctx context.Context, wallet *domain.WalletAddress, limit int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		count, err := lockWalletAddresses(ctx, tx, wallet.UserID)
+		if err != nil {
+			return err
+		}
+
+		if wallet.CreatedAt.IsZero() {
+			wallet.CreatedAt = time.Now()
+		}
+
+		// занятость адреса проверяется раньше лимита, чтобы повторная привязка своего адреса не упиралась в лимит
+		_, err = tx.ExecContext(ctx, queryInsertWalletAddress, wallet.Address, wallet.UserID, wallet.ChainID, wallet.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save wallet address: %w", conflictError(err))
+		}
+
+		if count >= limit {
+			return domain.ErrWalletLimitReached
+		}
+
+		return nil
+	})
+}
+
+func (s *UserPostgresStorage) ListWalletAddresses(ctx context.Context, userID string) ([]*domain.WalletAddress, error) {
+	rows, err := s.DB.QueryContext(ctx, queryListWalletAddresses, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallet addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*domain.WalletAddress
+	for rows.Next() {
+		wallet, err := scanWalletAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet address: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list wallet addresses: %w", err)
+	}
+
+	return wallets, nil
+}
+
+func (s *UserPostgresStorage) DeleteWalletAddress(ctx context.Context, userID, address string, keepLast bool) (bool, error) {
+	deleted := false
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		count, err := lockWalletAddresses(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, queryDeleteWalletAddress, userID, address)
+		if err != nil {
+			return fmt.Errorf("failed to delete wallet address: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to delete wallet address: %w", err)
+		}
+		if rows == 0 {
+			return nil
+		}
+
+		// откат транзакции возвращает адрес на место
+		if keepLast && count <= 1 {
+			return domain.ErrLastLoginMethod
+		}
+
+		deleted = true
+		return nil
+	})
+
+	return deleted, err
+}
+
+// lockWalletAddresses блокирует строку пользователя до конца транзакции и возвращает число его адресов:
+// параллельные привязки и отвязки одного пользователя выполняются по очереди
+func lockWalletAddresses(ctx context.Context, tx *sql.Tx, userID string) (int, error) {
+	var exists int
+	err := tx.QueryRowContext(ctx, queryLockUser, userID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, domain.ErrNilUser
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var count int
+	if err = tx.QueryRowContext(ctx, queryCountWalletAddresses, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count wallet addresses: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanWalletAddress(row interface{ Scan(dest ...any) error }) (*domain.WalletAddress, error) {
+	var wallet domain.WalletAddress
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(&wallet.Address, &wallet.UserID, &wallet.ChainID, &wallet.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastUsedAt.Valid {
+		wallet.LastUsedAt = lastUsedAt.Time
+	}
+
+	return &wallet, nil
+}
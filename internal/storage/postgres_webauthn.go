@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	queryInsertWebAuthnCredential = `
+		INSERT INTO webauthn_credentials (id, user_uuid, public_key, sign_count, aaguid, attestation_format,
+		                                  backup_eligible, backup_state, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	queryGetWebAuthnCredential = `
+		SELECT id, user_uuid, public_key, sign_count, aaguid, attestation_format, backup_eligible, backup_state,
+		       created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE id = $1
+	`
+
+	queryListWebAuthnCredentials = `
+		SELECT id, user_uuid, public_key, sign_count, aaguid, attestation_format, backup_eligible, backup_state,
+		       created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE user_uuid = $1
+		ORDER BY created_at
+	`
+
+	// счётчик принимается, только если вырос; нулевые оба — аутентификатор счётчик не ведёт (синхронизируемые passkey)
+	queryUseWebAuthnCredential = `
+		UPDATE webauthn_credentials SET sign_count = $2, backup_state = $3, last_used_at = $4
+		WHERE id = $1 AND (sign_count < $2 OR (sign_count = 0 AND $2 = 0))
+	`
+)
+
+// WebAuthnStorageInterface — ключи WebAuthn (passkey) пользователей
+type WebAuthnStorageInterface interface {
+	// SaveWebAuthnCredential сохраняет ключ; если такой id уже зарегистрирован, возвращает domain.ErrWebAuthnCredentialExists
+	SaveWebAuthnCredential(ctx context.Context, credential *domain.WebAuthnCredential) error
+	// GetWebAuthnCredential возвращает ключ по id или nil, если его нет
+	GetWebAuthnCredential(ctx context.Context, id []byte) (*domain.WebAuthnCredential, error)
+	ListWebAuthnCredentials(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error)
+	// UseWebAuthnCredential сохраняет счётчик подписей успешного входа. false — счётчик не вырос
+	// относительно сохранённого (в том числе в параллельном входе), вход принимать нельзя
+	UseWebAuthnCredential(ctx context.Context, id []byte, signCount uint32, backupState bool, usedAt time.Time) (bool, error)
+}
+
+func (s *UserPostgresStorage) SaveWebAuthnCredential(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	if credential.CreatedAt.IsZero() {
+		credential.CreatedAt = time.Now()
+	}
+
+	saved, err := s.execAffected(ctx, "failed to save webauthn credential", queryInsertWebAuthnCredential,
+		credential.ID, credential.UserID, credential.PublicKey, int64(credential.SignCount), credential.AAGUID,
+		credential.AttestationFormat, credential.BackupEligible, credential.BackupState, credential.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return domain.ErrWebAuthnCredentialExists
+	}
+
+	return nil
+}
+
+func (s *UserPostgresStorage) GetWebAuthnCredential(ctx context.Context, id []byte) (*domain.WebAuthnCredential, error) {
+	credential, err := scanWebAuthnCredential(s.DB.QueryRowContext(ctx, queryGetWebAuthnCredential, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+func (s *UserPostgresStorage) ListWebAuthnCredentials(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	rows, err := s.DB.QueryContext(ctx, queryListWebAuthnCredentials, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []*domain.WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webauthn credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+func (s *UserPostgresStorage) UseWebAuthnCredential(ctx context.Context, id []byte, signCount uint32, backupState bool, usedAt time.Time) (bool, error) {
+	return s.execAffected(ctx, "failed to use webauthn credential", queryUseWebAuthnCredential, id, int64(signCount), backupState, usedAt)
+}
+
+func scanWebAuthnCredential(row interface{ Scan(dest ...any) error }) (*domain.WebAuthnCredential, error) {
+	var credential domain.WebAuthnCredential
+	var signCount int64
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(&credential.ID, &credential.UserID, &credential.PublicKey, &signCount, &credential.AAGUID,
+		&credential.AttestationFormat, &credential.BackupEligible, &credential.BackupState, &credential.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.SignCount = uint32(signCount)
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = lastUsedAt.Time
+	}
+
+	return &credential, nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"math"
+	"time"
+)
+
+// RateLimiterInterface — token bucket лимит запросов. Allow забирает токен из ведра key и,
+// если ведро пусто, возвращает false и время до появления следующего токена
+type RateLimiterInterface interface {
+	Allow(ctx context.Context, key string, rule model.RateLimitRule) (bool, time.Duration, error)
+}
+
+// rateLimiterSweepInterval — как часто MemoryRateLimiter удаляет полные вёдра
+const rateLimiterSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take пополняет ведро за время с last до now и пытается забрать из него токен
+func (b *tokenBucket) take(now time.Time, rule model.RateLimitRule) (bool, time.Duration) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(rule.Burst), b.tokens+float64(elapsed)/float64(rule.Interval))
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration(math.Ceil((1 - b.tokens) * float64(rule.Interval)))
+}
+
+// full — ведро уже восстановилось бы до ёмкости, хранить его незачем
+func (b *tokenBucket) full(now time.Time, rule model.RateLimitRule) bool {
+	return float64(now.Sub(b.last)) >= (float64(rule.Burst)-b.tokens)*float64(rule.Interval)
+}
+
+func (m *MemoryRateLimiter) Allow(_ context.Context, key string, rule model.RateLimitRule) (bool, time.Duration, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= rateLimiterSweepInterval {
+		for k, entry := range m.buckets {
+			if entry.bucket.full(now, entry.rule) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	entry, ok := m.buckets[key]
+	if !ok {
+		entry = &memoryBucket{bucket: tokenBucket{tokens: float64(rule.Burst), last: now}}
+		m.buckets[key] = entry
+	}
+	entry.rule = rule
+
+	allowed, retryAfter := entry.bucket.take(now, rule)
+
+	return allowed, retryAfter, nil
+}
+
+type memoryBucket struct {
+	bucket tokenBucket
+	rule   model.RateLimitRule
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	rule := model.RateLimitRule{Burst: 2, Interval: 10 * time.Second}
+	start := time.Now()
+	bucket := &tokenBucket{tokens: float64(rule.Burst), last: start}
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := bucket.take(start, rule)
+		require.True(t, allowed, "запрос %d в пределах ёмкости", i+1)
+	}
+
+	allowed, retryAfter := bucket.take(start, rule)
+	require.False(t, allowed, "ведро пусто")
+	require.Equal(t, 10*time.Second, retryAfter)
+
+	allowed, retryAfter = bucket.take(start.Add(4*time.Second), rule)
+	require.False(t, allowed, "токен ещё не восстановился")
+	require.Equal(t, 6*time.Second, retryAfter)
+
+	allowed, _ = bucket.take(start.Add(10*time.Second), rule)
+	require.True(t, allowed, "токен восстановился")
+
+	require.False(t, bucket.full(start.Add(20*time.Second), rule))
+	require.True(t, bucket.full(start.Add(30*time.Second), rule), "ведро не копит больше ёмкости")
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := &MemoryRateLimiter{buckets: make(map[string]*memoryBucket), now: func() time.Time { return now }}
+	rule := model.RateLimitRule{Burst: 1, Interval: time.Minute}
+	ctx := context.Background()
+
+	allowed, _, err := limiter.Allow(ctx, "Login:ip:203.0.113.1", rule)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, retryAfter, err := limiter.Allow(ctx, "Login:ip:203.0.113.1", rule)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, time.Minute, retryAfter)
+
+	allowed, _, err = limiter.Allow(ctx, "Login:ip:203.0.113.2", rule)
+	require.NoError(t, err)
+	require.True(t, allowed, "у другого ключа своё ведро")
+
+	now = now.Add(2 * time.Minute)
+	allowed, _, err = limiter.Allow(ctx, "Register:ip:203.0.113.3", rule)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	require.Len(t, limiter.buckets, 1, "восстановившиеся вёдра удалены")
+}
+
+func TestRedisRateLimiterFallback(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { _ = client.Close() })
+
+	limiter := NewRateLimiter(&model.RedisConfig{RateLimitPrefix: "rate_limit_test"}, client)
+	rule := model.RateLimitRule{Burst: 1, Interval: time.Minute}
+
+	allowed, _, err := limiter.Allow(context.Background(), "Login:ip:203.0.113.1", rule)
+	require.NoError(t, err, "недоступный Redis не роняет запрос")
+	require.True(t, allowed)
+
+	allowed, _, err = limiter.Allow(context.Background(), "Login:ip:203.0.113.1", rule)
+	require.NoError(t, err)
+	require.False(t, allowed, "лимит продолжает действовать в памяти")
+}
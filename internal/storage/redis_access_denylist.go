@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/domain"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// familyAccessKey — sorted set jti access токенов семейства, score — время истечения (unix)
+func (s *SessionManager) familyAccessKey(familyID string) string {
+	return fmt.Sprintf("%s:family:%s:access", s.prefix, familyID)
+}
+
+// deniedAccessKey — запись denylist, живёт ровно до истечения отозванного токена
+func (s *SessionManager) deniedAccessKey(tokenID string) string {
+	return fmt.Sprintf("%s:denied:%s", s.prefix, tokenID)
+}
+
+// accessSessionKey — семейство, в котором выдан access токен; живёт до истечения токена
+func (s *SessionManager) accessSessionKey(tokenID string) string {
+	return fmt.Sprintf("%s:access:%s", s.prefix, tokenID)
+}
+
+// RecordAccessToken запоминает access токен, выданный в семействе, чтобы отозвать его вместе с семейством
+func (s *SessionManager) RecordAccessToken(ctx context.Context, familyID string, accessToken *domain.AccessToken) error {
+	key := s.familyAccessKey(familyID)
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(accessToken.ExpiresAt.Unix()), Member: accessToken.ID})
+		pipe.Expire(ctx, key, s.expiration)
+		pipe.Set(ctx, s.accessSessionKey(accessToken.ID), familyID, time.Until(accessToken.ExpiresAt))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record access token: %w", err)
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked проверяет jti по denylist
+func (s *SessionManager) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	exists, err := s.client.Exists(ctx, s.deniedAccessKey(tokenID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check access token denylist: %w", err)
+	}
+
+	return exists > 0, nil
+}
+
+// SessionIDByAccessToken возвращает сессию (семейство), в которой выдан access токен,
+// или пустую строку, если токен выдан до появления этой связи либо уже истёк
+func (s *SessionManager) SessionIDByAccessToken(ctx context.Context, tokenID string) (string, error) {
+	familyID, err := s.client.Get(ctx, s.accessSessionKey(tokenID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token session: %w", err)
+	}
+
+	return familyID, nil
+}
+
+// denyFamilyAccessTokens добавляет в pipe запись в denylist для всех живых access токенов семейства
+func (s *SessionManager) denyFamilyAccessTokens(ctx context.Context, client redis.Cmdable, pipe redis.Pipeliner, familyID string) error {
+	key := s.familyAccessKey(familyID)
+	now := time.Now()
+
+	tokens, err := client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min: strconv.FormatInt(now.Unix()+1, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get family access tokens: %w", err)
+	}
+
+	for _, token := range tokens {
+		tokenID, ok := token.Member.(string)
+		if !ok {
+			continue
+		}
+
+		ttl := time.Unix(int64(token.Score), 0).Sub(now)
+		pipe.Set(ctx, s.deniedAccessKey(tokenID), familyID, ttl)
+	}
+	pipe.Del(ctx, key)
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// поля входа по email в хеше Redis
+const (
+	emailLoginFieldCode     = "code"
+	emailLoginFieldLink     = "link"
+	emailLoginFieldAttempts = "attempts"
+)
+
+// emailLoginUseScript сверяет HMAC кода или ссылки: совпадение гасит вход целиком (и код, и ссылку),
+// несовпадение увеличивает счётчик попыток, а исчерпанный лимит удаляет вход.
+// Возвращает 1 — вход принят, 0 — нет
+var emailLoginUseScript = redis.NewScript(`
+local stored = redis.call('HGET', KEYS[1], ARGV[1])
+if not stored then
+	return 0
+end
+if stored == ARGV[2] then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if attempts >= tonumber(ARGV[3]) then
+	redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// EmailLoginStorageInterface — входы без пароля по коду и ссылке из письма. У пользователя одновременно жив
+// один вход; код и nonce ссылки хранятся только как HMAC, неверные попытки ограничены maxAttempts
+type EmailLoginStorageInterface interface {
+	// SaveEmailLogin начинает вход, заменяя незавершённый вход пользователя
+	SaveEmailLogin(ctx context.Context, userID, code, linkNonce string, ttl time.Duration) error
+	// UseEmailLoginCode принимает код и гасит вход; неверный код расходует попытку
+	UseEmailLoginCode(ctx context.Context, userID, code string, maxAttempts int) (bool, error)
+	// UseEmailLoginLink принимает nonce ссылки и гасит вход; неверный nonce расходует попытку
+	UseEmailLoginLink(ctx context.Context, userID, linkNonce string, maxAttempts int) (bool, error)
+}
+
+func (s *EmailLoginStorage) key(userID string) string {
+	return fmt.Sprintf("%s:%s", s.prefix, userID)
+}
+
+// secretHash — HMAC значения, привязанный к пользователю и виду секрета
+func (s *EmailLoginStorage) secretHash(field, userID, value string) string {
+	mac := hmac.New(sha256.New, s.pepper)
+	mac.Write([]byte(field + ":" + userID + ":" + value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *EmailLoginStorage) SaveEmailLogin(ctx context.Context, userID, code, linkNonce string, ttl time.Duration) error {
+	key := s.key(userID)
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key,
+			emailLoginFieldCode, s.secretHash(emailLoginFieldCode, userID, code),
+			emailLoginFieldLink, s.secretHash(emailLoginFieldLink, userID, linkNonce),
+			emailLoginFieldAttempts, 0)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save email login: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EmailLoginStorage) UseEmailLoginCode(ctx context.Context, userID, code string, maxAttempts int) (bool, error) {
+	return s.use(ctx, emailLoginFieldCode, userID, code, maxAttempts)
+}
+
+func (s *EmailLoginStorage) UseEmailLoginLink(ctx context.Context, userID, linkNonce string, maxAttempts int) (bool, error) {
+	return s.use(ctx, emailLoginFieldLink, userID, linkNonce, maxAttempts)
+}
+
+func (s *EmailLoginStorage) use(ctx context.Context, field, userID, value string, maxAttempts int) (bool, error) {
+	used, err := emailLoginUseScript.Run(ctx, s.client, []string{s.key(userID)},
+		field, s.secretHash(field, userID, value), maxAttempts).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to use email login %s: %w", field, err)
+	}
+
+	return used == 1, nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Области учёта неудачных входов
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+)
+
+// значения ключа блокировки
+const (
+	loginBlockBackoff = "backoff"
+	loginBlockLockout = "lockout"
+)
+
+// LoginAttemptsInterface считает неудачные входы по аккаунту и по адресу клиента. userID — ключ аккаунта
+// (id пользователя или производный от логина ключ несуществующего аккаунта).
+// Пустой userID или ip означает, что соответствующая область не учитывается
+type LoginAttemptsInterface interface {
+	// Blocked возвращает действующее ограничение входа или nil
+	Blocked(ctx context.Context, userID, ip string) (*domain.LoginBlock, error)
+	// RecordFailure учитывает неудачный вход и возвращает ограничение, которое он включил, или nil
+	RecordFailure(ctx context.Context, userID, ip string) (*domain.LoginBlock, error)
+	// ResetAccount сбрасывает счётчик аккаунта после успешного входа
+	ResetAccount(ctx context.Context, userID string) error
+	// Unlock снимает ограничения и сбрасывает счётчики (административная разблокировка)
+	Unlock(ctx context.Context, userID, ip string) error
+}
+
+func (l *LoginAttempts) failuresKey(scope, id string) string {
+	return fmt.Sprintf("%s:%s:%s:failures", l.prefix, scope, id)
+}
+
+func (l *LoginAttempts) blockKey(scope, id string) string {
+	return fmt.Sprintf("%s:%s:%s:block", l.prefix, scope, id)
+}
+
+// scopes перечисляет непустые области вместе с их политиками
+func (l *LoginAttempts) scopes(userID, ip string) []loginScope {
+	var scopes []loginScope
+	if userID != "" {
+		scopes = append(scopes, loginScope{name: LoginScopeAccount, id: userID, policy: l.cfg.Account})
+	}
+	if ip != "" {
+		scopes = append(scopes, loginScope{name: LoginScopeIP, id: ip, policy: l.cfg.IP})
+	}
+
+	return scopes
+}
+
+type loginScope struct {
+	name   string
+	id     string
+	policy model.LockoutPolicy
+}
+
+// Blocked при нескольких ограничениях возвращает блокировку, а из задержек — самую долгую
+func (l *LoginAttempts) Blocked(ctx context.Context, userID, ip string) (*domain.LoginBlock, error) {
+	var result *domain.LoginBlock
+
+	for _, scope := range l.scopes(userID, ip) {
+		key := l.blockKey(scope.name, scope.id)
+
+		kind, err := l.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get login block: %w", err)
+		}
+
+		var ttl time.Duration
+		ttl, err = l.client.PTTL(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get login block ttl: %w", err)
+		}
+		if ttl <= 0 {
+			continue
+		}
+
+		block := &domain.LoginBlock{Scope: scope.name, Locked: kind == loginBlockLockout, RetryAfter: ttl}
+		if result == nil || block.Locked && !result.Locked || block.Locked == result.Locked && block.RetryAfter > result.RetryAfter {
+			result = block
+		}
+	}
+
+	return result, nil
+}
+
+func (l *LoginAttempts) RecordFailure(ctx context.Context, userID, ip string) (*domain.LoginBlock, error) {
+	var result *domain.LoginBlock
+
+	for _, scope := range l.scopes(userID, ip) {
+		failuresKey := l.failuresKey(scope.name, scope.id)
+
+		var incr *redis.IntCmd
+		_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			incr = pipe.Incr(ctx, failuresKey)
+			pipe.Expire(ctx, failuresKey, l.cfg.Window)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to record login failure: %w", err)
+		}
+
+		delay, locked := blockDuration(int(incr.Val()), scope.policy)
+		if delay <= 0 {
+			continue
+		}
+
+		kind := loginBlockBackoff
+		if locked {
+			kind = loginBlockLockout
+		}
+
+		if err = l.client.Set(ctx, l.blockKey(scope.name, scope.id), kind, delay).Err(); err != nil {
+			return nil, fmt.Errorf("failed to set login block: %w", err)
+		}
+
+		if result == nil || locked {
+			result = &domain.LoginBlock{Scope: scope.name, Locked: locked, RetryAfter: delay}
+		}
+	}
+
+	return result, nil
+}
+
+func (l *LoginAttempts) ResetAccount(ctx context.Context, userID string) error {
+	err := l.client.Del(ctx, l.failuresKey(LoginScopeAccount, userID), l.blockKey(LoginScopeAccount, userID)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	return nil
+}
+
+func (l *LoginAttempts) Unlock(ctx context.Context, userID, ip string) error {
+	var keys []string
+	for _, scope := range l.scopes(userID, ip) {
+		keys = append(keys, l.failuresKey(scope.name, scope.id), l.blockKey(scope.name, scope.id))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := l.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to unlock login: %w", err)
+	}
+
+	return nil
+}
+
+// blockDuration — ограничение после failures неудач подряд: блокировка после LockoutAfter неудач,
+// иначе задержка BaseDelay * 2^(failures-BackoffAfter-1), но не больше MaxDelay
+func blockDuration(failures int, policy model.LockoutPolicy) (time.Duration, bool) {
+	if policy.LockoutAfter > 0 && failures >= policy.LockoutAfter {
+		return policy.LockoutDuration, true
+	}
+
+	if failures <= policy.BackoffAfter || policy.BaseDelay <= 0 {
+		return 0, false
+	}
+
+	delay := policy.BaseDelay
+	for i := policy.BackoffAfter + 1; i < failures; i++ {
+		delay *= 2
+		if policy.MaxDelay > 0 && delay >= policy.MaxDelay {
+			return policy.MaxDelay, false
+		}
+	}
+
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	return delay, false
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"crypto_analyzer_auth_service/internal/config/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBlockDuration(t *testing.T) {
+	policy := model.LockoutPolicy{
+		BackoffAfter:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Second,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+	}
+
+	cases := []struct {
+		failures int
+		delay    time.Duration
+		locked   bool
+	}{
+		{1, 0, false},
+		{3, 0, false},
+		{4, time.Second, false},
+		{5, 2 * time.Second, false},
+		{6, 4 * time.Second, false},
+		{7, 5 * time.Second, false},
+		{9, 5 * time.Second, false},
+		{10, 15 * time.Minute, true},
+		{25, 15 * time.Minute, true},
+	}
+
+	for _, tc := range cases {
+		delay, locked := blockDuration(tc.failures, policy)
+
+		require.Equal(t, tc.delay, delay, "failures %d", tc.failures)
+		require.Equal(t, tc.locked, locked, "failures %d", tc.failures)
+	}
+}
+
+func TestBlockDurationWithoutLockout(t *testing.T) {
+	delay, locked := blockDuration(1000, model.LockoutPolicy{BackoffAfter: 1, BaseDelay: time.Second, MaxDelay: time.Minute})
+
+	require.Equal(t, time.Minute, delay)
+	require.False(t, locked)
+}
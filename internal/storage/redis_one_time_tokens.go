@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Назначения одноразовых токенов; токен одного назначения нельзя предъявить для другого
+const (
+	TokenPurposePasswordReset        = "password_reset"
+	TokenPurposeEmailVerification    = "email_verification"
+	TokenPurposeMFAChallenge         = "mfa_challenge"
+	TokenPurposeWebAuthnRegistration = "webauthn_registration"
+	TokenPurposeWebAuthnLogin        = "webauthn_login"
+	TokenPurposeSiweNonce            = "siwe_nonce"
+	TokenPurposeWalletLink           = "wallet_link"
+)
+
+// OneTimeTokenStorageInterface — короткоживущие одноразовые токены (сброс пароля и т.п.).
+// В Redis хранится только HMAC токена; у пользователя одновременно жив один токен каждого назначения
+type OneTimeTokenStorageInterface interface {
+	SaveToken(ctx context.Context, purpose, userID, token string, ttl time.Duration) error
+	// PeekToken возвращает владельца токена, не погашая его; пустая строка — токена нет или он истёк
+	PeekToken(ctx context.Context, purpose, token string) (string, error)
+	// ConsumeToken атомарно гасит токен и возвращает его владельца; повторный вызов вернёт пустую строку
+	ConsumeToken(ctx context.Context, purpose, token string) (string, error)
+}
+
+func (s *OneTimeTokenStorage) tokenKey(purpose, token string) string {
+	mac := hmac.New(sha256.New, s.pepper)
+	mac.Write([]byte(purpose + ":" + token))
+
+	return fmt.Sprintf("%s:%s:%s", s.prefix, purpose, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// userTokenKey указывает на ключ текущего токена пользователя, чтобы новый токен вытеснял старый
+func (s *OneTimeTokenStorage) userTokenKey(purpose, userID string) string {
+	return fmt.Sprintf("%s:%s:user:%s", s.prefix, purpose, userID)
+}
+
+func (s *OneTimeTokenStorage) SaveToken(ctx context.Context, purpose, userID, token string, ttl time.Duration) error {
+	userKey := s.userTokenKey(purpose, userID)
+
+	previousKey, err := s.client.Get(ctx, userKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to get previous token: %w", err)
+	}
+
+	key := s.tokenKey(purpose, token)
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previousKey != "" {
+			pipe.Del(ctx, previousKey)
+		}
+		pipe.Set(ctx, key, userID, ttl)
+		pipe.Set(ctx, userKey, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *OneTimeTokenStorage) PeekToken(ctx context.Context, purpose, token string) (string, error) {
+	userID, err := s.client.Get(ctx, s.tokenKey(purpose, token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get token: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (s *OneTimeTokenStorage) ConsumeToken(ctx context.Context, purpose, token string) (string, error) {
+	key := s.tokenKey(purpose, token)
+
+	var get *redis.StringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	userID := get.Val()
+	if err = s.client.Del(ctx, s.userTokenKey(purpose, userID)).Err(); err != nil {
+		return "", fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestOneTimeTokenKeyDependsOnPurpose(t *testing.T) {
+	s := &OneTimeTokenStorage{prefix: "one_time_token", pepper: []byte("pepper")}
+	token := "raw-reset-token"
+
+	key := s.tokenKey(TokenPurposePasswordReset, token)
+
+	require.True(t, strings.HasPrefix(key, "one_time_token:password_reset:"))
+	require.NotContains(t, key, token)
+	require.NotEqual(t, key, s.tokenKey("other_purpose", token), "токен другого назначения не подходит")
+}
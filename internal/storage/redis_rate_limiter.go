@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// rateLimitScript атомарно пополняет ведро по времени Redis и забирает токен.
+// ARGV: ёмкость, интервал восстановления токена в микросекундах. Возвращает {1|0, ожидание в микросекундах}
+const rateLimitScript = `
+if redis.replicate_commands then redis.replicate_commands() end
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) / interval)
+  ts = now
+end
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) * interval)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', string.format('%.0f', ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * interval / 1000) + 1)
+
+return {allowed, wait}
+`
+
+// Allow считает ведро в Redis, общее для всех реплик. Пока Redis недоступен, лимит считается
+// в памяти процесса: так сбой Redis не открывает сервис без ограничений и не роняет все запросы
+func (r *RedisRateLimiter) Allow(ctx context.Context, key string, rule model.RateLimitRule) (bool, time.Duration, error) {
+	allowed, retryAfter, err := r.allowRedis(ctx, key, rule)
+	if err != nil {
+		logger.FromContext(ctx).Warn("rate limiter redis unavailable, using in-memory fallback", zap.Error(err))
+		return r.fallback.Allow(ctx, key, rule)
+	}
+
+	return allowed, retryAfter, nil
+}
+
+func (r *RedisRateLimiter) allowRedis(ctx context.Context, key string, rule model.RateLimitRule) (bool, time.Duration, error) {
+	result, err := r.script.Run(ctx, r.client, []string{fmt.Sprintf("%s:%s", r.prefix, key)},
+		rule.Burst, rule.Interval.Microseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Microsecond, nil
+}
@@ -2,38 +2,294 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto_analyzer_auth_service/internal/domain"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"sort"
+	"strings"
+	"time"
 )
 
+// maxRotateAttempts — сколько раз повторять ротацию, если ключи изменились во время транзакции
+const maxRotateAttempts = 3
+
 type SessionManagerInterface interface {
-	SaveRefreshToken(ctx context.Context, userID, refreshToken string) error
+	SaveRefreshToken(ctx context.Context, userID, refreshToken string, client domain.ClientInfo) (*domain.RefreshSession, error)
+	RotateRefreshToken(ctx context.Context, refreshToken, newRefreshToken string, client domain.ClientInfo) (*domain.RefreshSession, error)
 	GetUserIDByRefreshToken(ctx context.Context, refreshToken string) (string, error)
 	DeleteRefreshToken(ctx context.Context, refreshToken string) error
+	RecordAccessToken(ctx context.Context, familyID string, accessToken *domain.AccessToken) error
+	IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	ListSessions(ctx context.Context, userID string) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) (int, error)
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) (int, error)
+	SessionIDByAccessToken(ctx context.Context, tokenID string) (string, error)
 	//IsRefreshTokenValid(ctx context.Context, userID, refreshToken string) (bool, error)
 }
 
-func (s *SessionManager) SaveRefreshToken(ctx context.Context, userID, refreshToken string) error {
-	key := fmt.Sprintf("%s:%s", s.prefix, refreshToken)
-	if err := s.client.Set(ctx, key, userID, s.expiration).Err(); err != nil {
-		return fmt.Errorf("failed to save refresh token: %w", err)
+// refreshTokenRecord — значение ключа prefix:rt:<token>.
+// Ротированный токен не удаляется, а помечается Rotated, чтобы распознать его повторное предъявление
+type refreshTokenRecord struct {
+	UserID     string `json:"user_id"`
+	FamilyID   string `json:"family_id"`
+	Generation int    `json:"generation"`
+	Rotated    bool   `json:"rotated,omitempty"`
+}
+
+// refreshFamilyRecord — значение ключа prefix:family:<id>, описывает текущий токен семейства.
+// Семейство и есть сессия пользователя, поэтому здесь же хранятся данные об устройстве
+type refreshFamilyRecord struct {
+	UserID     string `json:"user_id"`
+	Generation int    `json:"generation"`
+	CurrentKey string `json:"current_key"`
+	CreatedAt  int64  `json:"created_at"`
+	LastUsedAt int64  `json:"last_used_at"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	ClientName string `json:"client_name,omitempty"`
+}
+
+// tokenKey — ключ записи токена в отдельном пространстве prefix:rt: — HMAC-SHA256 от токена с серверным pepper,
+// так что по содержимому Redis нельзя восстановить рабочий refresh токен, а токен не адресует ключи семейств и индексов
+func (s *SessionManager) tokenKey(refreshToken string) string {
+	mac := hmac.New(sha256.New, s.pepper)
+	mac.Write([]byte(refreshToken))
+
+	return fmt.Sprintf("%s:rt:%s", s.prefix, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// legacyTokenKey — ключ старого формата с токеном в открытом виде.
+// Такие записи принимаются, пока не истекут, новые под ними не создаются.
+// Ключ строится только для токена в формате GenerateRefreshToken (base64url настроенной длины):
+// токен с ':' указывал бы на служебные ключи (family:, user:, ...)
+func (s *SessionManager) legacyTokenKey(refreshToken string) (string, bool) {
+	if refreshToken == "" || strings.Contains(refreshToken, ":") {
+		return "", false
 	}
 
-	return nil
+	raw, err := base64.URLEncoding.DecodeString(refreshToken)
+	if err != nil || len(raw) != s.legacyTokenBytes || base64.URLEncoding.EncodeToString(raw) != refreshToken {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s:%s", s.prefix, refreshToken), true
+}
+
+// resolveTokenKey возвращает ключ, под которым хранится токен: хешированный, а если его нет —
+// один из старых (prefix:rt:<токен> или prefix:<токен>). Для неизвестного токена возвращается хешированный ключ
+func (s *SessionManager) resolveTokenKey(ctx context.Context, refreshToken string) (string, error) {
+	key := s.tokenKey(refreshToken)
+
+	exists, err := s.client.Exists(ctx, key).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to check refresh token: %w", err)
+	}
+	if exists > 0 {
+		return key, nil
+	}
+
+	legacyKey, ok := s.legacyTokenKey(refreshToken)
+	if !ok {
+		return key, nil
+	}
+
+	for _, candidate := range []string{fmt.Sprintf("%s:rt:%s", s.prefix, refreshToken), legacyKey} {
+		exists, err = s.client.Exists(ctx, candidate).Result()
+		if err != nil {
+			return "", fmt.Errorf("failed to check refresh token: %w", err)
+		}
+		if exists > 0 {
+			return candidate, nil
+		}
+	}
+
+	return key, nil
+}
+
+func (s *SessionManager) familyKey(familyID string) string {
+	return fmt.Sprintf("%s:family:%s", s.prefix, familyID)
+}
+
+// userSessionsKey — множество идентификаторов семейств пользователя
+func (s *SessionManager) userSessionsKey(userID string) string {
+	return fmt.Sprintf("%s:user:%s:sessions", s.prefix, userID)
+}
+
+// SaveRefreshToken начинает новое семейство refresh токенов (один вход — одно семейство)
+func (s *SessionManager) SaveRefreshToken(ctx context.Context, userID, refreshToken string, client domain.ClientInfo) (*domain.RefreshSession, error) {
+	familyID := uuid.New().String()
+	key := s.tokenKey(refreshToken)
+	now := time.Now().Unix()
+
+	tokenValue, err := json.Marshal(refreshTokenRecord{UserID: userID, FamilyID: familyID, Generation: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refresh token: %w", err)
+	}
+
+	familyValue, err := json.Marshal(refreshFamilyRecord{
+		UserID:     userID,
+		Generation: 1,
+		CurrentKey: key,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		ClientName: client.ClientName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refresh token family: %w", err)
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, tokenValue, s.expiration)
+		pipe.Set(ctx, s.familyKey(familyID), familyValue, s.expiration)
+		pipe.SAdd(ctx, s.userSessionsKey(userID), familyID)
+		pipe.Expire(ctx, s.userSessionsKey(userID), s.expiration)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return &domain.RefreshSession{UserID: userID, FamilyID: familyID, Generation: 1}, nil
+}
+
+// RotateRefreshToken атомарно заменяет refreshToken на newRefreshToken в рамках его семейства.
+// Если refreshToken уже был ротирован, всё семейство отзывается и возвращается
+// domain.ErrRefreshTokenReused вместе с данными предъявленного токена
+func (s *SessionManager) RotateRefreshToken(ctx context.Context, refreshToken, newRefreshToken string, client domain.ClientInfo) (*domain.RefreshSession, error) {
+	key, err := s.resolveTokenKey(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	newKey := s.tokenKey(newRefreshToken)
+
+	for attempt := 0; attempt < maxRotateAttempts; attempt++ {
+		var session *domain.RefreshSession
+		var rotateErr error
+
+		err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+			record, err := s.getRecord(ctx, tx, key)
+			if err != nil {
+				return err
+			}
+
+			var family *refreshFamilyRecord
+			if record.FamilyID == "" {
+				// токен старого формата (голый userID): ротация переводит его в новое семейство
+				record.FamilyID = uuid.New().String()
+				family = &refreshFamilyRecord{UserID: record.UserID, Generation: record.Generation, CurrentKey: key, CreatedAt: time.Now().Unix()}
+			}
+
+			familyKey := s.familyKey(record.FamilyID)
+			if family == nil {
+				if err = tx.Watch(ctx, familyKey).Err(); err != nil {
+					return fmt.Errorf("failed to watch refresh token family: %w", err)
+				}
+
+				family, err = s.getFamily(ctx, tx, familyKey)
+				if err != nil {
+					return err
+				}
+			}
+
+			session = &domain.RefreshSession{UserID: record.UserID, FamilyID: record.FamilyID, Generation: record.Generation}
+
+			if record.Rotated {
+				rotateErr = domain.ErrRefreshTokenReused
+
+				_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					return s.revokeFamily(ctx, tx, pipe, record.UserID, record.FamilyID, family)
+				})
+				return err
+			}
+
+			if family == nil {
+				// семейство уже отозвано (logout или обнаруженный повтор)
+				rotateErr = domain.ErrNoSuchRefreshToken
+
+				return tx.Del(ctx, key).Err()
+			}
+
+			record.Rotated = true
+			newRecord := refreshTokenRecord{UserID: record.UserID, FamilyID: record.FamilyID, Generation: family.Generation + 1}
+			family.Generation = newRecord.Generation
+			family.CurrentKey = newKey
+			family.LastUsedAt = time.Now().Unix()
+			if client.IP != "" {
+				family.IP = client.IP
+			}
+			if client.UserAgent != "" {
+				family.UserAgent = client.UserAgent
+			}
+			if client.ClientName != "" {
+				family.ClientName = client.ClientName
+			}
+
+			var recordValue, newRecordValue, familyValue []byte
+			if recordValue, err = json.Marshal(record); err != nil {
+				return fmt.Errorf("failed to marshal refresh token: %w", err)
+			}
+			if newRecordValue, err = json.Marshal(newRecord); err != nil {
+				return fmt.Errorf("failed to marshal refresh token: %w", err)
+			}
+			if familyValue, err = json.Marshal(family); err != nil {
+				return fmt.Errorf("failed to marshal refresh token family: %w", err)
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.SetArgs(ctx, key, recordValue, redis.SetArgs{KeepTTL: true})
+				pipe.Set(ctx, newKey, newRecordValue, s.expiration)
+				pipe.Set(ctx, familyKey, familyValue, s.expiration)
+				pipe.SAdd(ctx, s.userSessionsKey(record.UserID), record.FamilyID)
+				pipe.Expire(ctx, s.userSessionsKey(record.UserID), s.expiration)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			session = &domain.RefreshSession{UserID: newRecord.UserID, FamilyID: newRecord.FamilyID, Generation: newRecord.Generation}
+
+			return nil
+		}, key)
+
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return session, rotateErr
+	}
+
+	return nil, fmt.Errorf("failed to rotate refresh token: %w", redis.TxFailedErr)
 }
 
 func (s *SessionManager) GetUserIDByRefreshToken(ctx context.Context, refreshToken string) (string, error) {
-	key := fmt.Sprintf("%s:%s", s.prefix, refreshToken)
-	userID, err := s.client.Get(ctx, key).Result()
-	if errors.Is(err, redis.Nil) {
-		return "", domain.ErrNoSuchRefreshToken
+	key, err := s.resolveTokenKey(ctx, refreshToken)
+	if err != nil {
+		return "", err
 	}
+
+	record, err := s.getRecord(ctx, s.client, key)
 	if err != nil {
-		return "", fmt.Errorf("failed to get userID: %w", err)
+		return "", err
 	}
-	return userID, nil
+	if record.Rotated {
+		return "", domain.ErrNoSuchRefreshToken
+	}
+
+	return record.UserID, nil
 }
 
 /*
@@ -52,15 +308,232 @@ func (s *SessionManager) GetUserIDByRefreshToken(ctx context.Context, refreshTok
 		return storedUserID == userID, nil
 	}
 */
+
+// DeleteRefreshToken удаляет токен вместе со всем его семейством
 func (s *SessionManager) DeleteRefreshToken(ctx context.Context, refreshToken string) error {
-	key := fmt.Sprintf("%s:%s", s.prefix, refreshToken)
-	deleted, err := s.client.Del(ctx, key).Result()
+	key, err := s.resolveTokenKey(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	record, err := s.getRecord(ctx, s.client, key)
+	if err != nil {
+		return err
+	}
+
+	var family *refreshFamilyRecord
+	if record.FamilyID != "" {
+		family, err = s.getFamily(ctx, s.client, s.familyKey(record.FamilyID))
+		if err != nil {
+			return err
+		}
+	}
+
+	var deleted *redis.IntCmd
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.Del(ctx, key)
+		if record.FamilyID != "" {
+			return s.revokeFamily(ctx, s.client, pipe, record.UserID, record.FamilyID, family)
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete refresh token: %w", err)
 	}
 
-	if deleted == 0 {
+	if deleted.Val() == 0 {
 		return domain.ErrNoSuchRefreshToken
 	}
 	return nil
 }
+
+// ListSessions возвращает живые сессии пользователя, последние использованные — первыми
+func (s *SessionManager) ListSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	families, err := s.userFamilies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]domain.Session, 0, len(families))
+	for familyID, family := range families {
+		sessions = append(sessions, domain.Session{
+			ID:         familyID,
+			CreatedAt:  time.Unix(family.CreatedAt, 0),
+			LastUsedAt: time.Unix(family.LastUsedAt, 0),
+			IP:         family.IP,
+			UserAgent:  family.UserAgent,
+			ClientName: family.ClientName,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+// RevokeSession отзывает одно семейство пользователя. Чужая сессия неотличима от несуществующей
+func (s *SessionManager) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	family, err := s.getFamily(ctx, s.client, s.familyKey(sessionID))
+	if err != nil {
+		return err
+	}
+	if family == nil || family.UserID != userID {
+		return domain.ErrNoSuchSession
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return s.revokeFamily(ctx, s.client, pipe, userID, sessionID, family)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions отзывает все семейства пользователя и возвращает их количество
+func (s *SessionManager) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	families, err := s.userFamilies(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for familyID, family := range families {
+			if err := s.revokeFamily(ctx, s.client, pipe, userID, familyID, family); err != nil {
+				return err
+			}
+		}
+		// индекс не удаляется целиком: семейство, созданное входом после чтения индекса, остаётся в нём
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return len(families), nil
+}
+
+// RevokeOtherSessions отзывает все семейства пользователя, кроме keepSessionID, и возвращает их количество
+func (s *SessionManager) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) (int, error) {
+	families, err := s.userFamilies(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	delete(families, keepSessionID)
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for familyID, family := range families {
+			if err := s.revokeFamily(ctx, s.client, pipe, userID, familyID, family); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return len(families), nil
+}
+
+// userFamilies читает семейства из индекса пользователя; истёкшие попутно убираются из индекса
+func (s *SessionManager) userFamilies(ctx context.Context, userID string) (map[string]*refreshFamilyRecord, error) {
+	familyIDs, err := s.client.SMembers(ctx, s.userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	families := make(map[string]*refreshFamilyRecord, len(familyIDs))
+	var stale []interface{}
+
+	for _, familyID := range familyIDs {
+		var family *refreshFamilyRecord
+		family, err = s.getFamily(ctx, s.client, s.familyKey(familyID))
+		if err != nil {
+			return nil, err
+		}
+		if family == nil || family.UserID != userID {
+			stale = append(stale, familyID)
+			continue
+		}
+
+		families[familyID] = family
+	}
+
+	if len(stale) > 0 {
+		if err = s.client.SRem(ctx, s.userSessionsKey(userID), stale...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to clean user sessions: %w", err)
+		}
+	}
+
+	return families, nil
+}
+
+// revokeFamily добавляет в pipe удаление семейства, его текущего токена и записи в индексе пользователя,
+// а выданные семейством и ещё не истёкшие access токены — в denylist
+func (s *SessionManager) revokeFamily(ctx context.Context, client redis.Cmdable, pipe redis.Pipeliner, userID, familyID string, family *refreshFamilyRecord) error {
+	if err := s.denyFamilyAccessTokens(ctx, client, pipe, familyID); err != nil {
+		return err
+	}
+
+	pipe.Del(ctx, s.familyKey(familyID))
+	if family != nil {
+		pipe.Del(ctx, family.CurrentKey)
+	}
+	pipe.SRem(ctx, s.userSessionsKey(userID), familyID)
+
+	return nil
+}
+
+// getRecord читает запись токена. Записи старого формата содержат только userID,
+// такой токен считается первым поколением собственного семейства
+func (s *SessionManager) getRecord(ctx context.Context, client redis.Cmdable, key string) (*refreshTokenRecord, error) {
+	value, err := client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrNoSuchRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return parseRecord(value)
+}
+
+// parseRecord разбирает значение записи токена: JSON с семейством или, для старого формата, голый UUID пользователя.
+// Всё остальное (в том числе JSON без family_id) токеном не считается
+func parseRecord(value string) (*refreshTokenRecord, error) {
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(value), &record); err == nil {
+		if record.UserID == "" || record.FamilyID == "" {
+			return nil, domain.ErrNoSuchRefreshToken
+		}
+		return &record, nil
+	}
+
+	userID, err := uuid.Parse(value)
+	if err != nil || userID.String() != value {
+		return nil, domain.ErrNoSuchRefreshToken
+	}
+
+	return &refreshTokenRecord{UserID: value, Generation: 1}, nil
+}
+
+func (s *SessionManager) getFamily(ctx context.Context, client redis.Cmdable, familyKey string) (*refreshFamilyRecord, error) {
+	value, err := client.Get(ctx, familyKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token family: %w", err)
+	}
+
+	var family refreshFamilyRecord
+	if err = json.Unmarshal([]byte(value), &family); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh token family: %w", err)
+	}
+
+	return &family, nil
+}
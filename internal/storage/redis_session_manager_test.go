@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"crypto_analyzer_auth_service/internal/domain"
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestTokenKeyDoesNotExposeToken(t *testing.T) {
+	s := &SessionManager{prefix: "refresh_token", pepper: []byte("pepper"), legacyTokenBytes: 32}
+	token := base64.URLEncoding.EncodeToString(make([]byte, 32))
+
+	key := s.tokenKey(token)
+
+	require.True(t, strings.HasPrefix(key, "refresh_token:rt:"))
+	require.NotContains(t, key, token)
+	require.Equal(t, key, s.tokenKey(token), "ключ детерминирован")
+
+	legacyKey, ok := s.legacyTokenKey(token)
+	require.True(t, ok)
+	require.Equal(t, "refresh_token:"+token, legacyKey)
+
+	other := &SessionManager{prefix: "refresh_token", pepper: []byte("other pepper")}
+	require.NotEqual(t, key, other.tokenKey(token), "ключ зависит от pepper")
+}
+
+func TestRefreshTokenDoesNotAddressServiceKeys(t *testing.T) {
+	s := &SessionManager{prefix: "refresh_token", pepper: []byte("pepper"), legacyTokenBytes: 32}
+	familyID := "0b7e5c9e-4a57-4f2d-9d4b-4a1f0f6a0c11"
+	userID := "6f1c2a3b-5d4e-4f60-8a7b-9c0d1e2f3a4b"
+
+	serviceKeys := []string{
+		s.familyKey(familyID),
+		s.userSessionsKey(userID),
+	}
+
+	for _, serviceKey := range serviceKeys {
+		token := strings.TrimPrefix(serviceKey, "refresh_token:")
+
+		_, ok := s.legacyTokenKey(token)
+		require.False(t, ok, token)
+		require.NotContains(t, serviceKeys, s.tokenKey(token), token)
+	}
+}
+
+func TestLegacyTokenKeyRequiresTokenFormat(t *testing.T) {
+	s := &SessionManager{prefix: "refresh_token", pepper: []byte("pepper"), legacyTokenBytes: 32}
+	token := base64.URLEncoding.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "другая длина", token: base64.URLEncoding.EncodeToString(make([]byte, 16))},
+		{name: "не base64url", token: strings.Repeat("!", len(token))},
+		{name: "стандартный base64", token: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\xff", 32)))},
+		{name: "перевод строки", token: token[:20] + "\n" + token[20:]},
+		{name: "без выравнивания", token: base64.RawURLEncoding.EncodeToString(make([]byte, 32))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := s.legacyTokenKey(tt.token)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	userID := "6f1c2a3b-5d4e-4f60-8a7b-9c0d1e2f3a4b"
+
+	record, err := parseRecord(`{"user_id":"` + userID + `","family_id":"family","generation":3}`)
+	require.NoError(t, err)
+	require.Equal(t, &refreshTokenRecord{UserID: userID, FamilyID: "family", Generation: 3}, record)
+
+	record, err = parseRecord(userID)
+	require.NoError(t, err, "запись старого формата")
+	require.Equal(t, &refreshTokenRecord{UserID: userID, Generation: 1}, record)
+
+	for _, value := range []string{
+		`{"user_id":"` + userID + `","generation":1}`,
+		`{"current_key":"refresh_token:rt:00","generation":1}`,
+		`[]`,
+		"{" + userID + "}",
+		"urn:uuid:" + userID,
+		"not a user id",
+	} {
+		_, err = parseRecord(value)
+		require.ErrorIs(t, err, domain.ErrNoSuchRefreshToken, value)
+	}
+}
@@ -1,20 +1,33 @@
 package storage
 
 import (
+	"context"
 	"crypto_analyzer_auth_service/internal/config/model"
 	"database/sql"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"os"
+	"sync"
 	"time"
 )
 
 var (
-	_ JWTManagerInterface     = (*JWTManager)(nil)
-	_ UsersStorageInterface   = (*UserPostgresStorage)(nil)
-	_ SessionManagerInterface = (*SessionManager)(nil)
+	_ JWTManagerInterface          = (*JWTManager)(nil)
+	_ UsersStorageInterface        = (*UserPostgresStorage)(nil)
+	_ SessionManagerInterface      = (*SessionManager)(nil)
+	_ PasswordHasherInterface      = (*PasswordHasher)(nil)
+	_ BreachCheckerInterface       = (*BreachedPasswordsFile)(nil)
+	_ OneTimeTokenStorageInterface = (*OneTimeTokenStorage)(nil)
+	_ LoginAttemptsInterface       = (*LoginAttempts)(nil)
+	_ EmailLoginStorageInterface   = (*EmailLoginStorage)(nil)
+	_ RateLimiterInterface         = (*RedisRateLimiter)(nil)
+	_ RateLimiterInterface         = (*MemoryRateLimiter)(nil)
 )
 
 type JWTManager struct {
-	secretKey         []byte
+	keys              *keyRing
+	algorithm         string
+	keyPath           string
 	accessTokenTTL    time.Duration
 	refreshTokenBytes int
 }
@@ -22,30 +35,154 @@ type JWTManager struct {
 type SessionManager struct {
 	client     *redis.Client
 	prefix     string
+	pepper     []byte
 	expiration time.Duration
+	// legacyTokenBytes — длина refresh токена в байтах, по ней узнаются токены старого формата
+	legacyTokenBytes int
+}
+
+type OneTimeTokenStorage struct {
+	client *redis.Client
+	prefix string
+	pepper []byte
+}
+
+type LoginAttempts struct {
+	client *redis.Client
+	prefix string
+	cfg    *model.LockoutConfig
+}
+
+type EmailLoginStorage struct {
+	client *redis.Client
+	prefix string
+	pepper []byte
+}
+
+// RedisRateLimiter хранит вёдра лимита запросов в Redis, при его недоступности — в fallback
+type RedisRateLimiter struct {
+	client   *redis.Client
+	script   *redis.Script
+	prefix   string
+	fallback RateLimiterInterface
+}
+
+// MemoryRateLimiter хранит вёдра лимита запросов в памяти процесса
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
 }
 
 type UserPostgresStorage struct {
 	DB *sql.DB
 }
 
-func NewJWTManager(cfg *model.JwtConfig) JWTManagerInterface {
-	return &JWTManager{
-		secretKey:         cfg.SecretKey,
+type PasswordHasher struct {
+	memory      uint32
+	time        uint32
+	parallelism uint8
+}
+
+// BreachedPasswordsFile ищет SHA-1 пароля в локальном файле Pwned Passwords в формате
+// "ordered by hash": строки вида <SHA-1 в hex>:<count>, отсортированные по хешу.
+// Файл не загружается в память, поиск — бинарный по смещениям в файле
+type BreachedPasswordsFile struct {
+	file *os.File
+	size int64
+}
+
+// NewJWTManager загружает ключ подписи и, если ключ лежит в файле, следит за его заменой до отмены ctx
+func NewJWTManager(ctx context.Context, cfg *model.JwtConfig) (JWTManagerInterface, error) {
+	material := cfg.SecretKey
+	if cfg.KeyPath != "" {
+		var err error
+		material, err = os.ReadFile(cfg.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+	}
+
+	key, err := newSigningKey(cfg.SigningAlgorithm, material)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init signing key: %w", err)
+	}
+
+	manager := &JWTManager{
+		keys:              newKeyRing(key),
+		algorithm:         cfg.SigningAlgorithm,
+		keyPath:           cfg.KeyPath,
 		accessTokenTTL:    cfg.AccessTokenTTL,
 		refreshTokenBytes: cfg.RefreshTokenBytes,
 	}
+
+	if cfg.KeyPath != "" && cfg.KeyWatchInterval > 0 {
+		go manager.watchKeyFile(ctx, cfg.KeyWatchInterval)
+	}
+
+	return manager, nil
 }
 
-func NewSessionManager(cfg *model.RedisConfig, client *redis.Client) SessionManagerInterface {
+func NewSessionManager(cfg *model.RedisConfig, jwtCfg *model.JwtConfig, client *redis.Client) SessionManagerInterface {
 
 	return &SessionManager{
-		client:     client,
-		prefix:     cfg.RefreshPrefix,
-		expiration: cfg.RefreshExpiration,
+		client:           client,
+		prefix:           cfg.RefreshPrefix,
+		pepper:           cfg.RefreshPepper,
+		expiration:       cfg.RefreshExpiration,
+		legacyTokenBytes: jwtCfg.RefreshTokenBytes,
+	}
+}
+
+func NewOneTimeTokenStorage(cfg *model.RedisConfig, client *redis.Client) OneTimeTokenStorageInterface {
+	return &OneTimeTokenStorage{
+		client: client,
+		prefix: cfg.OneTimeTokenPrefix,
+		pepper: cfg.RefreshPepper,
+	}
+}
+
+func NewLoginAttempts(redisCfg *model.RedisConfig, cfg *model.LockoutConfig, client *redis.Client) LoginAttemptsInterface {
+	return &LoginAttempts{
+		client: client,
+		prefix: redisCfg.LoginAttemptsPrefix,
+		cfg:    cfg,
+	}
+}
+
+func NewEmailLoginStorage(cfg *model.RedisConfig, client *redis.Client) EmailLoginStorageInterface {
+	return &EmailLoginStorage{
+		client: client,
+		prefix: cfg.EmailLoginPrefix,
+		pepper: cfg.RefreshPepper,
+	}
+}
+
+func NewRateLimiter(cfg *model.RedisConfig, client *redis.Client) RateLimiterInterface {
+	return &RedisRateLimiter{
+		client:   client,
+		script:   redis.NewScript(rateLimitScript),
+		prefix:   cfg.RateLimitPrefix,
+		fallback: NewMemoryRateLimiter(),
+	}
+}
+
+func NewMemoryRateLimiter() RateLimiterInterface {
+	return &MemoryRateLimiter{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
 	}
 }
 
 func NewUserStorage(db *sql.DB) UsersStorageInterface {
 	return &UserPostgresStorage{DB: db}
 }
+
+func NewPasswordHasher(cfg *model.PasswordConfig) PasswordHasherInterface {
+	return &PasswordHasher{
+		memory:      cfg.Argon2Memory,
+		time:        cfg.Argon2Time,
+		parallelism: cfg.Argon2Parallelism,
+	}
+}
@@ -0,0 +1,3 @@
+-- имена пользователей уникальны без учёта регистра;
+-- если в таблице уже есть дубликаты, их нужно разрешить до применения миграции
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_key ON users (LOWER(username));
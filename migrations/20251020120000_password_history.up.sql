@@ -0,0 +1,9 @@
+-- предыдущие хеши паролей пользователя, чтобы при смене пароля нельзя было вернуть недавний
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_uuid TEXT NOT NULL REFERENCES users (uuid) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS password_history_user_uuid_idx ON password_history (user_uuid, id DESC);
@@ -0,0 +1,2 @@
+-- время подтверждения email; NULL — email не подтверждён (в том числе у пользователей, созданных до миграции)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP NULL;
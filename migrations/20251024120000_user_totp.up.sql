@@ -0,0 +1,19 @@
+-- TOTP секрет пользователя, зашифрованный AES-256-GCM (MFA_TOTP_ENCRYPTION_KEY).
+-- confirmed_at IS NULL — подключение начато, но не подтверждено кодом; last_used_step защищает от повтора кода
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_uuid TEXT PRIMARY KEY REFERENCES users (uuid) ON DELETE CASCADE,
+    secret_encrypted BYTEA NOT NULL,
+    confirmed_at TIMESTAMP NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- одноразовые коды восстановления, хранятся только SHA-256 хеши
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_uuid TEXT NOT NULL REFERENCES users (uuid) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS recovery_codes_user_uuid_code_hash_key ON recovery_codes (user_uuid, code_hash);
@@ -0,0 +1,16 @@
+-- ключи WebAuthn (passkey) пользователей. id — id учётных данных от аутентификатора,
+-- public_key — COSE_Key; sign_count — последний принятый счётчик подписей, откат выдаёт клон ключа
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id BYTEA PRIMARY KEY,
+    user_uuid TEXT NOT NULL REFERENCES users (uuid) ON DELETE CASCADE,
+    public_key BYTEA NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid BYTEA NOT NULL,
+    attestation_format TEXT NOT NULL,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS webauthn_credentials_user_uuid_idx ON webauthn_credentials (user_uuid);
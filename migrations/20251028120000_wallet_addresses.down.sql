@@ -0,0 +1,13 @@
+-- аккаунты без email (вход только кошельком) не переживают возврат ограничения NOT NULL:
+-- откат останавливается, пока такие аккаунты не будут удалены или не получат email
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE email IS NULL) THEN
+        RAISE EXCEPTION 'cannot restore users.email NOT NULL: % users have no email',
+            (SELECT COUNT(*) FROM users WHERE email IS NULL);
+    END IF;
+END
+$$;
+
+DROP TABLE IF EXISTS wallet_addresses;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
@@ -0,0 +1,14 @@
+-- у аккаунта, созданного входом через кошелёк, нет email
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+
+-- адреса Ethereum пользователей. address — 0x + 40 hex в нижнем регистре; адрес принадлежит одному аккаунту,
+-- chain_id — сеть, в которой адрес подписал последнее сообщение входа
+CREATE TABLE IF NOT EXISTS wallet_addresses (
+    address TEXT PRIMARY KEY,
+    user_uuid TEXT NOT NULL REFERENCES users (uuid) ON DELETE CASCADE,
+    chain_id BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_addresses_user_uuid_idx ON wallet_addresses (user_uuid);
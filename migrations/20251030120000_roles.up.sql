@@ -0,0 +1,48 @@
+-- разрешения: имя вида ресурс:действие попадает в claim scope access токена
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+-- роли пользователей; granted_by — uuid администратора, NULL — назначено через ADMIN_API_TOKEN
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_uuid TEXT NOT NULL REFERENCES users (uuid) ON DELETE CASCADE,
+    role TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    granted_by TEXT NULL,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_uuid, role)
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_idx ON user_roles (role);
+
+-- разрешения, которые проверяет сам сервис, и роли по умолчанию
+INSERT INTO permissions (name, description) VALUES
+    ('accounts:unlock', 'Снятие блокировки входа'),
+    ('roles:read', 'Просмотр ролей и ролей пользователей'),
+    ('roles:manage', 'Назначение и снятие ролей')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Администратор'),
+    ('support', 'Поддержка')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'accounts:unlock'),
+    ('admin', 'roles:read'),
+    ('admin', 'roles:manage'),
+    ('support', 'accounts:unlock'),
+    ('support', 'roles:read')
+ON CONFLICT (role, permission) DO NOTHING;
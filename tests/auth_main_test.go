@@ -2,14 +2,18 @@ package tests
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto_analyzer_auth_service/internal/config"
 	"crypto_analyzer_auth_service/internal/config/model"
 	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/infrastructure/mailer"
 	"crypto_analyzer_auth_service/internal/infrastructure/postgres"
 	redisInit "crypto_analyzer_auth_service/internal/infrastructure/redis"
+	"crypto_analyzer_auth_service/internal/infrastructure/secretbox"
 	"crypto_analyzer_auth_service/internal/service"
 	"crypto_analyzer_auth_service/internal/storage"
 	"database/sql"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -21,9 +25,12 @@ import (
 var (
 	DB                 *sql.DB
 	redisClient        *redis.Client
-	userStorage        *storage.UserPostgresStorage
-	userSessionManager *storage.SessionManager
-	userJWTManager     *storage.JWTManager
+	userStorage        storage.UsersStorageInterface
+	userSessionManager storage.SessionManagerInterface
+	userJWTManager     storage.JWTManagerInterface
+	userPasswordHasher storage.PasswordHasherInterface
+	testMailer         *mailer.MemoryMailer
+	loginAttempts      storage.LoginAttemptsInterface
 	controllerService  *service.ControllerService
 )
 
@@ -58,15 +65,61 @@ func TestMain(m *testing.M) {
 		zap.L().Fatal("failed to init redis client", zap.Error(err))
 	}
 
-	userStorage, err = storage.NewUserStorage(DB)
+	userStorage = storage.NewUserStorage(DB)
+	userSessionManager = storage.NewSessionManager(configMain.RedisCfg, configMain.JwtCfg, redisClient)
+
+	userJWTManager, err = storage.NewJWTManager(context.Background(), configMain.JwtCfg)
+	if err != nil {
+		zap.L().Fatal("failed to init jwt manager", zap.Error(err))
+	}
+
+	userPasswordHasher = storage.NewPasswordHasher(configMain.PasswordCfg)
+
+	var breachChecker storage.BreachCheckerInterface
+	if configMain.PasswordCfg.BreachFilePath != "" {
+		breachChecker, err = storage.NewBreachedPasswordsFile(configMain.PasswordCfg.BreachFilePath)
+		if err != nil {
+			zap.L().Fatal("failed to init breached passwords checker", zap.Error(err))
+		}
+	}
+
+	passwordPolicy, err := service.NewPasswordPolicy(configMain.PasswordCfg, breachChecker)
 	if err != nil {
-		zap.L().Fatal("failed to init user storage", zap.Error(err))
+		zap.L().Fatal("failed to init password policy", zap.Error(err))
+	}
+
+	testMailer = mailer.NewMemoryMailer()
+	// неудачные входы под несуществующими логинами тоже считаются, а логины в тестах повторяются:
+	// свой префикс на каждый прогон, чтобы прошлые прогоны не включали задержки
+	configMain.RedisCfg.LoginAttemptsPrefix += ":test:" + uuid.NewString()
+	loginAttempts = storage.NewLoginAttempts(configMain.RedisCfg, configMain.LockoutCfg, redisClient)
+
+	// без MFA_TOTP_ENCRYPTION_KEY в .env.test секреты шифруются случайным ключом прогона
+	totpKey := configMain.MFACfg.EncryptionKey
+	if len(totpKey) == 0 {
+		totpKey = make([]byte, secretbox.KeySize)
+		if _, err = rand.Read(totpKey); err != nil {
+			zap.L().Fatal("failed to generate totp encryption key", zap.Error(err))
+		}
 	}
 
-	userSessionManager = storage.NewSessionManager(configMain.RedisCfg, redisClient)
-	userJWTManager = storage.NewJWTManager(configMain.JwtCfg)
+	totpBox, err := secretbox.New(totpKey)
+	if err != nil {
+		zap.L().Fatal("failed to init totp secret encryption", zap.Error(err))
+	}
 
-	controllerService = service.NewService(userStorage, userSessionManager, userJWTManager)
+	controllerService = service.NewService(service.Deps{
+		Storage:        userStorage,
+		Session:        userSessionManager,
+		JWTManager:     userJWTManager,
+		Hasher:         userPasswordHasher,
+		Tokens:         storage.NewOneTimeTokenStorage(configMain.RedisCfg, redisClient),
+		Attempts:       loginAttempts,
+		EmailLogins:    storage.NewEmailLoginStorage(configMain.RedisCfg, redisClient),
+		Mailer:         testMailer,
+		SecretBox:      totpBox,
+		PasswordPolicy: passwordPolicy,
+	}, configMain)
 
 	code := m.Run()
 	os.Exit(code)
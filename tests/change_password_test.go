@@ -0,0 +1,108 @@
+package tests
+
+import (
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/service"
+	"errors"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"testing"
+)
+
+func changePasswordRequest(currentPassword, newPassword string) *pb.ChangePasswordRequest {
+	return &pb.ChangePasswordRequest{
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	}
+}
+
+func TestChangePassword(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Change password and revoke other sessions", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+		newPassword := "Changed98765_pass"
+
+		var accessToken, registerRefreshToken, otherRefreshToken string
+
+		t.WithNewStep("Register and login from another device", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			accessToken = resp.Token
+			registerRefreshToken = resp.RefreshToken
+
+			loginResp, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err, "Отсутствие ошибки, данные корректны")
+			otherRefreshToken = loginResp.RefreshToken
+		})
+
+		t.WithNewStep("Change password with wrong current password", func(sCtx provider.StepCtx) {
+			_, err := controllerService.ChangePassword(authorizedContext(ctx, accessToken), changePasswordRequest("Wrong12321_new", newPassword))
+
+			sCtx.Assert().ErrorIs(err, domain.ErrWrongCurrentPassword, "Неверный текущий пароль")
+		})
+
+		t.WithNewStep("Change password to the current one", func(sCtx provider.StepCtx) {
+			_, err := controllerService.ChangePassword(authorizedContext(ctx, accessToken), changePasswordRequest(password, password))
+
+			var policyErr *domain.PasswordPolicyError
+			sCtx.Require().True(errors.As(err, &policyErr), "Ошибка политики паролей")
+			sCtx.Assert().Equal(service.ViolationReused, policyErr.Violations[0].Code, "Текущий пароль нельзя задать снова")
+		})
+
+		t.WithNewStep("Change password", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.ChangePassword(authorizedContext(ctx, accessToken), changePasswordRequest(password, newPassword))
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, текущий пароль верный")
+			sCtx.Assert().EqualValues(1, resp.RevokedSessions, "Отозвана сессия второго устройства")
+
+			_, err = controllerService.Refresh(ctx, refreshRequest(otherRefreshToken))
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchRefreshToken, "Refresh токен другой сессии не работает")
+
+			_, err = verifyRequest(accessToken)
+			sCtx.Assert().NoError(err, "Access токен текущей сессии работает")
+
+			_, err = controllerService.Refresh(ctx, refreshRequest(registerRefreshToken))
+			sCtx.Assert().NoError(err, "Refresh токен текущей сессии работает")
+		})
+
+		t.WithNewStep("Login with new password", func(sCtx provider.StepCtx) {
+			_, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidCredentials, "Старый пароль не подходит")
+
+			_, err = controllerService.Login(ctx, loginRequest(username, "", newPassword))
+			sCtx.Assert().NoError(err, "Новый пароль подходит")
+		})
+
+		t.WithNewStep("Change password back to the previous one", func(sCtx provider.StepCtx) {
+			_, err := controllerService.ChangePassword(authorizedContext(ctx, accessToken), changePasswordRequest(newPassword, password))
+
+			var policyErr *domain.PasswordPolicyError
+			sCtx.Require().True(errors.As(err, &policyErr), "Ошибка политики паролей")
+			sCtx.Assert().Equal(service.ViolationReused, policyErr.Violations[0].Code, "Пароль из истории нельзя задать снова")
+		})
+	})
+}
+
+func TestChangePasswordWithoutAccessToken(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Change password without access token", func(t provider.T) {
+		t.WithNewStep("Change password without access token", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.ChangePassword(ctx, changePasswordRequest("New12321_new", "Changed98765_pass"))
+
+			sCtx.Assert().ErrorIs(err, domain.ErrNoAccessToken)
+			sCtx.Assert().Nil(resp, "Response nil")
+		})
+	})
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/service"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var mailCodeRegexp = regexp.MustCompile(`Your code: ([0-9]+)`)
+
+// emailLoginTestService — сервис с коротким лимитом попыток; пороги блокировок высокие,
+// чтобы неверные коды сценариев не включали задержки входа
+func emailLoginTestService() *service.ControllerService {
+	policy := model.LockoutPolicy{BackoffAfter: 100, BaseDelay: time.Second, MaxDelay: time.Second, LockoutAfter: 100,
+		LockoutDuration: time.Minute}
+
+	s := lockoutTestService(policy, policy)
+	s.EmailLoginCfg = &model.EmailLoginConfig{CodeTTL: time.Minute, MaxAttempts: 3, LinkSecret: []byte("test-link-secret")}
+
+	return s
+}
+
+// startEmailLogin запрашивает вход по email и возвращает код и токен ссылки из письма
+func startEmailLogin(ctx context.Context, sCtx provider.StepCtx, s *service.ControllerService, email string) (string, string) {
+	sent := testMailer.Count(email)
+
+	_, err := s.StartEmailLogin(ctx, &pb.StartEmailLoginRequest{Email: email})
+	sCtx.Require().NoError(err)
+
+	msg, ok := testMailer.WaitFor(email, sent+1, 5*time.Second)
+	sCtx.Require().True(ok, "Письмо со входом отправлено")
+
+	match := mailCodeRegexp.FindStringSubmatch(msg.Body)
+	sCtx.Require().NotNil(match, "Письмо содержит код")
+
+	token := tokenFromMail(msg.Body)
+	sCtx.Require().NotEmpty(token, "Письмо содержит ссылку")
+
+	return match[1], token
+}
+
+func TestEmailLogin(tt *testing.T) {
+	ctx := newTestContext(tt)
+	s := emailLoginTestService()
+
+	runner.Run(tt, "Log in without password by code and by link from email", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := s.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+		})
+
+		t.WithNewStep("Log in by code", func(sCtx provider.StepCtx) {
+			code, _ := startEmailLogin(ctx, sCtx, s, email)
+
+			wrongCode := "000000"
+			if code == wrongCode {
+				wrongCode = "000001"
+			}
+
+			_, err := s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Email: email, Code: wrongCode})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginCode, "Неверный код")
+
+			resp, err := s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Email: email, Code: code})
+			sCtx.Require().NoError(err, "Вход по коду")
+			sCtx.Assert().NotEmpty(resp.Token)
+			sCtx.Assert().NotEmpty(resp.RefreshToken)
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().True(verifyResp.EmailVerified, "Вход по письму подтверждает email")
+
+			_, err = s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Email: email, Code: code})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginCode, "Код одноразовый")
+		})
+
+		t.WithNewStep("Log in by link", func(sCtx provider.StepCtx) {
+			code, token := startEmailLogin(ctx, sCtx, s, email)
+
+			_, err := s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Token: token + "x"})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginToken, "Подпись ссылки не сходится")
+
+			resp, err := s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Token: token})
+			sCtx.Require().NoError(err, "Вход по ссылке")
+			sCtx.Assert().NotEmpty(resp.Token)
+
+			_, err = s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Token: token})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginToken, "Ссылка одноразовая")
+
+			_, err = s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Email: email, Code: code})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginCode, "Ссылка гасит и код")
+		})
+
+		t.WithNewStep("New request replaces previous one", func(sCtx provider.StepCtx) {
+			_, oldToken := startEmailLogin(ctx, sCtx, s, email)
+			_, newToken := startEmailLogin(ctx, sCtx, s, email)
+
+			_, err := s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Token: oldToken})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginToken, "Старая ссылка больше не работает")
+
+			_, err = s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Token: newToken})
+			sCtx.Assert().NoError(err, "Новая ссылка работает")
+		})
+
+		t.WithNewStep("Attempt limit", func(sCtx provider.StepCtx) {
+			code, _ := startEmailLogin(ctx, sCtx, s, email)
+
+			wrongCode := "000000"
+			if code == wrongCode {
+				wrongCode = "000001"
+			}
+
+			for i := 0; i < s.EmailLoginCfg.MaxAttempts; i++ {
+				_, err := s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Email: email, Code: wrongCode})
+				sCtx.Require().ErrorIs(err, domain.ErrInvalidEmailLoginCode)
+			}
+
+			_, err := s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Email: email, Code: code})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginCode, "После исчерпания попыток верный код не принимается")
+		})
+	})
+}
+
+func TestEmailLoginUnknownEmail(tt *testing.T) {
+	ctx := newTestContext(tt)
+	s := emailLoginTestService()
+
+	runner.Run(tt, "Email login for unregistered email", func(t provider.T) {
+		email := "unknown-email-login@gmail.com"
+
+		t.WithNewStep("Start and complete", func(sCtx provider.StepCtx) {
+			sent := testMailer.Count(email)
+
+			resp, err := s.StartEmailLogin(ctx, &pb.StartEmailLoginRequest{Email: email})
+			sCtx.Require().NoError(err, "Ответ как для зарегистрированного email")
+			sCtx.Assert().NotNil(resp)
+
+			_, ok := testMailer.WaitFor(email, sent+1, 200*time.Millisecond)
+			sCtx.Assert().False(ok, "Письмо не отправляется")
+
+			_, err = s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{Email: email, Code: "123456"})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidEmailLoginCode)
+
+			_, err = s.CompleteEmailLogin(ctx, &pb.CompleteEmailLoginRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoEmailLoginCode)
+		})
+	})
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"testing"
+	"time"
+)
+
+func TestEmailVerification(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Verify email after registration", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		var token string
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+			sent := testMailer.Count(email)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			sCtx.Assert().False(resp.EmailVerificationRequired, "В режиме claim токены выдаются сразу")
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err, "Отсутствие ошибки, access token valid")
+			sCtx.Assert().False(verifyResp.EmailVerified, "Email ещё не подтверждён")
+
+			msg, ok := testMailer.WaitFor(email, sent+1, 5*time.Second)
+			sCtx.Require().True(ok, "Письмо подтверждения отправлено")
+
+			token = tokenFromMail(msg.Body)
+			sCtx.Require().NotEmpty(token, "Ссылка содержит токен")
+		})
+
+		t.WithNewStep("Verify email", func(sCtx provider.StepCtx) {
+			_, err := controllerService.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token})
+			sCtx.Require().NoError(err, "Отсутствие ошибки, токен действителен")
+
+			_, err = controllerService.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidVerification, "Токен одноразовый")
+		})
+
+		t.WithNewStep("Login after verification", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err, "Отсутствие ошибки, данные корректны")
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err, "Отсутствие ошибки, access token valid")
+			sCtx.Assert().True(verifyResp.EmailVerified, "Claim email_verified в новом токене")
+		})
+	})
+}
+
+func TestEmailVerificationBlocksLogin(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Block login until email is verified", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		blockingService := *controllerService
+		blockingService.EmailCfg = &model.EmailVerificationConfig{Mode: model.EmailVerificationBlock, TokenTTL: time.Hour}
+
+		var token string
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+			sent := testMailer.Count(email)
+
+			resp, err := blockingService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, nil)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			sCtx.Assert().True(resp.EmailVerificationRequired, "Нужно подтвердить email")
+			sCtx.Assert().Empty(resp.Token, "Access токен не выдан")
+			sCtx.Assert().Empty(resp.RefreshToken, "Refresh токен не выдан")
+
+			msg, ok := testMailer.WaitFor(email, sent+1, 5*time.Second)
+			sCtx.Require().True(ok, "Письмо подтверждения отправлено")
+			token = tokenFromMail(msg.Body)
+		})
+
+		t.WithNewStep("Login before verification", func(sCtx provider.StepCtx) {
+			_, err := blockingService.Login(ctx, loginRequest(username, "", password))
+
+			sCtx.Assert().ErrorIs(err, domain.ErrEmailNotVerified, "Вход заблокирован до подтверждения")
+		})
+
+		t.WithNewStep("Login after verification", func(sCtx provider.StepCtx) {
+			_, err := blockingService.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: token})
+			sCtx.Require().NoError(err, "Отсутствие ошибки, токен действителен")
+
+			resp, err := blockingService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err, "Вход разрешён после подтверждения")
+
+			t.Cleanup(func() {
+				_ = userSessionManager.DeleteRefreshToken(ctx, resp.RefreshToken)
+			})
+		})
+	})
+}
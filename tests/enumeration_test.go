@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/controller"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/logger"
+	"crypto_analyzer_auth_service/internal/storage"
+	"github.com/google/uuid"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"sort"
+	"testing"
+	"time"
+)
+
+// medianLoginDuration — медиана времени n неудачных входов
+func medianLoginDuration(n int, login func() error) time.Duration {
+	durations := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		_ = login()
+		durations = append(durations, time.Since(start))
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	return durations[n/2]
+}
+
+func TestLoginResponsesIndistinguishable(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Unknown user and wrong password give the same response", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		// без задержек после неудач: иначе ответы различались бы блокировкой, а не существованием аккаунта
+		noLockoutService := *controllerService
+		noLockoutService.Attempts = storage.NewLoginAttempts(
+			&model.RedisConfig{LoginAttemptsPrefix: "login_attempts_test:" + uuid.NewString()},
+			&model.LockoutConfig{Window: time.Minute},
+			redisClient,
+		)
+		authController := controller.NewController(&noLockoutService, logger.Log)
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := noLockoutService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+		})
+
+		t.WithNewStep("Compare gRPC statuses", func(sCtx provider.StepCtx) {
+			_, wrongPasswordErr := authController.Login(ctx, loginRequest("", email, "Wrong12321_new"))
+			_, unknownUserErr := authController.Login(ctx, loginRequest("", "unknown25@gmail.com", "Wrong12321_new"))
+
+			wrongPassword, ok := status.FromError(wrongPasswordErr)
+			sCtx.Require().True(ok, "Неверный пароль — gRPC статус")
+			unknownUser, ok := status.FromError(unknownUserErr)
+			sCtx.Require().True(ok, "Несуществующий пользователь — gRPC статус")
+
+			sCtx.Assert().True(proto.Equal(wrongPassword.Proto(), unknownUser.Proto()), "Статусы совпадают вместе с деталями")
+			sCtx.Assert().Equal(domain.ErrInvalidCredentials.Error(), unknownUser.Message())
+		})
+
+		t.WithNewStep("Compare response time", func(sCtx provider.StepCtx) {
+			wrongPassword := medianLoginDuration(5, func() error {
+				_, err := noLockoutService.Login(ctx, loginRequest("", email, "Wrong12321_new"))
+				return err
+			})
+			unknownUser := medianLoginDuration(5, func() error {
+				_, err := noLockoutService.Login(ctx, loginRequest("", "unknown25@gmail.com", "Wrong12321_new"))
+				return err
+			})
+
+			// без сверки с фиктивным хешем несуществующий пользователь отвечал бы на порядки быстрее
+			sCtx.Assert().Greater(unknownUser, wrongPassword/2, "Несуществующий пользователь отвечает не быстрее")
+		})
+	})
+}
+
+func TestRegisterTakenEmailIndistinguishable(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Register with taken email in block mode looks like a new registration", func(t provider.T) {
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		blockingService := *controllerService
+		blockingService.EmailCfg = &model.EmailVerificationConfig{Mode: model.EmailVerificationBlock, TokenTTL: time.Hour}
+
+		t.WithNewStep("Register twice with the same email", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, nil)
+			})
+
+			sent := testMailer.Count(email)
+
+			first, err := blockingService.Register(ctx, registerRequest("Shellshocker25", email, password))
+			sCtx.Require().NoError(err, "Первая регистрация")
+
+			_, ok := testMailer.WaitFor(email, sent+1, 5*time.Second)
+			sCtx.Require().True(ok, "Письмо подтверждения отправлено")
+			sent++
+
+			second, err := blockingService.Register(ctx, registerRequest("Shellshocker26", email, password))
+			sCtx.Require().NoError(err, "Занятый email не выдаётся ошибкой")
+			sCtx.Assert().True(proto.Equal(first, second), "Ответ совпадает с ответом на новую регистрацию")
+
+			msg, ok := testMailer.WaitFor(email, sent+1, 5*time.Second)
+			sCtx.Require().True(ok, "Владельцу адреса отправлено письмо")
+			sCtx.Assert().Equal("You already have an account", msg.Subject)
+		})
+
+		t.WithNewStep("Taken username is still reported", func(sCtx provider.StepCtx) {
+			_, err := blockingService.Register(ctx, registerRequest("Shellshocker25", "newemail26@gmail.com", password))
+			sCtx.Assert().ErrorIs(err, domain.ErrUsernameAlreadyTaken)
+		})
+	})
+}
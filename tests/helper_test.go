@@ -61,3 +61,8 @@ func verifyRequest(accessToken string) (*auth.VerifyResponse, error) {
 	ctx := metadata.NewIncomingContext(context.Background(), md)
 	return controllerService.Verify(ctx, &pb.VerifyRequest{})
 }
+
+// authorizedContext — контекст входящего запроса с access токеном вызывающего
+func authorizedContext(ctx context.Context, accessToken string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", accessToken))
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/service"
+	"crypto_analyzer_auth_service/internal/storage"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+	"time"
+)
+
+const testAdminToken = "test-admin-token"
+
+// lockoutTestService — сервис с короткими порогами неудачных входов и токеном администратора
+func lockoutTestService(account, ip model.LockoutPolicy) *service.ControllerService {
+	s := *controllerService
+	s.Attempts = storage.NewLoginAttempts(
+		&model.RedisConfig{LoginAttemptsPrefix: "login_attempts_test:" + uuid.NewString()},
+		&model.LockoutConfig{Account: account, IP: ip, Window: time.Minute},
+		redisClient,
+	)
+	s.AdminCfg = &model.AdminConfig{Token: testAdminToken}
+	s.ProxyCfg = &model.ProxyConfig{TrustedHops: 0}
+
+	return &s
+}
+
+func adminContext(ctx context.Context, token string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs("x-admin-token", token))
+}
+
+// authorizeCall проверяет требование метода так же, как AuthorizationInterceptor, и возвращает контекст,
+// с которым вызывается сам метод сервиса
+func authorizeCall(ctx context.Context, s *service.ControllerService, method string) (context.Context, error) {
+	return s.Authorize(ctx, service.MethodPermissions[method])
+}
+
+// unlockAccount — UnlockAccount с metadata x-admin-token, равной token
+func unlockAccount(ctx context.Context, s *service.ControllerService, token string,
+	req *pb.UnlockAccountRequest) (*pb.UnlockAccountResponse, error) {
+	callCtx, err := authorizeCall(adminContext(ctx, token), s, "UnlockAccount")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UnlockAccount(callCtx, req)
+}
+
+func TestLoginLockout(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Lock account after failed logins and unlock by admin", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		lockoutService := lockoutTestService(
+			model.LockoutPolicy{BackoffAfter: 100, LockoutAfter: 3, LockoutDuration: time.Minute},
+			model.LockoutPolicy{},
+		)
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := lockoutService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+		})
+
+		t.WithNewStep("Fail login until lockout", func(sCtx provider.StepCtx) {
+			for i := 0; i < 3; i++ {
+				_, err := lockoutService.Login(ctx, loginRequest(username, "", "Wrong12321_new"))
+				sCtx.Require().ErrorIs(err, domain.ErrInvalidCredentials, "Неверный пароль до блокировки")
+			}
+		})
+
+		t.WithNewStep("Login with correct password while locked", func(sCtx provider.StepCtx) {
+			_, err := lockoutService.Login(ctx, loginRequest(username, "", password))
+
+			var blockedErr *domain.LoginBlockedError
+			sCtx.Require().True(errors.As(err, &blockedErr), "Вход заблокирован")
+			sCtx.Assert().True(blockedErr.Locked, "Блокировка аккаунта, а не задержка")
+			sCtx.Assert().Greater(blockedErr.RetryAfter, time.Duration(0), "Известно, когда повторить")
+
+			_, err = lockoutService.Login(ctx, loginRequest(username, "", "Wrong12321_new"))
+			sCtx.Assert().ErrorIs(err, domain.ErrAccountLocked, "Ответ не зависит от пароля")
+		})
+
+		t.WithNewStep("Unlock without admin token", func(sCtx provider.StepCtx) {
+			_, err := unlockAccount(ctx, lockoutService, "wrong", &pb.UnlockAccountRequest{Email: email})
+
+			sCtx.Assert().ErrorIs(err, domain.ErrAdminRequired)
+		})
+
+		t.WithNewStep("Unlock by admin", func(sCtx provider.StepCtx) {
+			_, err := unlockAccount(ctx, lockoutService, testAdminToken, &pb.UnlockAccountRequest{Email: email})
+			sCtx.Require().NoError(err, "Администратор снял блокировку")
+
+			resp, err := lockoutService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err, "Вход после разблокировки")
+
+			t.Cleanup(func() {
+				_ = userSessionManager.DeleteRefreshToken(ctx, resp.RefreshToken)
+			})
+		})
+	})
+}
+
+func TestLoginBackoff(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Delay login after failed attempts", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		lockoutService := lockoutTestService(
+			model.LockoutPolicy{BackoffAfter: 1, BaseDelay: 30 * time.Second, MaxDelay: time.Minute},
+			model.LockoutPolicy{},
+		)
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := lockoutService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+		})
+
+		t.WithNewStep("Login after two failures", func(sCtx provider.StepCtx) {
+			for i := 0; i < 2; i++ {
+				_, err := lockoutService.Login(ctx, loginRequest(username, "", "Wrong12321_new"))
+				sCtx.Require().ErrorIs(err, domain.ErrInvalidCredentials, "Неверный пароль")
+			}
+
+			_, err := lockoutService.Login(ctx, loginRequest(username, "", password))
+
+			var blockedErr *domain.LoginBlockedError
+			sCtx.Require().True(errors.As(err, &blockedErr), "Вход отложен")
+			sCtx.Assert().False(blockedErr.Locked, "Задержка, а не блокировка")
+			sCtx.Assert().ErrorIs(err, domain.ErrTooManyLoginAttempts)
+		})
+	})
+}
+
+func TestLoginIPLockout(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Lock source address after failed logins", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+		ip := "203.0.113.25"
+
+		ipCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", ip))
+
+		lockoutService := lockoutTestService(
+			model.LockoutPolicy{},
+			model.LockoutPolicy{BackoffAfter: 100, LockoutAfter: 2, LockoutDuration: time.Minute},
+		)
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := lockoutService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+				_, _ = unlockAccount(ctx, lockoutService, testAdminToken, &pb.UnlockAccountRequest{Ip: ip})
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+		})
+
+		t.WithNewStep("Fail logins for unknown users from one address", func(sCtx provider.StepCtx) {
+			for _, unknown := range []string{"unknownUser1", "unknownUser2"} {
+				_, err := lockoutService.Login(ipCtx, loginRequest(unknown, "", password))
+				sCtx.Require().ErrorIs(err, domain.ErrInvalidCredentials, "Пользователь не найден")
+			}
+
+			_, err := lockoutService.Login(ipCtx, loginRequest(username, "", password))
+			sCtx.Assert().ErrorIs(err, domain.ErrAccountLocked, "Адрес заблокирован и для существующего аккаунта")
+		})
+
+		t.WithNewStep("Spoofed x-forwarded-for does not bypass lockout", func(sCtx provider.StepCtx) {
+			spoofedCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "198.51.100.7, "+ip))
+			_, err := lockoutService.Login(spoofedCtx, loginRequest(username, "", password))
+			sCtx.Assert().ErrorIs(err, domain.ErrAccountLocked, "Значения, подставленные клиентом перед адресом шлюза, не учитываются")
+
+			peerCtx := peer.NewContext(metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "198.51.100.7")),
+				&peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000}})
+			_, err = lockoutService.Login(peerCtx, loginRequest(username, "", password))
+			sCtx.Assert().ErrorIs(err, domain.ErrAccountLocked, "У прямого gRPC клиента адрес берётся из соединения")
+		})
+
+		t.WithNewStep("Unlock address by admin", func(sCtx provider.StepCtx) {
+			_, err := unlockAccount(ctx, lockoutService, testAdminToken, &pb.UnlockAccountRequest{Ip: ip})
+			sCtx.Require().NoError(err, "Администратор снял блокировку адреса")
+
+			resp, err := lockoutService.Login(ipCtx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err, "Вход после разблокировки")
+
+			t.Cleanup(func() {
+				_ = userSessionManager.DeleteRefreshToken(ctx, resp.RefreshToken)
+			})
+		})
+	})
+}
@@ -2,24 +2,43 @@ package tests
 
 import (
 	"crypto_analyzer_auth_service/internal/domain"
+	"github.com/google/uuid"
 	"github.com/ozontech/allure-go/pkg/framework/provider"
 	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"testing"
 )
 
 func TestLoginWeakPassword(tt *testing.T) {
 	ctx := newTestContext(tt)
 
-	runner.Run(tt, "Login with weak password", func(t provider.T) {
-		t.WithNewStep("Login with weak password", func(sCtx provider.StepCtx) {
-			username := "Shellshocker"
-			email := "newemail@gmail.com"
-			password := "123123123"
+	runner.Run(tt, "Login with legacy password that fails the current policy", func(t provider.T) {
+		username := "Shellshocker"
+		email := "newemail@gmail.com"
+		password := "123123123"
+
+		t.WithNewStep("Create user with weak legacy password", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			hash, err := userPasswordHasher.Hash(password)
+			sCtx.Require().NoError(err)
+
+			_, err = DB.ExecContext(ctx,
+				`INSERT INTO users (uuid, username, email, password_hash) VALUES ($1, $2, $3, $4)`,
+				uuid.New().String(), username, email, hash)
+			sCtx.Require().NoError(err, "Пользователь со старым паролем создан")
 
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, nil)
+			})
+		})
+
+		t.WithNewStep("Login with weak password", func(sCtx provider.StepCtx) {
 			resp, err := controllerService.Login(ctx, loginRequest(username, email, password))
 
-			sCtx.Assert().ErrorIs(err, domain.ErrWeakPassword)
-			sCtx.Assert().Nil(resp, "Response nil, вход не выполнен")
+			sCtx.Assert().NoError(err, "Политика паролей при входе не применяется")
+			sCtx.Assert().NotNil(resp, "Response не nil, вход выполнен")
 		})
 	})
 }
@@ -201,7 +220,7 @@ func TestLoginNoEmailInvalidUsername(tt *testing.T) {
 
 			resp, err := controllerService.Login(ctx, loginRequest(username, email, password))
 
-			sCtx.Assert().ErrorIs(err, domain.ErrNilUser)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidCredentials)
 			sCtx.Assert().Nil(resp, "Response nil, вход не выполнен")
 		})
 	})
@@ -237,8 +256,89 @@ func TestLoginNoUsernameInvalidEmail(tt *testing.T) {
 
 			resp, err := controllerService.Login(ctx, loginRequest(username, email, password))
 
-			sCtx.Assert().ErrorIs(err, domain.ErrNilUser)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidCredentials)
+			sCtx.Assert().Nil(resp, "Response nil, вход не выполнен")
+		})
+	})
+}
+
+func TestLoginUsernameAndEmailOfDifferentUsers(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Login with username and email of different accounts", func(t provider.T) {
+		t.WithNewStep("Register two users", func(sCtx provider.StepCtx) {
+			password := "New12321_new"
+
+			cleanUserByEmail(ctx, tt, "newemail@gmail.com", nil)
+			cleanUserByEmail(ctx, tt, "newemail25@gmail.com", nil)
+
+			first, err := controllerService.Register(ctx, registerRequest("Shellshocker", "newemail@gmail.com", password))
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, "newemail@gmail.com", first)
+			})
+			sCtx.Require().NoError(err, "Отсутствие ошибки, все данные корректны")
+
+			second, err := controllerService.Register(ctx, registerRequest("Shellshocker25", "newemail25@gmail.com", password))
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, "newemail25@gmail.com", second)
+			})
+			sCtx.Require().NoError(err, "Отсутствие ошибки, все данные корректны")
+		})
+
+		t.WithNewStep("Login with username of first and email of second user", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Login(ctx, loginRequest("Shellshocker", "newemail25@gmail.com", "New12321_new"))
+
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidCredentials)
 			sCtx.Assert().Nil(resp, "Response nil, вход не выполнен")
 		})
+
+		t.WithNewStep("Login with username in another case", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Login(ctx, loginRequest("shellSHOCKER", "newemail@gmail.com", "New12321_new"))
+
+			sCtx.Assert().NoError(err, "Отсутствие ошибки, username без учёта регистра")
+			sCtx.Assert().NotNil(resp, "Response не nil, вход выполнен")
+		})
+	})
+}
+
+func TestLoginUpgradesBcryptHash(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Login rehashes legacy bcrypt password", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		t.WithNewStep("Create user with bcrypt hash", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			sCtx.Require().NoError(err)
+
+			_, err = DB.ExecContext(ctx,
+				`INSERT INTO users (uuid, username, email, password_hash) VALUES ($1, $2, $3, $4)`,
+				uuid.New().String(), username, email, string(hash))
+			sCtx.Require().NoError(err, "Пользователь со старым хешем создан")
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, nil)
+			})
+		})
+
+		t.WithNewStep("Login and check hash upgraded", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Login(ctx, loginRequest("", email, password))
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, bcrypt хеш принимается")
+			sCtx.Require().NotNil(resp, "Response не nil, вход выполнен")
+
+			var passwordHash string
+			err = DB.QueryRowContext(ctx, `SELECT password_hash FROM users WHERE email = $1`, email).Scan(&passwordHash)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().True(strings.HasPrefix(passwordHash, "$argon2id$"), "Хеш пересчитан в argon2id")
+
+			resp, err = controllerService.Login(ctx, loginRequest("", email, password))
+			sCtx.Assert().NoError(err, "Вход с новым хешем")
+			sCtx.Assert().NotNil(resp)
+		})
 	})
 }
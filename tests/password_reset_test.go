@@ -0,0 +1,129 @@
+package tests
+
+import (
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var mailTokenRegexp = regexp.MustCompile(`token=([^\s&]+)`)
+
+// tokenFromMail достаёт токен из ссылки в письме
+func tokenFromMail(body string) string {
+	match := mailTokenRegexp.FindStringSubmatch(body)
+	if match == nil {
+		return ""
+	}
+
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		return ""
+	}
+
+	return token
+}
+
+func TestPasswordReset(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Reset forgotten password", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+		newPassword := "Reset98765_pass"
+
+		var refreshToken string
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			refreshToken = resp.RefreshToken
+		})
+
+		var token string
+
+		t.WithNewStep("Request password reset", func(sCtx provider.StepCtx) {
+			sent := testMailer.Count(email)
+
+			resp, err := controllerService.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Email: email})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, email корректный")
+			sCtx.Require().NotNil(resp, "Response не nil")
+
+			msg, ok := testMailer.WaitFor(email, sent+1, 5*time.Second)
+			sCtx.Require().True(ok, "Письмо со ссылкой отправлено")
+
+			token = tokenFromMail(msg.Body)
+			sCtx.Require().NotEmpty(token, "Ссылка содержит токен")
+		})
+
+		t.WithNewStep("Confirm reset with weak password", func(sCtx provider.StepCtx) {
+			_, err := controllerService.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: token, NewPassword: "weak"})
+
+			sCtx.Assert().ErrorIs(err, domain.ErrWeakPassword, "Слабый пароль отклонён, токен не погашен")
+		})
+
+		t.WithNewStep("Confirm reset", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: token, NewPassword: newPassword})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, токен действителен")
+			sCtx.Assert().EqualValues(1, resp.RevokedSessions, "Сессия регистрации отозвана")
+
+			_, err = controllerService.Refresh(ctx, refreshRequest(refreshToken))
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchRefreshToken, "Refresh токен отозванной сессии не работает")
+
+			_, err = controllerService.Login(ctx, loginRequest(username, "", newPassword))
+			sCtx.Assert().NoError(err, "Новый пароль подходит")
+		})
+
+		t.WithNewStep("Reuse reset token", func(sCtx provider.StepCtx) {
+			_, err := controllerService.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: token, NewPassword: "Another98765_pass"})
+
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidResetToken, "Токен одноразовый")
+		})
+	})
+}
+
+func TestPasswordResetUnknownEmail(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Request password reset for unknown email", func(t provider.T) {
+		email := "unknown25@gmail.com"
+
+		t.WithNewStep("Request password reset", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Email: email})
+
+			sCtx.Require().NoError(err, "Ответ такой же, как для зарегистрированного email")
+			sCtx.Require().NotNil(resp, "Response не nil")
+
+			_, ok := testMailer.WaitFor(email, 1, 200*time.Millisecond)
+			sCtx.Assert().False(ok, "Письмо не отправлено")
+		})
+	})
+}
+
+func TestPasswordResetInvalidToken(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Confirm password reset with unknown token", func(t provider.T) {
+		t.WithNewStep("Confirm reset", func(sCtx provider.StepCtx) {
+			_, err := controllerService.ConfirmPasswordReset(ctx, &pb.ConfirmPasswordResetRequest{Token: "unknown", NewPassword: "Reset98765_pass"})
+
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidResetToken)
+		})
+	})
+}
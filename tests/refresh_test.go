@@ -1,6 +1,7 @@
 package tests
 
 import (
+	pb "crypto_analyzer_auth_service/gen/go"
 	"crypto_analyzer_auth_service/internal/domain"
 	"github.com/ozontech/allure-go/pkg/framework/provider"
 	"github.com/ozontech/allure-go/pkg/framework/runner"
@@ -184,3 +185,108 @@ func TestRefreshWithoutRefreshToken(tt *testing.T) {
 		})
 	})
 }
+
+func TestRefreshReusedRefreshToken(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Refresh with already rotated refresh token", func(t provider.T) {
+		var refreshToken, rotatedRefreshToken string
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			username := "Shellshocker25"
+			email := "newemail25@gmail.com"
+			password := "New12321_new"
+
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			sCtx.Require().NotNil(resp, "Response не nil, регистрация успешна")
+			sCtx.Require().NotEmpty(resp.RefreshToken, "Наличие refresh токена")
+
+			refreshToken = resp.RefreshToken
+		})
+
+		t.WithNewStep("Refresh with valid refresh token", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Refresh(ctx, refreshRequest(refreshToken))
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, refresh token valid")
+			sCtx.Require().NotNil(resp, "Response не nil, refresh выполнен")
+
+			rotatedRefreshToken = resp.RefreshToken
+		})
+
+		t.WithNewStep("Refresh with already rotated refresh token", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Refresh(ctx, refreshRequest(refreshToken))
+
+			sCtx.Assert().ErrorIs(err, domain.ErrRefreshTokenReused)
+			sCtx.Assert().Nil(resp, "Response nil, повторное использование обнаружено")
+		})
+
+		t.WithNewStep("Refresh with token from revoked family", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Refresh(ctx, refreshRequest(rotatedRefreshToken))
+
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchRefreshToken)
+			sCtx.Assert().Nil(resp, "Response nil, семейство отозвано")
+		})
+	})
+}
+
+func TestRefreshServiceKeysAreNotRefreshTokens(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Refresh with Redis service key names as refresh token", func(t provider.T) {
+		var refreshToken, userID, sessionID string
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			username := "Shellshocker25"
+			email := "newemail25@gmail.com"
+			password := "New12321_new"
+
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			sCtx.Require().NotEmpty(resp.RefreshToken, "Наличие refresh токена")
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err)
+
+			sessionsResp, err := controllerService.ListSessions(authorizedContext(ctx, resp.Token), &pb.ListSessionsRequest{})
+			sCtx.Require().NoError(err)
+			sCtx.Require().Len(sessionsResp.Sessions, 1)
+
+			refreshToken = resp.RefreshToken
+			userID = verifyResp.UserId
+			sessionID = sessionsResp.Sessions[0].Id
+		})
+
+		t.WithNewStep("Service keys are rejected", func(sCtx provider.StepCtx) {
+			for _, token := range []string{"family:" + sessionID, "user:" + userID + ":sessions"} {
+				resp, err := controllerService.Refresh(ctx, refreshRequest(token))
+				sCtx.Assert().ErrorIs(err, domain.ErrNoSuchRefreshToken, token)
+				sCtx.Assert().Nil(resp, "Response nil, сессия не перехвачена")
+
+				_, err = controllerService.Logout(ctx, logoutRequest(token))
+				sCtx.Assert().ErrorIs(err, domain.ErrNoSuchRefreshToken, token)
+			}
+		})
+
+		t.WithNewStep("Session is intact", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Refresh(ctx, refreshRequest(refreshToken))
+
+			sCtx.Assert().NoError(err, "Настоящий refresh токен работает")
+			sCtx.Assert().NotNil(resp)
+		})
+	})
+}
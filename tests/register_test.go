@@ -3,8 +3,11 @@ package tests
 import (
 	"crypto_analyzer_auth_service/gen/go"
 	"crypto_analyzer_auth_service/internal/domain"
+	"errors"
+	"fmt"
 	"github.com/ozontech/allure-go/pkg/framework/provider"
 	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"sync"
 	"testing"
 )
 
@@ -183,3 +186,92 @@ func TestRegisterNoPassword(tt *testing.T) {
 		})
 	})
 }
+
+func TestRegisterUsernameExistsCaseInsensitive(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Register with username exists in another case", func(t provider.T) {
+		t.WithNewStep("Register first time", func(sCtx provider.StepCtx) {
+			username := "Shellshocker"
+			email := "newemail@gmail.com"
+			password := "New12321_new"
+
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Ошибки нет, регистрация выполнена")
+		})
+
+		t.WithNewStep("Register username exists", func(sCtx provider.StepCtx) {
+			username := "SHELLSHOCKER"
+			email := "newemail25@gmail.com"
+			password := "New12321_new"
+
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, nil)
+			})
+
+			sCtx.Assert().ErrorIs(err, domain.ErrUsernameAlreadyTaken)
+			sCtx.Assert().Nil(resp, "Response nil, регистрация не выполнена")
+		})
+	})
+}
+
+func TestRegisterConcurrentSameEmail(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	const attempts = 10
+
+	runner.Run(tt, "Concurrent registrations with the same email", func(t provider.T) {
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		t.WithNewStep("Parallel register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			var wg sync.WaitGroup
+			responses := make([]*auth.RegisterResponse, attempts)
+			errs := make([]error, attempts)
+
+			for i := 0; i < attempts; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					username := fmt.Sprintf("Shellshocker%d", 100+i)
+					responses[i], errs[i] = controllerService.Register(ctx, registerRequest(username, email, password))
+				}(i)
+			}
+			wg.Wait()
+
+			var created int
+			for i := 0; i < attempts; i++ {
+				if errs[i] == nil {
+					created++
+					resp := responses[i]
+					t.Cleanup(func() {
+						cleanUserByEmail(ctx, tt, email, resp)
+					})
+					continue
+				}
+
+				var conflictErr *domain.ConflictError
+				sCtx.Assert().True(errors.As(errs[i], &conflictErr), "Конфликт уникальности, а не внутренняя ошибка")
+				sCtx.Assert().ErrorIs(errs[i], domain.ErrEmailAlreadyTaken)
+				if conflictErr != nil {
+					sCtx.Assert().Equal("email", conflictErr.Field, "Конфликт по полю email")
+				}
+			}
+
+			sCtx.Assert().Equal(1, created, "Зарегистрирован ровно один пользователь")
+		})
+	})
+}
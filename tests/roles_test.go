@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/service"
+	"github.com/google/uuid"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"testing"
+)
+
+// rolesTestService — копия сервиса с токеном администратора для первого назначения ролей
+func rolesTestService() *service.ControllerService {
+	s := *controllerService
+	s.AdminCfg = &model.AdminConfig{Token: testAdminToken}
+
+	return &s
+}
+
+func TestRoles(tt *testing.T) {
+	ctx := newTestContext(tt)
+	s := rolesTestService()
+
+	runner.Run(tt, "Assign roles and authorize by scope", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		var userID string
+		var userCtx context.Context
+		adminCtx := adminContext(ctx, testAdminToken)
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := s.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err)
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Empty(verifyResp.Roles, "У нового пользователя нет ролей")
+			sCtx.Assert().Empty(verifyResp.Scope)
+
+			userID = verifyResp.UserId
+			userCtx = authorizedContext(ctx, resp.Token)
+		})
+
+		t.WithNewStep("Role methods require permission", func(sCtx provider.StepCtx) {
+			_, err := authorizeCall(ctx, s, "ListRoles")
+			sCtx.Assert().ErrorIs(err, domain.ErrAdminRequired, "Без токенов")
+
+			_, err = authorizeCall(adminContext(ctx, "wrong"), s, "ListRoles")
+			sCtx.Assert().ErrorIs(err, domain.ErrAdminRequired, "Неверный ADMIN_API_TOKEN")
+
+			_, err = authorizeCall(userCtx, s, "AssignRole")
+			sCtx.Assert().ErrorIs(err, domain.ErrPermissionDenied, "Пользователь без роли не назначает себе роли")
+
+			_, err = s.ListRoles(adminCtx, &pb.ListRolesRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrPermissionDenied, "Вызов в обход AuthorizationInterceptor не выполняется")
+
+			readCtx, err := authorizeCall(adminCtx, s, "ListRoles")
+			sCtx.Require().NoError(err)
+			_, err = s.AssignRole(readCtx, &pb.AssignRoleRequest{UserId: userID, Role: "admin"})
+			sCtx.Assert().ErrorIs(err, domain.ErrPermissionDenied, "Проверка одного разрешения не открывает другое")
+		})
+
+		t.WithNewStep("List roles", func(sCtx provider.StepCtx) {
+			callCtx, err := authorizeCall(adminCtx, s, "ListRoles")
+			sCtx.Require().NoError(err)
+
+			resp, err := s.ListRoles(callCtx, &pb.ListRolesRequest{})
+			sCtx.Require().NoError(err)
+
+			var admin *pb.Role
+			for _, role := range resp.Roles {
+				if role.Name == "admin" {
+					admin = role
+				}
+			}
+			sCtx.Require().NotNil(admin, "Роль admin создаётся миграцией")
+			sCtx.Assert().Contains(admin.Permissions, domain.PermissionRolesManage)
+		})
+
+		t.WithNewStep("Assign role", func(sCtx provider.StepCtx) {
+			callCtx, err := authorizeCall(adminCtx, s, "AssignRole")
+			sCtx.Require().NoError(err)
+
+			_, err = s.AssignRole(callCtx, &pb.AssignRoleRequest{UserId: userID})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoRole)
+
+			_, err = s.AssignRole(callCtx, &pb.AssignRoleRequest{UserId: userID, Role: "superuser"})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchRole)
+
+			_, err = s.AssignRole(callCtx, &pb.AssignRoleRequest{UserId: uuid.NewString(), Role: "admin"})
+			sCtx.Assert().ErrorIs(err, domain.ErrNilUser)
+
+			resp, err := s.AssignRole(callCtx, &pb.AssignRoleRequest{UserId: userID, Role: "admin"})
+			sCtx.Require().NoError(err)
+			sCtx.Assert().True(resp.Assigned)
+
+			resp, err = s.AssignRole(callCtx, &pb.AssignRoleRequest{UserId: userID, Role: "admin"})
+			sCtx.Require().NoError(err)
+			sCtx.Assert().False(resp.Assigned, "Повторное назначение ничего не меняет")
+		})
+
+		t.WithNewStep("Roles and scope in access token", func(sCtx provider.StepCtx) {
+			loginResp, err := s.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+
+			verifyResp, err := verifyRequest(loginResp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Equal([]string{"admin"}, verifyResp.Roles)
+			sCtx.Assert().Equal("accounts:unlock roles:manage roles:read", verifyResp.Scope)
+
+			userCtx = authorizedContext(ctx, loginResp.Token)
+		})
+
+		t.WithNewStep("Access token with scope authorizes role methods", func(sCtx provider.StepCtx) {
+			manageCtx, err := authorizeCall(userCtx, s, "AssignRole")
+			sCtx.Require().NoError(err, "Разрешение roles:manage из access токена")
+
+			_, err = s.AssignRole(manageCtx, &pb.AssignRoleRequest{UserId: userID, Role: "support"})
+			sCtx.Require().NoError(err, "Администратор назначает роль по access токену")
+
+			readCtx, err := authorizeCall(userCtx, s, "GetUserRoles")
+			sCtx.Require().NoError(err)
+
+			resp, err := s.GetUserRoles(readCtx, &pb.GetUserRolesRequest{UserId: userID})
+			sCtx.Require().NoError(err)
+			sCtx.Require().Len(resp.Roles, 2)
+			sCtx.Assert().Equal("admin", resp.Roles[0].Name)
+			sCtx.Assert().Equal("support", resp.Roles[1].Name)
+
+			_, err = s.GetUserRoles(readCtx, &pb.GetUserRolesRequest{UserId: uuid.NewString()})
+			sCtx.Assert().ErrorIs(err, domain.ErrNilUser)
+		})
+
+		t.WithNewStep("Revoke role", func(sCtx provider.StepCtx) {
+			callCtx, err := authorizeCall(adminCtx, s, "RevokeRole")
+			sCtx.Require().NoError(err)
+
+			for _, role := range []string{"admin", "support"} {
+				resp, err := s.RevokeRole(callCtx, &pb.RevokeRoleRequest{UserId: userID, Role: role})
+				sCtx.Require().NoError(err)
+				sCtx.Assert().True(resp.Revoked, role)
+			}
+
+			resp, err := s.RevokeRole(callCtx, &pb.RevokeRoleRequest{UserId: userID, Role: "admin"})
+			sCtx.Require().NoError(err)
+			sCtx.Assert().False(resp.Revoked, "Роль уже снята")
+
+			loginResp, err := s.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+
+			verifyResp, err := verifyRequest(loginResp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Empty(verifyResp.Roles, "Новые токены выпускаются без снятых ролей")
+
+			_, err = authorizeCall(authorizedContext(ctx, loginResp.Token), s, "ListRoles")
+			sCtx.Assert().ErrorIs(err, domain.ErrPermissionDenied)
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+func TestSessionsListAndRevoke(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "List and revoke sessions", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		var accessToken, secondRefreshToken string
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			sCtx.Require().NotNil(resp, "Response не nil, регистрация успешна")
+
+			accessToken = resp.Token
+		})
+
+		t.WithNewStep("Login from another device", func(sCtx provider.StepCtx) {
+			md := metadata.Pairs("x-client-name", "mobile", "user-agent", "test-agent")
+			resp, err := controllerService.Login(metadata.NewIncomingContext(ctx, md), loginRequest(username, "", password))
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, данные корректны")
+			sCtx.Require().NotNil(resp, "Response не nil, логин успешен")
+
+			secondRefreshToken = resp.RefreshToken
+		})
+
+		var mobileSessionID string
+
+		t.WithNewStep("List sessions", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.ListSessions(authorizedContext(ctx, accessToken), &pb.ListSessionsRequest{})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, access token valid")
+			sCtx.Require().Len(resp.Sessions, 2, "Две сессии: регистрация и логин")
+
+			for _, session := range resp.Sessions {
+				sCtx.Assert().NotZero(session.CreatedAt, "Время создания сессии")
+				if session.ClientName == "mobile" {
+					mobileSessionID = session.Id
+					sCtx.Assert().Equal("test-agent", session.UserAgent, "User agent сохранён")
+				}
+			}
+			sCtx.Require().NotEmpty(mobileSessionID, "Сессия с именем клиента найдена")
+		})
+
+		t.WithNewStep("Revoke session", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.RevokeSession(authorizedContext(ctx, accessToken), &pb.RevokeSessionRequest{SessionId: mobileSessionID})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, сессия принадлежит пользователю")
+			sCtx.Require().NotNil(resp, "Response не nil, сессия отозвана")
+
+			_, err = controllerService.Refresh(ctx, refreshRequest(secondRefreshToken))
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchRefreshToken, "Refresh токен отозванной сессии не работает")
+		})
+
+		t.WithNewStep("Revoke unknown session", func(sCtx provider.StepCtx) {
+			_, err := controllerService.RevokeSession(authorizedContext(ctx, accessToken), &pb.RevokeSessionRequest{SessionId: mobileSessionID})
+
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchSession)
+		})
+
+		t.WithNewStep("Revoke all sessions", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.RevokeAllSessions(authorizedContext(ctx, accessToken), &pb.RevokeAllSessionsRequest{})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, access token valid")
+			sCtx.Assert().EqualValues(1, resp.Revoked, "Осталась одна сессия")
+
+			_, err = controllerService.ListSessions(authorizedContext(ctx, accessToken), &pb.ListSessionsRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrRevokedAccessToken, "Access токен отозван вместе с сессиями")
+		})
+	})
+}
+
+func TestSessionsWithoutAccessToken(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "List sessions without access token", func(t provider.T) {
+		t.WithNewStep("List sessions without access token", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.ListSessions(ctx, &pb.ListSessionsRequest{})
+
+			sCtx.Assert().ErrorIs(err, domain.ErrNoAccessToken)
+			sCtx.Assert().Nil(resp, "Response nil")
+		})
+	})
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/totp"
+	"encoding/base32"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTP(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "Enroll TOTP, login with second factor and disable it", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		var accessToken string
+		var secret []byte
+		var recoveryCodes []string
+		var confirmStep int64
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			accessToken = resp.Token
+		})
+
+		t.WithNewStep("Enroll TOTP", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.EnrollTOTP(authorizedContext(ctx, accessToken), &pb.EnrollTOTPRequest{})
+			sCtx.Require().NoError(err, "Подключение начато")
+
+			secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(resp.Secret)
+			sCtx.Require().NoError(err, "Секрет в base32")
+			sCtx.Assert().Len(secret, totp.SecretSize)
+			sCtx.Assert().True(strings.HasPrefix(resp.OtpauthUri, "otpauth://totp/"), "otpauth ссылка")
+			sCtx.Assert().Contains(resp.OtpauthUri, "secret="+resp.Secret)
+			sCtx.Assert().Len(resp.RecoveryCodes, 10, "Коды восстановления")
+			recoveryCodes = resp.RecoveryCodes
+		})
+
+		t.WithNewStep("Login before confirmation", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+			sCtx.Assert().False(resp.MfaRequired, "Неподтверждённая 2FA не требуется")
+			sCtx.Assert().NotEmpty(resp.Token)
+		})
+
+		t.WithNewStep("Confirm TOTP", func(sCtx provider.StepCtx) {
+			authCtx := authorizedContext(ctx, accessToken)
+			confirmStep = totp.Step(time.Now())
+
+			_, err := controllerService.ConfirmTOTP(authCtx, &pb.ConfirmTOTPRequest{Code: totp.Code(secret, confirmStep+5)})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidMFACode, "Код другого шага не подходит")
+
+			_, err = controllerService.ConfirmTOTP(authCtx, &pb.ConfirmTOTPRequest{Code: totp.Code(secret, confirmStep)})
+			sCtx.Require().NoError(err, "2FA включена")
+
+			_, err = controllerService.EnrollTOTP(authCtx, &pb.EnrollTOTPRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrTOTPAlreadyEnabled, "Включённую 2FA нельзя перезаписать")
+		})
+
+		t.WithNewStep("Login with TOTP code", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+			sCtx.Require().True(resp.MfaRequired, "Нужен второй фактор")
+			sCtx.Assert().Empty(resp.Token, "Access токен не выдан")
+			sCtx.Assert().Empty(resp.RefreshToken, "Refresh токен не выдан")
+
+			_, err = controllerService.CompleteMFA(ctx, &pb.CompleteMFARequest{MfaToken: resp.MfaToken, Code: totp.Code(secret, confirmStep)})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidMFACode, "Уже принятый код не принимается повторно")
+
+			completed, err := controllerService.CompleteMFA(ctx, &pb.CompleteMFARequest{MfaToken: resp.MfaToken, Code: totp.Code(secret, confirmStep+1)})
+			sCtx.Require().NoError(err, "Код следующего шага принят")
+			sCtx.Assert().NotEmpty(completed.Token)
+			sCtx.Assert().NotEmpty(completed.RefreshToken)
+
+			_, err = verifyRequest(completed.Token)
+			sCtx.Assert().NoError(err, "Выданный access токен работает")
+
+			_, err = controllerService.CompleteMFA(ctx, &pb.CompleteMFARequest{MfaToken: resp.MfaToken, Code: recoveryCodes[0]})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidMFAToken, "Токен челленджа одноразовый")
+		})
+
+		t.WithNewStep("Login with recovery code", func(sCtx provider.StepCtx) {
+			resp, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+			sCtx.Require().True(resp.MfaRequired)
+
+			_, err = controllerService.CompleteMFA(ctx, &pb.CompleteMFARequest{MfaToken: resp.MfaToken, Code: strings.ToUpper(recoveryCodes[0])})
+			sCtx.Require().NoError(err, "Код восстановления принят без учёта регистра")
+
+			resp, err = controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+
+			_, err = controllerService.CompleteMFA(ctx, &pb.CompleteMFARequest{MfaToken: resp.MfaToken, Code: recoveryCodes[0]})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidMFACode, "Код восстановления одноразовый")
+		})
+
+		t.WithNewStep("Disable TOTP", func(sCtx provider.StepCtx) {
+			authCtx := authorizedContext(ctx, accessToken)
+
+			_, err := controllerService.DisableTOTP(authCtx, &pb.DisableTOTPRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoMFACode, "Без кода 2FA не отключается")
+
+			_, err = controllerService.DisableTOTP(authCtx, &pb.DisableTOTPRequest{Code: recoveryCodes[1]})
+			sCtx.Require().NoError(err, "2FA отключена кодом восстановления")
+
+			resp, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+			sCtx.Assert().False(resp.MfaRequired, "После отключения второй фактор не нужен")
+			sCtx.Assert().NotEmpty(resp.Token)
+		})
+	})
+}
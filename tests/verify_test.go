@@ -86,3 +86,87 @@ func TestVerifyWithoutAccessToken(tt *testing.T) {
 		})
 	})
 }
+
+func TestVerifyAccessTokenAfterLogout(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	var accessToken, refreshToken string
+
+	username := "Shellshocker25"
+	email := "newemail25@gmail.com"
+	password := "New12321_new"
+
+	runner.Run(tt, "Verify access token after logout", func(t provider.T) {
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, nil)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			sCtx.Require().NotNil(resp, "Response не nil, регистрация успешна")
+
+			accessToken = resp.GetToken()
+			refreshToken = resp.GetRefreshToken()
+		})
+
+		t.WithNewStep("Logout", func(sCtx provider.StepCtx) {
+			_, err := controllerService.Logout(ctx, logoutRequest(refreshToken))
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, refresh token valid")
+		})
+
+		t.WithNewStep("Verify access token after logout", func(sCtx provider.StepCtx) {
+			resp, err := verifyRequest(accessToken)
+
+			sCtx.Assert().ErrorIs(err, domain.ErrRevokedAccessToken)
+			sCtx.Assert().Nil(resp, "Response nil, access токен отозван")
+		})
+	})
+}
+
+func TestVerifyAccessTokenAfterRevokeAll(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	var accessToken, otherAccessToken string
+
+	username := "Shellshocker25"
+	email := "newemail25@gmail.com"
+	password := "New12321_new"
+
+	runner.Run(tt, "Verify access tokens after revoking all sessions", func(t provider.T) {
+		t.WithNewStep("Register and login", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := controllerService.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, nil)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			accessToken = resp.GetToken()
+
+			loginResp, err := controllerService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err, "Отсутствие ошибки, данные корректны")
+			otherAccessToken = loginResp.GetToken()
+		})
+
+		t.WithNewStep("Revoke all sessions", func(sCtx provider.StepCtx) {
+			_, err := controllerService.RevokeAllSessions(authorizedContext(ctx, accessToken), &pb.RevokeAllSessionsRequest{})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки, access token valid")
+		})
+
+		t.WithNewStep("Verify access tokens after revoke all", func(sCtx provider.StepCtx) {
+			_, err := verifyRequest(accessToken)
+			sCtx.Assert().ErrorIs(err, domain.ErrRevokedAccessToken)
+
+			_, err = verifyRequest(otherAccessToken)
+			sCtx.Assert().ErrorIs(err, domain.ErrRevokedAccessToken)
+		})
+	})
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/ethereum"
+	"crypto_analyzer_auth_service/internal/infrastructure/siwe"
+	"crypto_analyzer_auth_service/internal/service"
+	"encoding/hex"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"testing"
+	"time"
+)
+
+const siweTestDomain = "localhost:3000"
+
+// siweTestService — копия сервиса с настроенным входом через кошелёк: в .env.test он может быть выключен
+func siweTestService() *service.ControllerService {
+	s := *controllerService
+	s.SiweCfg = &model.SiweConfig{
+		Domain:     siweTestDomain,
+		ChainIDs:   []int64{1, 137},
+		NonceTTL:   time.Minute,
+		MaxWallets: 10,
+	}
+
+	return &s
+}
+
+// cleanUserByWallet удаляет аккаунт, к которому привязан адрес
+func cleanUserByWallet(t *testing.T, address ethereum.Address) {
+	_, err := DB.Exec(`DELETE FROM users WHERE uuid IN (SELECT user_uuid FROM wallet_addresses WHERE address = $1)`, address.Hex())
+	if err != nil {
+		t.Logf("failed to delete user by wallet %s: %v", address, err)
+	}
+}
+
+// siweMessage запрашивает nonce и собирает сообщение EIP-4361 для key; edit меняет поля до подписи
+func siweMessage(ctx context.Context, sCtx provider.StepCtx, s *service.ControllerService, key *ethereum.PrivateKey,
+	edit func(m *siwe.Message)) string {
+	nonceResp, err := s.GetSiweNonce(ctx, &pb.GetSiweNonceRequest{})
+	sCtx.Require().NoError(err)
+	sCtx.Require().Equal(siweTestDomain, nonceResp.Domain)
+
+	return buildSiweMessage(key, nonceResp.Nonce, nonceResp.Domain, nonceResp.ChainIds[0], edit)
+}
+
+func buildSiweMessage(key *ethereum.PrivateKey, nonce, domain string, chainID int64, edit func(m *siwe.Message)) string {
+	message := &siwe.Message{
+		Domain:    domain,
+		Address:   key.Address(),
+		Statement: "Sign in to Crypto Analyzer",
+		URI:       "http://" + siweTestDomain,
+		Version:   siwe.Version,
+		ChainID:   chainID,
+		Nonce:     nonce,
+		IssuedAt:  time.Now(),
+	}
+	if edit != nil {
+		edit(message)
+	}
+
+	return message.String()
+}
+
+// walletLoginRequest подписывает сообщение так же, как personal_sign в кошельке
+func walletLoginRequest(sCtx provider.StepCtx, key *ethereum.PrivateKey, message string) *pb.LoginWithWalletRequest {
+	signature, err := key.SignPersonal([]byte(message))
+	sCtx.Require().NoError(err)
+
+	return &pb.LoginWithWalletRequest{Message: message, Signature: "0x" + hex.EncodeToString(signature)}
+}
+
+func TestWalletLogin(tt *testing.T) {
+	ctx := newTestContext(tt)
+	s := siweTestService()
+
+	runner.Run(tt, "Sign in with Ethereum wallet", func(t provider.T) {
+		var key *ethereum.PrivateKey
+		var userID string
+
+		t.WithNewStep("First login creates account", func(sCtx provider.StepCtx) {
+			var err error
+			key, err = ethereum.GenerateKey()
+			sCtx.Require().NoError(err)
+
+			t.Cleanup(func() {
+				cleanUserByWallet(tt, key.Address())
+			})
+
+			resp, err := s.LoginWithWallet(ctx, walletLoginRequest(sCtx, key, siweMessage(ctx, sCtx, s, key, nil)))
+			sCtx.Require().NoError(err, "Вход по подписи кошелька")
+			sCtx.Assert().True(resp.NewAccount, "Для нового адреса создаётся аккаунт")
+			sCtx.Assert().NotEmpty(resp.RefreshToken)
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Equal(key.Address().String(), verifyResp.Username, "Имя аккаунта — адрес")
+			sCtx.Assert().Empty(verifyResp.Email)
+			userID = verifyResp.UserId
+		})
+
+		t.WithNewStep("Next login returns same account", func(sCtx provider.StepCtx) {
+			resp, err := s.LoginWithWallet(ctx, walletLoginRequest(sCtx, key, siweMessage(ctx, sCtx, s, key, func(m *siwe.Message) {
+				m.ChainID = 137
+			})))
+			sCtx.Require().NoError(err)
+			sCtx.Assert().False(resp.NewAccount)
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Equal(userID, verifyResp.UserId)
+		})
+
+		t.WithNewStep("Nonce is one-time", func(sCtx provider.StepCtx) {
+			req := walletLoginRequest(sCtx, key, siweMessage(ctx, sCtx, s, key, nil))
+
+			_, err := s.LoginWithWallet(ctx, req)
+			sCtx.Require().NoError(err)
+
+			_, err = s.LoginWithWallet(ctx, req)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidSiweNonce, "Подписанное сообщение нельзя предъявить повторно")
+
+			_, err = s.LoginWithWallet(ctx, walletLoginRequest(sCtx, key, siweMessage(ctx, sCtx, s, key, func(m *siwe.Message) {
+				m.Nonce = "0123456789abcdef"
+			})))
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidSiweNonce, "Nonce, который сервер не выдавал")
+		})
+
+		t.WithNewStep("Message fields are checked", func(sCtx provider.StepCtx) {
+			for name, edit := range map[string]func(m *siwe.Message){
+				"domain":  func(m *siwe.Message) { m.Domain = "phishing.example" },
+				"chain":   func(m *siwe.Message) { m.ChainID = 56 },
+				"expired": func(m *siwe.Message) { m.ExpirationTime = time.Now().Add(-time.Second) },
+			} {
+				_, err := s.LoginWithWallet(ctx, walletLoginRequest(sCtx, key, siweMessage(ctx, sCtx, s, key, edit)))
+				sCtx.Assert().ErrorIs(err, domain.ErrInvalidSiweMessage, name)
+			}
+
+			_, err := s.LoginWithWallet(ctx, &pb.LoginWithWalletRequest{Message: "hello", Signature: "0x00"})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidSiweMessage, "Не сообщение EIP-4361")
+		})
+
+		t.WithNewStep("Signature must match address", func(sCtx provider.StepCtx) {
+			other, err := ethereum.GenerateKey()
+			sCtx.Require().NoError(err)
+
+			_, err = s.LoginWithWallet(ctx, walletLoginRequest(sCtx, other, siweMessage(ctx, sCtx, s, key, nil)))
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWalletSignature, "Сообщение от имени чужого адреса")
+
+			req := walletLoginRequest(sCtx, key, siweMessage(ctx, sCtx, s, key, nil))
+			req.Signature = req.Signature[:20]
+			_, err = s.LoginWithWallet(ctx, req)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWalletSignature, "Обрезанная подпись")
+		})
+
+		t.WithNewStep("Wallet account has no password", func(sCtx provider.StepCtx) {
+			_, err := s.Login(ctx, loginRequest(key.Address().String(), "", "New12321_new"))
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidCredentials)
+		})
+	})
+}
+
+func TestWalletLoginNotConfigured(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	s := *controllerService
+	s.SiweCfg = &model.SiweConfig{}
+
+	runner.Run(tt, "Wallet login without SIWE_DOMAIN", func(t provider.T) {
+		t.WithNewStep("Nonce and login", func(sCtx provider.StepCtx) {
+			_, err := s.GetSiweNonce(ctx, &pb.GetSiweNonceRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrSiweNotConfigured)
+
+			resp, err := s.LoginWithWallet(ctx, &pb.LoginWithWalletRequest{Message: "message", Signature: "0x00"})
+			sCtx.Assert().ErrorIs(err, domain.ErrSiweNotConfigured)
+			sCtx.Assert().Nil(resp)
+		})
+	})
+}
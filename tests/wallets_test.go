@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/ethereum"
+	"crypto_analyzer_auth_service/internal/infrastructure/siwe"
+	"crypto_analyzer_auth_service/internal/service"
+	"encoding/hex"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"testing"
+)
+
+// linkWalletRequest запрашивает nonce привязки от имени владельца ctx и подписывает сообщение ключом key
+func linkWalletRequest(ctx context.Context, sCtx provider.StepCtx, s *service.ControllerService, key *ethereum.PrivateKey,
+	edit func(m *siwe.Message)) *pb.LinkWalletRequest {
+	nonceResp, err := s.GetWalletLinkNonce(ctx, &pb.GetWalletLinkNonceRequest{})
+	sCtx.Require().NoError(err)
+
+	message := buildSiweMessage(key, nonceResp.Nonce, nonceResp.Domain, nonceResp.ChainIds[0], edit)
+
+	signature, err := key.SignPersonal([]byte(message))
+	sCtx.Require().NoError(err)
+
+	return &pb.LinkWalletRequest{Message: message, Signature: "0x" + hex.EncodeToString(signature)}
+}
+
+func TestLinkWallets(tt *testing.T) {
+	ctx := newTestContext(tt)
+	s := siweTestService()
+
+	runner.Run(tt, "Link wallet addresses to account", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		var authCtx context.Context
+		var first, second, foreign *ethereum.PrivateKey
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := s.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err)
+			authCtx = authorizedContext(ctx, resp.Token)
+
+			for _, key := range []**ethereum.PrivateKey{&first, &second, &foreign} {
+				*key, err = ethereum.GenerateKey()
+				sCtx.Require().NoError(err)
+			}
+
+			t.Cleanup(func() {
+				cleanUserByWallet(tt, foreign.Address())
+			})
+		})
+
+		t.WithNewStep("Linking requires access token", func(sCtx provider.StepCtx) {
+			_, err := s.GetWalletLinkNonce(ctx, &pb.GetWalletLinkNonceRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoAccessToken)
+
+			_, err = s.LinkWallet(ctx, &pb.LinkWalletRequest{Message: "message", Signature: "0x00"})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoAccessToken)
+		})
+
+		t.WithNewStep("Link wallet", func(sCtx provider.StepCtx) {
+			resp, err := s.LinkWallet(authCtx, linkWalletRequest(authCtx, sCtx, s, first, nil))
+			sCtx.Require().NoError(err, "Привязка адреса с подтверждением владения")
+			sCtx.Assert().Equal(first.Address().String(), resp.Wallet.Address, "Адрес в формате EIP-55")
+			sCtx.Assert().NotZero(resp.Wallet.CreatedAt)
+
+			resp, err = s.LinkWallet(authCtx, linkWalletRequest(authCtx, sCtx, s, first, nil))
+			sCtx.Require().NoError(err, "Повторная привязка своего адреса не ошибка")
+			sCtx.Assert().Equal(first.Address().String(), resp.Wallet.Address)
+		})
+
+		t.WithNewStep("Ownership proof is checked", func(sCtx provider.StepCtx) {
+			req := linkWalletRequest(authCtx, sCtx, s, second, nil)
+			signature, err := first.SignPersonal([]byte(req.Message))
+			sCtx.Require().NoError(err)
+			req.Signature = "0x" + hex.EncodeToString(signature)
+
+			_, err = s.LinkWallet(authCtx, req)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWalletSignature, "Подпись другим ключом")
+
+			_, err = s.LinkWallet(authCtx, linkWalletRequest(authCtx, sCtx, s, second, nil))
+			sCtx.Require().NoError(err)
+
+			_, err = s.LinkWallet(authCtx, req)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidSiweNonce, "Nonce одноразовый")
+
+			nonceResp, err := s.GetSiweNonce(ctx, &pb.GetSiweNonceRequest{})
+			sCtx.Require().NoError(err)
+			message := buildSiweMessage(foreign, nonceResp.Nonce, nonceResp.Domain, nonceResp.ChainIds[0], nil)
+			signature, err = foreign.SignPersonal([]byte(message))
+			sCtx.Require().NoError(err)
+
+			_, err = s.LinkWallet(authCtx, &pb.LinkWalletRequest{Message: message, Signature: "0x" + hex.EncodeToString(signature)})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidSiweNonce, "Nonce входа не годится для привязки")
+
+			_, err = s.LinkWallet(authCtx, linkWalletRequest(authCtx, sCtx, s, foreign, func(m *siwe.Message) {
+				m.Domain = "phishing.example"
+			}))
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidSiweMessage, "Чужой домен")
+		})
+
+		t.WithNewStep("Address belongs to one user", func(sCtx provider.StepCtx) {
+			loginResp, err := s.LoginWithWallet(ctx, walletLoginRequest(sCtx, foreign, siweMessage(ctx, sCtx, s, foreign, nil)))
+			sCtx.Require().NoError(err)
+			sCtx.Require().True(loginResp.NewAccount)
+
+			_, err = s.LinkWallet(authCtx, linkWalletRequest(authCtx, sCtx, s, foreign, nil))
+			sCtx.Assert().ErrorIs(err, domain.ErrWalletAlreadyLinked, "Адрес другого аккаунта не переносится")
+
+			foreignCtx := authorizedContext(ctx, loginResp.Token)
+			_, err = s.LinkWallet(foreignCtx, linkWalletRequest(foreignCtx, sCtx, s, first, nil))
+			sCtx.Assert().ErrorIs(err, domain.ErrWalletAlreadyLinked)
+
+			_, err = s.UnlinkWallet(foreignCtx, &pb.UnlinkWalletRequest{Address: foreign.Address().String()})
+			sCtx.Assert().ErrorIs(err, domain.ErrLastLoginMethod, "Единственный способ входа не отвязывается")
+		})
+
+		t.WithNewStep("List wallets", func(sCtx provider.StepCtx) {
+			resp, err := s.ListWallets(authCtx, &pb.ListWalletsRequest{})
+			sCtx.Require().NoError(err)
+			sCtx.Require().Len(resp.Wallets, 2)
+			sCtx.Assert().Equal(first.Address().String(), resp.Wallets[0].Address, "Адреса в порядке привязки")
+			sCtx.Assert().Equal(second.Address().String(), resp.Wallets[1].Address)
+		})
+
+		t.WithNewStep("Linked wallet signs in to account", func(sCtx provider.StepCtx) {
+			resp, err := s.LoginWithWallet(ctx, walletLoginRequest(sCtx, second, siweMessage(ctx, sCtx, s, second, nil)))
+			sCtx.Require().NoError(err)
+			sCtx.Assert().False(resp.NewAccount)
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Equal(email, verifyResp.Email)
+			sCtx.Assert().Empty(verifyResp.Wallets, "Claim wallets выключен")
+		})
+
+		t.WithNewStep("Wallets claim", func(sCtx provider.StepCtx) {
+			claimService := *s
+			siweCfg := *s.SiweCfg
+			siweCfg.WalletsClaim = true
+			claimService.SiweCfg = &siweCfg
+
+			resp, err := claimService.Login(ctx, loginRequest(username, "", password))
+			sCtx.Require().NoError(err)
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err)
+			sCtx.Assert().ElementsMatch([]string{first.Address().Hex(), second.Address().Hex()}, verifyResp.Wallets)
+		})
+
+		t.WithNewStep("Wallet limit", func(sCtx provider.StepCtx) {
+			limitService := *s
+			siweCfg := *s.SiweCfg
+			siweCfg.MaxWallets = 2
+			limitService.SiweCfg = &siweCfg
+
+			third, err := ethereum.GenerateKey()
+			sCtx.Require().NoError(err)
+
+			_, err = limitService.LinkWallet(authCtx, linkWalletRequest(authCtx, sCtx, &limitService, third, nil))
+			sCtx.Assert().ErrorIs(err, domain.ErrWalletLimitReached)
+
+			_, err = limitService.LinkWallet(authCtx, linkWalletRequest(authCtx, sCtx, &limitService, first, nil))
+			sCtx.Assert().NoError(err, "Повторная привязка своего адреса не упирается в лимит")
+		})
+
+		t.WithNewStep("Unlink wallet", func(sCtx provider.StepCtx) {
+			_, err := s.UnlinkWallet(authCtx, &pb.UnlinkWalletRequest{Address: "0x123"})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWalletAddress)
+
+			_, err = s.UnlinkWallet(authCtx, &pb.UnlinkWalletRequest{Address: foreign.Address().Hex()})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchWallet, "Чужой адрес не отвязывается")
+
+			_, err = s.UnlinkWallet(authCtx, &pb.UnlinkWalletRequest{Address: first.Address().Hex()})
+			sCtx.Require().NoError(err)
+
+			_, err = s.UnlinkWallet(authCtx, &pb.UnlinkWalletRequest{Address: first.Address().String()})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoSuchWallet)
+
+			_, err = s.UnlinkWallet(authCtx, &pb.UnlinkWalletRequest{Address: second.Address().String()})
+			sCtx.Require().NoError(err, "У аккаунта есть пароль, последний адрес отвязывается")
+
+			resp, err := s.ListWallets(authCtx, &pb.ListWalletsRequest{})
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Empty(resp.Wallets)
+		})
+	})
+}
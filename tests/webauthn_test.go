@@ -0,0 +1,217 @@
+package tests
+
+import (
+	pb "crypto_analyzer_auth_service/gen/go"
+	"crypto_analyzer_auth_service/internal/config/model"
+	"crypto_analyzer_auth_service/internal/domain"
+	"crypto_analyzer_auth_service/internal/infrastructure/webauthn/webauthntest"
+	"crypto_analyzer_auth_service/internal/service"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/runner"
+	"testing"
+	"time"
+)
+
+const (
+	webAuthnTestRPID   = "localhost"
+	webAuthnTestOrigin = "http://localhost:3000"
+)
+
+// webAuthnTestService — копия сервиса с настроенным RP: в .env.test WebAuthn может быть выключен
+func webAuthnTestService() *service.ControllerService {
+	s := *controllerService
+	s.WebAuthnCfg = &model.WebAuthnConfig{
+		RPID:         webAuthnTestRPID,
+		RPName:       "Crypto Analyzer",
+		Origins:      []string{webAuthnTestOrigin},
+		ChallengeTTL: time.Minute,
+	}
+
+	return &s
+}
+
+func TestWebAuthn(tt *testing.T) {
+	ctx := newTestContext(tt)
+	s := webAuthnTestService()
+
+	runner.Run(tt, "Register passkey with software authenticator and log in with it", func(t provider.T) {
+		username := "Shellshocker25"
+		email := "newemail25@gmail.com"
+		password := "New12321_new"
+
+		var accessToken string
+		var authenticator *webauthntest.Authenticator
+
+		t.WithNewStep("Register", func(sCtx provider.StepCtx) {
+			cleanUserByEmail(ctx, tt, email, nil)
+
+			resp, err := s.Register(ctx, registerRequest(username, email, password))
+
+			t.Cleanup(func() {
+				cleanUserByEmail(ctx, tt, email, resp)
+			})
+
+			sCtx.Require().NoError(err, "Отсутствие ошибки при сильном пароле и корректном email")
+			accessToken = resp.Token
+
+			authenticator, err = webauthntest.New(webAuthnTestRPID, webAuthnTestOrigin)
+			sCtx.Require().NoError(err)
+		})
+
+		t.WithNewStep("Register passkey", func(sCtx provider.StepCtx) {
+			authCtx := authorizedContext(ctx, accessToken)
+
+			_, err := s.BeginWebAuthnRegistration(ctx, &pb.BeginWebAuthnRegistrationRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrNoAccessToken, "Регистрация ключа только с access токеном")
+
+			options, err := s.BeginWebAuthnRegistration(authCtx, &pb.BeginWebAuthnRegistrationRequest{})
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Equal(webAuthnTestRPID, options.RpId)
+			sCtx.Assert().Len(options.UserHandle, 16, "User handle — UUID пользователя")
+			sCtx.Assert().Equal(email, options.UserName)
+			sCtx.Assert().Contains(options.Algorithms, int64(-7), "ES256 поддерживается")
+			sCtx.Assert().Empty(options.ExcludeCredentials)
+
+			attestation, err := authenticator.Create(options.Challenge, options.UserHandle)
+			sCtx.Require().NoError(err)
+
+			resp, err := s.FinishWebAuthnRegistration(authCtx, &pb.FinishWebAuthnRegistrationRequest{
+				ClientDataJson:    attestation.ClientDataJSON,
+				AttestationObject: attestation.AttestationObject,
+			})
+			sCtx.Require().NoError(err, "Ключ зарегистрирован")
+			sCtx.Assert().Equal(authenticator.CredentialID, resp.CredentialId)
+
+			_, err = s.FinishWebAuthnRegistration(authCtx, &pb.FinishWebAuthnRegistrationRequest{
+				ClientDataJson:    attestation.ClientDataJSON,
+				AttestationObject: attestation.AttestationObject,
+			})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWebAuthnChallenge, "Челлендж одноразовый")
+		})
+
+		t.WithNewStep("Register the same passkey again", func(sCtx provider.StepCtx) {
+			authCtx := authorizedContext(ctx, accessToken)
+
+			options, err := s.BeginWebAuthnRegistration(authCtx, &pb.BeginWebAuthnRegistrationRequest{})
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Equal([][]byte{authenticator.CredentialID}, options.ExcludeCredentials,
+				"Зарегистрированный ключ исключается")
+
+			attestation, err := authenticator.Create(options.Challenge, options.UserHandle)
+			sCtx.Require().NoError(err)
+
+			_, err = s.FinishWebAuthnRegistration(authCtx, &pb.FinishWebAuthnRegistrationRequest{
+				ClientDataJson:    attestation.ClientDataJSON,
+				AttestationObject: attestation.AttestationObject,
+			})
+			sCtx.Assert().ErrorIs(err, domain.ErrWebAuthnCredentialExists)
+		})
+
+		t.WithNewStep("Log in with passkey", func(sCtx provider.StepCtx) {
+			options, err := s.BeginWebAuthnLogin(ctx, &pb.BeginWebAuthnLoginRequest{})
+			sCtx.Require().NoError(err)
+			sCtx.Assert().Equal(webAuthnTestRPID, options.RpId)
+
+			assertion, err := authenticator.Get(options.Challenge)
+			sCtx.Require().NoError(err)
+
+			req := webAuthnLoginRequest(assertion)
+
+			resp, err := s.FinishWebAuthnLogin(ctx, req)
+			sCtx.Require().NoError(err, "Вход по ключу")
+			sCtx.Assert().NotEmpty(resp.Token)
+			sCtx.Assert().NotEmpty(resp.RefreshToken)
+
+			verifyResp, err := verifyRequest(resp.Token)
+			sCtx.Require().NoError(err, "Выданный токен проходит Verify")
+			sCtx.Assert().Equal(email, verifyResp.Email)
+
+			_, err = s.FinishWebAuthnLogin(ctx, req)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWebAuthnChallenge, "Повтор ответа не принимается")
+		})
+
+		t.WithNewStep("Reject invalid assertions", func(sCtx provider.StepCtx) {
+			login := func(prepare func(a *webauthntest.Authenticator), tamper func(req *pb.FinishWebAuthnLoginRequest)) error {
+				options, err := s.BeginWebAuthnLogin(ctx, &pb.BeginWebAuthnLoginRequest{})
+				sCtx.Require().NoError(err)
+
+				copied := *authenticator
+				prepare(&copied)
+
+				assertion, err := copied.Get(options.Challenge)
+				sCtx.Require().NoError(err)
+
+				req := webAuthnLoginRequest(assertion)
+				tamper(req)
+
+				_, err = s.FinishWebAuthnLogin(ctx, req)
+				return err
+			}
+			noop := func(req *pb.FinishWebAuthnLoginRequest) {}
+
+			err := login(func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example" }, noop)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWebAuthnAssertion, "Ответ для чужого origin")
+
+			err = login(func(a *webauthntest.Authenticator) { a.SkipUserVerification = true }, noop)
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWebAuthnAssertion, "Без проверки пользователя")
+
+			err = login(func(a *webauthntest.Authenticator) {}, func(req *pb.FinishWebAuthnLoginRequest) {
+				req.UserHandle = make([]byte, 16)
+			})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWebAuthnAssertion, "Чужой user handle")
+
+			err = login(func(a *webauthntest.Authenticator) {}, func(req *pb.FinishWebAuthnLoginRequest) {
+				req.CredentialId = []byte("unknown credential")
+			})
+			sCtx.Assert().ErrorIs(err, domain.ErrInvalidWebAuthnAssertion, "Неизвестный ключ")
+		})
+
+		t.WithNewStep("Detect cloned authenticator", func(sCtx provider.StepCtx) {
+			// клон с тем же ключом, отставший по счётчику от оригинала
+			clone := *authenticator
+			clone.SignCount = 0
+
+			options, err := s.BeginWebAuthnLogin(ctx, &pb.BeginWebAuthnLoginRequest{})
+			sCtx.Require().NoError(err)
+
+			assertion, err := clone.Get(options.Challenge)
+			sCtx.Require().NoError(err)
+
+			_, err = s.FinishWebAuthnLogin(ctx, webAuthnLoginRequest(assertion))
+			sCtx.Assert().ErrorIs(err, domain.ErrWebAuthnCloneDetected, "Счётчик подписей не вырос")
+
+			options, err = s.BeginWebAuthnLogin(ctx, &pb.BeginWebAuthnLoginRequest{})
+			sCtx.Require().NoError(err)
+
+			assertion, err = authenticator.Get(options.Challenge)
+			sCtx.Require().NoError(err)
+
+			_, err = s.FinishWebAuthnLogin(ctx, webAuthnLoginRequest(assertion))
+			sCtx.Assert().NoError(err, "Оригинальный ключ продолжает работать")
+		})
+	})
+}
+
+func TestWebAuthnNotConfigured(tt *testing.T) {
+	ctx := newTestContext(tt)
+
+	runner.Run(tt, "WebAuthn without relying party", func(t provider.T) {
+		t.WithNewStep("Begin login", func(sCtx provider.StepCtx) {
+			s := *controllerService
+			s.WebAuthnCfg = &model.WebAuthnConfig{}
+
+			_, err := s.BeginWebAuthnLogin(ctx, &pb.BeginWebAuthnLoginRequest{})
+			sCtx.Assert().ErrorIs(err, domain.ErrWebAuthnNotConfigured)
+		})
+	})
+}
+
+func webAuthnLoginRequest(assertion *webauthntest.Assertion) *pb.FinishWebAuthnLoginRequest {
+	return &pb.FinishWebAuthnLoginRequest{
+		CredentialId:      assertion.CredentialID,
+		ClientDataJson:    assertion.ClientDataJSON,
+		AuthenticatorData: assertion.AuthenticatorData,
+		Signature:         assertion.Signature,
+		UserHandle:        assertion.UserHandle,
+	}
+}